@@ -0,0 +1,4 @@
+-- Rollback Infonavit seguro de daños column
+
+ALTER TABLE fiscal_years 
+DROP COLUMN IF EXISTS infonavit_insurance_bimonthly;
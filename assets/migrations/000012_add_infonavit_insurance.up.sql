@@ -0,0 +1,13 @@
+-- Add the Infonavit seguro de daños fee to fiscal_years table
+-- Charged on top of the credit amortization for every active Infonavit loan
+
+ALTER TABLE fiscal_years 
+ADD COLUMN IF NOT EXISTS infonavit_insurance_bimonthly NUMERIC(10, 2) DEFAULT 15.00;
+
+-- Add comment
+COMMENT ON COLUMN fiscal_years.infonavit_insurance_bimonthly IS 'Infonavit seguro de daños fee per bimester (pesos)';
+
+-- Update existing records with default value
+UPDATE fiscal_years 
+SET infonavit_insurance_bimonthly = 15.00 
+WHERE infonavit_insurance_bimonthly IS NULL;
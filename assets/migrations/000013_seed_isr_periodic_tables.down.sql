@@ -0,0 +1,5 @@
+-- Rollback the non-monthly ISR tariffs
+
+DELETE FROM isr_brackets
+WHERE fiscal_year_id = (SELECT id FROM fiscal_years WHERE year = 2025)
+  AND periodicity IN ('DAILY', 'WEEKLY', 'BIWEEKLY', 'SEMIMONTHLY', 'ANNUAL');
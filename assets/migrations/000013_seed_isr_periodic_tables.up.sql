@@ -0,0 +1,75 @@
+-- Seed the 2025 ISR tariffs for every non-monthly periodicity (RMF Anexo 8)
+-- Daily, weekly and quincenal tables are the official Anexo 8 tables: the monthly
+-- tariff divided by 30.4 and multiplied by the days of the period. The catorcenal
+-- (BIWEEKLY, 14 days) table follows the same rule (LISR Art. 96). The annual table
+-- is the Art. 152 tariff used for the annual calculation.
+
+-- Daily (1 day)
+INSERT INTO isr_brackets (fiscal_year_id, periodicity, lower_limit, upper_limit, fixed_fee, surplus_percent) VALUES
+((SELECT id FROM fiscal_years WHERE year = 2025), 'DAILY', 0.01, 24.54, 0.00, 0.0192),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'DAILY', 24.55, 208.29, 0.47, 0.0640),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'DAILY', 208.30, 366.05, 12.23, 0.1088),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'DAILY', 366.06, 425.52, 29.40, 0.1600),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'DAILY', 425.53, 509.46, 38.91, 0.1792),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'DAILY', 509.47, 1027.52, 53.95, 0.2136),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'DAILY', 1027.53, 1619.51, 164.61, 0.2352),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'DAILY', 1619.52, 3091.90, 303.85, 0.3000),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'DAILY', 3091.91, 4122.54, 745.56, 0.3200),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'DAILY', 4122.55, 12367.62, 1075.37, 0.3400),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'DAILY', 12367.63, 999999999.99, 3878.69, 0.3500);
+
+-- Weekly (7 days)
+INSERT INTO isr_brackets (fiscal_year_id, periodicity, lower_limit, upper_limit, fixed_fee, surplus_percent) VALUES
+((SELECT id FROM fiscal_years WHERE year = 2025), 'WEEKLY', 0.01, 171.78, 0.00, 0.0192),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'WEEKLY', 171.79, 1458.03, 3.29, 0.0640),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'WEEKLY', 1458.04, 2562.35, 85.61, 0.1088),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'WEEKLY', 2562.36, 2978.64, 205.80, 0.1600),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'WEEKLY', 2978.65, 3566.22, 272.37, 0.1792),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'WEEKLY', 3566.23, 7192.64, 377.65, 0.2136),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'WEEKLY', 7192.65, 11336.57, 1152.27, 0.2352),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'WEEKLY', 11336.58, 21643.30, 2126.95, 0.3000),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'WEEKLY', 21643.31, 28857.78, 5218.92, 0.3200),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'WEEKLY', 28857.79, 86573.34, 7527.59, 0.3400),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'WEEKLY', 86573.35, 999999999.99, 27150.83, 0.3500);
+
+-- Catorcenal (14 days)
+INSERT INTO isr_brackets (fiscal_year_id, periodicity, lower_limit, upper_limit, fixed_fee, surplus_percent) VALUES
+((SELECT id FROM fiscal_years WHERE year = 2025), 'BIWEEKLY', 0.01, 343.56, 0.00, 0.0192),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'BIWEEKLY', 343.57, 2916.06, 6.58, 0.0640),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'BIWEEKLY', 2916.07, 5124.70, 171.22, 0.1088),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'BIWEEKLY', 5124.71, 5957.28, 411.60, 0.1600),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'BIWEEKLY', 5957.29, 7132.44, 544.74, 0.1792),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'BIWEEKLY', 7132.45, 14385.28, 755.30, 0.2136),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'BIWEEKLY', 14385.29, 22673.14, 2304.54, 0.2352),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'BIWEEKLY', 22673.15, 43286.60, 4253.90, 0.3000),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'BIWEEKLY', 43286.61, 57715.56, 10437.84, 0.3200),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'BIWEEKLY', 57715.57, 173146.68, 15055.18, 0.3400),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'BIWEEKLY', 173146.69, 999999999.99, 54301.66, 0.3500);
+
+-- Quincenal (15 days)
+INSERT INTO isr_brackets (fiscal_year_id, periodicity, lower_limit, upper_limit, fixed_fee, surplus_percent) VALUES
+((SELECT id FROM fiscal_years WHERE year = 2025), 'SEMIMONTHLY', 0.01, 368.10, 0.00, 0.0192),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'SEMIMONTHLY', 368.11, 3124.35, 7.05, 0.0640),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'SEMIMONTHLY', 3124.36, 5490.75, 183.45, 0.1088),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'SEMIMONTHLY', 5490.76, 6382.80, 441.00, 0.1600),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'SEMIMONTHLY', 6382.81, 7641.90, 583.65, 0.1792),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'SEMIMONTHLY', 7641.91, 15412.80, 809.25, 0.2136),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'SEMIMONTHLY', 15412.81, 24292.65, 2469.15, 0.2352),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'SEMIMONTHLY', 24292.66, 46378.50, 4557.75, 0.3000),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'SEMIMONTHLY', 46378.51, 61838.10, 11183.40, 0.3200),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'SEMIMONTHLY', 61838.11, 185514.30, 16130.55, 0.3400),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'SEMIMONTHLY', 185514.31, 999999999.99, 58180.35, 0.3500);
+
+-- Annual (Art. 152)
+INSERT INTO isr_brackets (fiscal_year_id, periodicity, lower_limit, upper_limit, fixed_fee, surplus_percent) VALUES
+((SELECT id FROM fiscal_years WHERE year = 2025), 'ANNUAL', 0.01, 8952.49, 0.00, 0.0192),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'ANNUAL', 8952.50, 75984.55, 171.88, 0.0640),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'ANNUAL', 75984.56, 133536.07, 4461.94, 0.1088),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'ANNUAL', 133536.08, 155229.80, 10723.55, 0.1600),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'ANNUAL', 155229.81, 185852.57, 14194.54, 0.1792),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'ANNUAL', 185852.58, 374837.88, 19682.13, 0.2136),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'ANNUAL', 374837.89, 590795.99, 60049.40, 0.2352),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'ANNUAL', 590796.00, 1127926.84, 110842.74, 0.3000),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'ANNUAL', 1127926.85, 1503902.46, 271981.99, 0.3200),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'ANNUAL', 1503902.47, 4511707.37, 392294.17, 0.3400),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'ANNUAL', 4511707.38, 999999999.99, 1414947.85, 0.3500);
@@ -0,0 +1,5 @@
+-- Rollback the Actividad Empresarial retention rules
+
+DELETE FROM fiscal_retention_rules
+WHERE fiscal_year_id = (SELECT id FROM fiscal_years WHERE year = 2025)
+  AND regime_name = 'ACTIVIDAD_EMPRESARIAL';
@@ -0,0 +1,7 @@
+-- Seed the 2025 retention rules of Actividad Empresarial y Profesional
+-- A Persona Moral that pays honorarios retains 10% of the fee as ISR (LISR Art. 106);
+-- individuals do not retain
+
+INSERT INTO fiscal_retention_rules (fiscal_year_id, regime_name, client_type, retention_rate, description) VALUES
+((SELECT id FROM fiscal_years WHERE year = 2025), 'ACTIVIDAD_EMPRESARIAL', 'PERSONA_MORAL', 0.1000, 'Retención del 10% de ISR sobre honorarios pagados por una Persona Moral'),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'ACTIVIDAD_EMPRESARIAL', 'PERSONA_FISICA', 0.0000, 'Sin retención cuando el cliente es Persona Física');
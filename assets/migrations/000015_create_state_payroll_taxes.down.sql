@@ -0,0 +1,3 @@
+-- Rollback the state payroll taxes
+
+DROP TABLE IF EXISTS state_payroll_taxes;
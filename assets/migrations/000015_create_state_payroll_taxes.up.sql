@@ -0,0 +1,55 @@
+-- =====================================================
+-- Migration: Create State Payroll Taxes
+-- Description: Impuesto Sobre Nóminas (ISN) rate of every state
+-- =====================================================
+
+-- Each state taxes the remuneraciones an employer pays in its territory.
+-- Rates are the general rate of each state's Ley de Hacienda; review them yearly.
+CREATE TABLE state_payroll_taxes (
+    id SERIAL PRIMARY KEY,
+    fiscal_year_id INT REFERENCES fiscal_years(id) ON DELETE CASCADE,
+
+    state_code VARCHAR(10) NOT NULL,  -- e.g. CDMX, JAL, NL
+    state_name VARCHAR(100) NOT NULL,
+    rate NUMERIC(6, 4) NOT NULL,      -- e.g. 0.0400 for 4%
+
+    UNIQUE(fiscal_year_id, state_code)
+);
+
+CREATE INDEX idx_state_payroll_taxes_fiscal_year ON state_payroll_taxes(fiscal_year_id);
+
+COMMENT ON TABLE state_payroll_taxes IS 'State payroll tax (ISN) rates applied to the remuneraciones paid by an employer';
+
+INSERT INTO state_payroll_taxes (fiscal_year_id, state_code, state_name, rate) VALUES
+((SELECT id FROM fiscal_years WHERE year = 2025), 'AGS', 'Aguascalientes', 0.0250),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'BC', 'Baja California', 0.0425),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'BCS', 'Baja California Sur', 0.0250),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'CAMP', 'Campeche', 0.0300),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'CHIS', 'Chiapas', 0.0200),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'CHIH', 'Chihuahua', 0.0300),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'CDMX', 'Ciudad de México', 0.0400),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'COAH', 'Coahuila', 0.0300),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'COL', 'Colima', 0.0200),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'DGO', 'Durango', 0.0200),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'MEX', 'Estado de México', 0.0300),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'GTO', 'Guanajuato', 0.0300),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'GRO', 'Guerrero', 0.0200),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'HGO', 'Hidalgo', 0.0250),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'JAL', 'Jalisco', 0.0200),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'MICH', 'Michoacán', 0.0300),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'MOR', 'Morelos', 0.0200),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'NAY', 'Nayarit', 0.0250),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'NL', 'Nuevo León', 0.0300),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'OAX', 'Oaxaca', 0.0300),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'PUE', 'Puebla', 0.0300),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'QRO', 'Querétaro', 0.0300),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'QROO', 'Quintana Roo', 0.0300),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'SLP', 'San Luis Potosí', 0.0250),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'SIN', 'Sinaloa', 0.0300),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'SON', 'Sonora', 0.0250),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'TAB', 'Tabasco', 0.0300),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'TAMPS', 'Tamaulipas', 0.0300),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'TLAX', 'Tlaxcala', 0.0300),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'VER', 'Veracruz', 0.0300),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'YUC', 'Yucatán', 0.0300),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'ZAC', 'Zacatecas', 0.0300);
@@ -0,0 +1,3 @@
+-- Rollback the IMSS risk classes
+
+DROP TABLE IF EXISTS imss_risk_classes;
@@ -0,0 +1,29 @@
+-- =====================================================
+-- Migration: Create IMSS Risk Classes
+-- Description: Prima de riesgo de trabajo of every company risk class
+-- =====================================================
+
+-- Companies are classified by the hazard of their activity (RACERF Art. 73).
+-- A company pays the prima media of its class until its own siniestralidad sets
+-- an exact prima, which must stay between 0.5% and 15% (LSS Art. 74).
+CREATE TABLE imss_risk_classes (
+    id SERIAL PRIMARY KEY,
+    fiscal_year_id INT REFERENCES fiscal_years(id) ON DELETE CASCADE,
+
+    risk_class VARCHAR(3) NOT NULL,    -- I to V
+    description VARCHAR(100) NOT NULL,
+    premium NUMERIC(8, 7) NOT NULL,    -- e.g. 0.0054355 for 0.54355%
+
+    UNIQUE(fiscal_year_id, risk_class)
+);
+
+CREATE INDEX idx_imss_risk_classes_fiscal_year ON imss_risk_classes(fiscal_year_id);
+
+COMMENT ON TABLE imss_risk_classes IS 'Prima media de riesgo de trabajo of each IMSS risk class, used when the company has no exact prima';
+
+INSERT INTO imss_risk_classes (fiscal_year_id, risk_class, description, premium) VALUES
+((SELECT id FROM fiscal_years WHERE year = 2025), 'I', 'Riesgo ordinario de vida (oficinas, comercio)', 0.0054355),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'II', 'Riesgo bajo', 0.0113065),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'III', 'Riesgo medio', 0.0259840),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'IV', 'Riesgo alto', 0.0465325),
+((SELECT id FROM fiscal_years WHERE year = 2025), 'V', 'Riesgo máximo (construcción, minería)', 0.0758875);
@@ -0,0 +1,16 @@
+-- Rollback the IMSS concept base types
+
+DELETE FROM imss_concepts
+WHERE concept_name IN ('Enfermedad y Maternidad (Cuota Fija)', 'Enfermedad y Maternidad (Excedente)');
+
+UPDATE imss_concepts
+SET concept_name = 'Enfermedad y Maternidad (Gastos Médicos)',
+    worker_percent = 0.00400,
+    employer_percent = 0.01050
+WHERE concept_name = 'Enfermedad y Maternidad (Gastos Médicos Pensionados)';
+
+ALTER TABLE imss_concepts
+DROP COLUMN IF EXISTS excess_over_umas,
+DROP COLUMN IF EXISTS base_type;
+
+DROP TYPE IF EXISTS imss_base_type;
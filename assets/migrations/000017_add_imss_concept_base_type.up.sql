@@ -0,0 +1,25 @@
+-- Declare the base each IMSS concept applies its percentage to
+-- Enfermedad y Maternidad is not a flat percentage of the SBC (LSS Art. 106-107):
+--   * Cuota fija: the employer pays 20.40% of one UMA per day worked
+--   * Excedente: 1.10% employer and 0.40% worker on the SBC above 3 UMAs
+--   * Gastos médicos de pensionados: 1.05% employer and 0.375% worker on the SBC (LSS Art. 25)
+
+CREATE TYPE imss_base_type AS ENUM ('SBC', 'UMA_FIXED', 'EXCESS_UMA');
+
+ALTER TABLE imss_concepts
+ADD COLUMN IF NOT EXISTS base_type imss_base_type NOT NULL DEFAULT 'SBC',
+ADD COLUMN IF NOT EXISTS excess_over_umas INT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN imss_concepts.base_type IS 'SBC capped at base_cap_in_umas, one UMA per day (UMA_FIXED) or the SBC above excess_over_umas (EXCESS_UMA)';
+COMMENT ON COLUMN imss_concepts.excess_over_umas IS 'UMAs of the SBC exempt from an EXCESS_UMA concept';
+
+-- The old row mixed the excedente and pensionados worker rates
+UPDATE imss_concepts
+SET concept_name = 'Enfermedad y Maternidad (Gastos Médicos Pensionados)',
+    worker_percent = 0.00375,
+    employer_percent = 0.01050
+WHERE concept_name = 'Enfermedad y Maternidad (Gastos Médicos)';
+
+INSERT INTO imss_concepts (concept_name, worker_percent, employer_percent, base_cap_in_umas, is_fixed_rate, base_type, excess_over_umas) VALUES
+('Enfermedad y Maternidad (Cuota Fija)', 0.00000, 0.20400, 0, TRUE, 'UMA_FIXED', 0),
+('Enfermedad y Maternidad (Excedente)', 0.00400, 0.01100, 25, TRUE, 'EXCESS_UMA', 3);
@@ -0,0 +1,4 @@
+-- Rollback the cuota social column
+
+ALTER TABLE imss_employer_cesantia_brackets
+DROP COLUMN IF EXISTS social_quota_daily;
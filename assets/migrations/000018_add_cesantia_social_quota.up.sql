@@ -0,0 +1,22 @@
+-- Add the cuota social to the Cesantía brackets
+-- The Federal Government deposits a daily cuota social in the retirement account
+-- of workers earning up to 4 UMAs (LSS Art. 168 fr. IV). The 2020 amounts are
+-- updated quarterly with the INPC; these are the 2025 approximations.
+
+ALTER TABLE imss_employer_cesantia_brackets
+ADD COLUMN IF NOT EXISTS social_quota_daily NUMERIC(6, 2) NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN imss_employer_cesantia_brackets.social_quota_daily IS 'Cuota social the government pays per day worked (pesos); 0 above 4 UMAs';
+
+UPDATE imss_employer_cesantia_brackets SET social_quota_daily = 13.32
+WHERE fiscal_year_id = (SELECT id FROM fiscal_years WHERE year = 2025) AND lower_bound_uma = 1.000;
+UPDATE imss_employer_cesantia_brackets SET social_quota_daily = 12.71
+WHERE fiscal_year_id = (SELECT id FROM fiscal_years WHERE year = 2025) AND lower_bound_uma = 1.501;
+UPDATE imss_employer_cesantia_brackets SET social_quota_daily = 12.09
+WHERE fiscal_year_id = (SELECT id FROM fiscal_years WHERE year = 2025) AND lower_bound_uma = 2.001;
+UPDATE imss_employer_cesantia_brackets SET social_quota_daily = 11.48
+WHERE fiscal_year_id = (SELECT id FROM fiscal_years WHERE year = 2025) AND lower_bound_uma = 2.501;
+UPDATE imss_employer_cesantia_brackets SET social_quota_daily = 10.87
+WHERE fiscal_year_id = (SELECT id FROM fiscal_years WHERE year = 2025) AND lower_bound_uma = 3.001;
+UPDATE imss_employer_cesantia_brackets SET social_quota_daily = 10.26
+WHERE fiscal_year_id = (SELECT id FROM fiscal_years WHERE year = 2025) AND lower_bound_uma = 3.501;
@@ -1,14 +1,18 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/jcroyoaun/totalcompmx/internal/database"
 	"github.com/jcroyoaun/totalcompmx/internal/equity"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
 	"github.com/jcroyoaun/totalcompmx/internal/password"
+	"github.com/jcroyoaun/totalcompmx/internal/payroll"
 	"github.com/jcroyoaun/totalcompmx/internal/pdf"
 	"github.com/jcroyoaun/totalcompmx/internal/request"
 	"github.com/jcroyoaun/totalcompmx/internal/response"
@@ -16,25 +20,22 @@ import (
 	"github.com/jcroyoaun/totalcompmx/internal/validator"
 )
 
-type OtherBenefit struct {
-	Name         string
-	Amount       float64
-	TaxFree      bool
-	Currency     string
-	Cadence      string // monthly, annual, etc.
-	IsPercentage bool   // true if Amount is a percentage of gross annual salary
-}
+// OtherBenefit is the payroll engine's "Otras prestaciones" line item
+type OtherBenefit = payroll.OtherBenefit
 
 type PackageResult struct {
 	PackageName     string
 	*database.SalaryCalculation
 	EquityConfig    *equity.EquityConfig
 	EquitySchedule  []equity.YearlyEquity
+	EquityOutlook   *equity.ValueOutlook
 }
 
 type PackageInput struct {
 	Name                    string
 	Regime                  string
+	Zone                    string // GENERAL, FRONTERA_NORTE or FRONTERA_SUR
+	Municipality            string // Optional; a border municipality overrides Zone
 	Currency                string
 	ExchangeRate            string
 	PaymentFrequency        string
@@ -49,7 +50,38 @@ type PackageInput struct {
 	PrimaVacacionalPercent  string
 	HasFondoAhorro          bool
 	FondoAhorroPercent      string
-	UnpaidVacationDays      string // RESICO only: days off without pay
+	HasInfonavitCredit      bool
+	InfonavitCreditType     string // PERCENTAGE, FIXED_PESOS or VSM
+	InfonavitCreditValue    string
+	HasPTU                  bool
+	PTUMode                 string // EMPLOYEE_SHARE, DAYS or COMPANY_POOL
+	PTUValue                string
+	PTUThreeYearAverage     string // Optional; raises the three-month cap
+	PTUDaysWorked           string // COMPANY_POOL only
+	PTUTotalDaysWorked      string // COMPANY_POOL only
+	PTUTotalSalaries        string // COMPANY_POOL only
+	HasOvertime             bool
+	OvertimeHoursPerWeek    string
+	SundaysPerMonth         string // Sundays worked per month, paid with prima dominical
+	State                   string // Sueldos only: state code of the employer cost (ISN); empty skips it
+	RiskClass               string // Sueldos and Asimilados: IMSS risk class I to V; empty uses the default prima
+	RiskPremium             string // Sueldos and Asimilados: exact prima de riesgo in percent; wins over RiskClass
+	AforeYears              string // Sueldos only: years of the AFORE projection; empty or 0 skips it
+	AforeRealReturn         string // Sueldos only: yearly real return in percent
+	AforeSalaryGrowth       string // Sueldos only: yearly real salary growth in percent
+	AforeVoluntary          string // Sueldos only: monthly voluntary contributions
+	AforeInitialBalance     string // Sueldos only: current AFORE balance
+	HasProjection           bool   // Projects the package over homeProjectionYears
+	ProjectionRaise         string // Yearly raise in percent
+	ProjectionInflation     string // Expected yearly inflation in percent
+	ProjectionPromotions    string // "year:raise" pairs, e.g. "3:15, 5:10"
+	UnpaidVacationDays      string // Independent only: days off without pay
+	ClientType              string // Independent only: PERSONA_FISICA or PERSONA_MORAL
+	ChargesIVA              bool   // Independent only: invoices add 16% IVA
+	IVAExpenses             string // RESICO only: monthly expenses with acreditable IVA
+	// Actividad Empresarial only: deductible expenses by category
+	Expenses                []payroll.Expense
+	BorderStimulus          bool // Actividad Empresarial only: registered in the padrón of the border ISR stimulus
 	OtherBenefits           []OtherBenefit
 	// Equity fields
 	HasEquity               bool
@@ -57,6 +89,16 @@ type PackageInput struct {
 	HasRefreshers           bool
 	RefresherMinUSD         string
 	RefresherMaxUSD         string
+	VestingYears            string // Equal vesting over this many years when VestingPercents is empty
+	VestingPercents         string // Percent vesting each year, e.g. "5/15/40/40"
+	CliffMonths             string
+	VestFrequency           string // ANNUAL, QUARTERLY or MONTHLY
+	EquityWithholding       string // PAYROLL or SELL_TO_COVER
+	SellToCoverPercent      string
+	PriceGrowthBear         string // Expected yearly share price growth in percent
+	PriceGrowthBase         string
+	PriceGrowthBull         string
+	PriceVolatility         string // Adds the Monte Carlo percentiles
 }
 
 func (app *application) clearSession(w http.ResponseWriter, r *http.Request) {
@@ -85,6 +127,7 @@ func (app *application) home(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
 		data := app.newTemplateData(r)
+		data["BorderMunicipalities"] = payroll.BorderMunicipalities()
 		
 		// Always fetch FiscalYear for exchange rate display
 		fiscalYear, found, err := app.db.GetActiveFiscalYear()
@@ -94,6 +137,20 @@ func (app *application) home(w http.ResponseWriter, r *http.Request) {
 		}
 		if found {
 			data["FiscalYear"] = fiscalYear
+
+			stateTaxes, err := app.db.GetStatePayrollTaxes(fiscalYear.ID)
+			if err != nil {
+				app.serverError(w, r, err)
+				return
+			}
+			data["StatePayrollTaxes"] = stateTaxes
+
+			riskClasses, err := app.db.GetRiskClasses(fiscalYear.ID)
+			if err != nil {
+				app.serverError(w, r, err)
+				return
+			}
+			data["RiskClasses"] = riskClasses
 		}
 		
 		// Check if we have comparison results in session (from POST-Redirect-GET)
@@ -138,9 +195,18 @@ func (app *application) home(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		// Load the rate tables once for every package in this comparison
+		tables, err := app.loadRateTables(fiscalYear)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
 		// Parse arrays from form
 		packageNames := r.Form["PackageName[]"]
 		regimes := r.Form["Regime[]"]
+		zones := r.Form["Zone[]"]
+		municipalities := r.Form["Municipality[]"]
 		salariesStr := r.Form["GrossMonthlySalary[]"]
 		currencies := r.Form["Currency[]"]
 		exchangeRatesStr := r.Form["ExchangeRate[]"]
@@ -156,7 +222,35 @@ func (app *application) home(w http.ResponseWriter, r *http.Request) {
 		hasFondoAhorro := r.Form["HasFondoAhorro[]"]
 		fondoAhorroPercentStr := r.Form["FondoAhorroPercent[]"]
 		hasInfonavitCredit := r.Form["HasInfonavitCredit[]"]
+		infonavitCreditTypes := r.Form["InfonavitCreditType[]"]
+		infonavitCreditValuesStr := r.Form["InfonavitCreditValue[]"]
+		hasPTUChecks := r.Form["HasPTU[]"]
+		ptuModes := r.Form["PTUMode[]"]
+		ptuValuesStr := r.Form["PTUValue[]"]
+		ptuAveragesStr := r.Form["PTUThreeYearAverage[]"]
+		ptuDaysWorkedStr := r.Form["PTUDaysWorked[]"]
+		ptuTotalDaysStr := r.Form["PTUTotalDaysWorked[]"]
+		ptuTotalSalariesStr := r.Form["PTUTotalSalaries[]"]
+		hasOvertimeChecks := r.Form["HasOvertime[]"]
+		overtimeHoursStr := r.Form["OvertimeHoursPerWeek[]"]
+		sundaysPerMonthStr := r.Form["SundaysPerMonth[]"]
+		states := r.Form["State[]"]
+		riskClasses := r.Form["RiskClass[]"]
+		riskPremiumsStr := r.Form["RiskPremium[]"]
+		aforeYearsStr := r.Form["AforeYears[]"]
+		aforeReturnsStr := r.Form["AforeRealReturn[]"]
+		aforeGrowthsStr := r.Form["AforeSalaryGrowth[]"]
+		aforeVoluntaryStr := r.Form["AforeVoluntary[]"]
+		aforeBalancesStr := r.Form["AforeInitialBalance[]"]
 		unpaidVacationDaysStr := r.Form["UnpaidVacationDays[]"]
+		clientTypes := r.Form["ClientType[]"]
+		chargesIVAChecks := r.Form["ChargesIVA[]"]
+		ivaExpensesStr := r.Form["IVAExpenses[]"]
+		borderStimulusChecks := r.Form["BorderStimulus[]"]
+		expensesStr := map[payroll.ExpenseCategory][]string{}
+		for _, category := range payroll.ExpenseCategories() {
+			expensesStr[category] = r.Form[fmt.Sprintf("Expense-%s[]", category)]
+		}
 		
 		// Equity form data
 		hasEquity := r.Form["HasEquity[]"]
@@ -164,6 +258,22 @@ func (app *application) home(w http.ResponseWriter, r *http.Request) {
 		hasRefreshers := r.Form["HasRefreshers[]"]
 		refresherMinUSDStr := r.Form["RefresherMinUSD[]"]
 		refresherMaxUSDStr := r.Form["RefresherMaxUSD[]"]
+		vestingYearsStr := r.Form["VestingYears[]"]
+		vestingPercentsStr := r.Form["VestingPercents[]"]
+		cliffMonthsStr := r.Form["CliffMonths[]"]
+		vestFrequencies := r.Form["VestFrequency[]"]
+		equityWithholdings := r.Form["EquityWithholding[]"]
+		sellToCoverPercentsStr := r.Form["SellToCoverPercent[]"]
+		priceGrowthBearsStr := r.Form["PriceGrowthBear[]"]
+		priceGrowthBasesStr := r.Form["PriceGrowthBase[]"]
+		priceGrowthBullsStr := r.Form["PriceGrowthBull[]"]
+		priceVolatilitiesStr := r.Form["PriceVolatility[]"]
+
+		// Multi-year projection form data
+		hasProjectionChecks := r.Form["HasProjection[]"]
+		projectionRaisesStr := r.Form["ProjectionRaise[]"]
+		projectionInflationsStr := r.Form["ProjectionInflation[]"]
+		projectionPromotionsStr := r.Form["ProjectionPromotions[]"]
 
 		var results []PackageResult
 		var bestPackage *PackageResult
@@ -188,11 +298,36 @@ func (app *application) home(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 		
-		if !hasValidPackage {
-			form.Validator.AddFieldError("GrossMonthlySalary", "Debes ingresar al menos un salario válido para comparar")
+		// Amounts beyond money.MaxAmount would overflow the calculation
+		amountFields := []string{"ValesDespensaAmount[]", "InfonavitCreditValue[]", "PTUValue[]", "PTUThreeYearAverage[]", "PTUTotalSalaries[]", "AforeVoluntary[]",
+			"AforeInitialBalance[]", "IVAExpenses[]", "InitialEquityUSD[]", "RefresherMinUSD[]", "RefresherMaxUSD[]"}
+		for _, category := range payroll.ExpenseCategories() {
+			amountFields = append(amountFields, fmt.Sprintf("Expense-%s[]", category))
+		}
+		for i := 0; i < numPackages; i++ {
+			amountFields = append(amountFields, fmt.Sprintf("OtherBenefitAmount-%d[]", i))
+		}
+		validAmounts := true
+		for _, field := range amountFields {
+			for _, value := range r.Form[field] {
+				amount := 0.0
+				fmt.Sscanf(value, "%f", &amount)
+				validAmounts = validAmounts && money.ValidAmounts(amount)
+			}
+		}
+
+		if !hasValidPackage || !validAmounts {
+			if !hasValidPackage {
+				form.Validator.AddFieldError("GrossMonthlySalary", "Debes ingresar al menos un salario válido para comparar")
+			} else {
+				form.Validator.AddFieldError("Amounts", fmt.Sprintf("Los montos no pueden ser mayores a $%s", money.MaxAmount))
+			}
 			
 			// Restore form with error
 			data := app.newTemplateData(r)
+			data["BorderMunicipalities"] = payroll.BorderMunicipalities()
+			data["StatePayrollTaxes"] = tables.StatePayrollTaxes()
+			data["RiskClasses"] = tables.RiskClasses()
 			data["Form"] = form
 			err = response.Page(w, http.StatusUnprocessableEntity, data, "pages/home.tmpl")
 			if err != nil {
@@ -222,6 +357,17 @@ func (app *application) home(w http.ResponseWriter, r *http.Request) {
 				regime = regimes[i]
 			}
 
+			// Work location: a border municipality wins over the selected zone
+			zoneStr := ""
+			if i < len(zones) {
+				zoneStr = zones[i]
+			}
+			municipality := ""
+			if i < len(municipalities) {
+				municipality = municipalities[i]
+			}
+			zone := resolveZone(zoneStr, municipality)
+
 			// Currency conversion (USD -> MXN if needed)
 			currency := "MXN"
 			exchangeRate := 20.0 // Default exchange rate
@@ -241,29 +387,62 @@ func (app *application) home(w http.ResponseWriter, r *http.Request) {
 				paymentFreq = paymentFrequencies[i]
 			}
 			
+			// Map the frequency to the ISR periodicity used for the payslip
+			payPeriod := payroll.PeriodMonthly
 			switch paymentFreq {
 			case "hourly":
 				hoursPerWeek := 40.0 // Default
 				if i < len(hoursPerWeekStr) && hoursPerWeekStr[i] != "" {
 					fmt.Sscanf(hoursPerWeekStr[i], "%f", &hoursPerWeek)
 				}
-				// Convert hourly to monthly: rate * hours/week * 4.33 weeks/month
-				salary = salary * hoursPerWeek * 4.33
+				// Hourly workers are paid by the week: rate * hours/week
+				salary = salary * hoursPerWeek
+				payPeriod = payroll.PeriodWeekly
 			case "daily":
-				// Convert daily to monthly: daily * 30 days/month
-				salary = salary * 30
+				payPeriod = payroll.PeriodDaily
 			case "weekly":
-				// Convert weekly to monthly: weekly * 4.33 weeks/month
-				salary = salary * 4.33
+				payPeriod = payroll.PeriodWeekly
 			case "biweekly":
-				// Convert biweekly to monthly: biweekly * 2.17 (26 periods / 12 months)
-				salary = salary * 2.17
+				// Catorcenal: every 14 days
+				payPeriod = payroll.PeriodBiweekly
+			case "semimonthly":
+				// Quincenal: twice a month
+				payPeriod = payroll.PeriodSemimonthly
 			case "monthly":
 				// Already monthly, no conversion needed
 			}
+			salaryError := ""
+			if !money.ValidAmounts(salary) {
+				salaryError = fmt.Sprintf("es mayor al máximo de $%s", money.MaxAmount)
+			}
+			monthlySalary := payroll.MonthlyFromPeriod(money.FromFloat(salary), payPeriod)
 
 			// Now salary is in MXN monthly
 
+			// Salaried packages cannot pay less than the zone's minimum wage
+			if salaryError == "" && regime == "sueldos_salarios" && monthlySalary < payroll.MinimumMonthlyWage(tables, zone) {
+				salaryError = fmt.Sprintf("es menor al salario mínimo mensual de su zona ($%s)", payroll.MinimumMonthlyWage(tables, zone))
+			}
+			if salaryError != "" {
+				packageName := fmt.Sprintf("Paquete %d", i+1)
+				if i < len(packageNames) && packageNames[i] != "" {
+					packageName = packageNames[i]
+				}
+				form.Validator.AddFieldError("GrossMonthlySalary", fmt.Sprintf("El salario de %s %s", packageName, salaryError))
+
+				data := app.newTemplateData(r)
+				data["BorderMunicipalities"] = payroll.BorderMunicipalities()
+				data["StatePayrollTaxes"] = tables.StatePayrollTaxes()
+				data["RiskClasses"] = tables.RiskClasses()
+				data["FiscalYear"] = fiscalYear
+				data["Form"] = form
+				err = response.Page(w, http.StatusUnprocessableEntity, data, "pages/home.tmpl")
+				if err != nil {
+					app.serverError(w, r, err)
+				}
+				return
+			}
+
 			// Parse benefits (only for Sueldos y Salarios)
 			hasAguin := false
 			aguinDays := 15
@@ -275,7 +454,21 @@ func (app *application) home(w http.ResponseWriter, r *http.Request) {
 			hasFondo := false
 			fondoPercent := 13.0
 			hasInfonavit := false
+			infonavitCredit := payroll.InfonavitCredit{Type: payroll.InfonavitPercentage}
+			hasPTU := false
+			ptu := payroll.PTU{Mode: payroll.PTUDays}
+			hasOvertime := false
+			overtime := payroll.Overtime{}
+			stateCode := ""
+			workRisk := payroll.WorkRisk{}
+			riskPremiumStr := ""
+			afore := payroll.AforeInput{RealReturn: 4, SalaryGrowth: 1}
 			unpaidVacationDays := 0
+			clientType := payroll.ClientPersonaFisica
+			chargesIVA := false
+			ivaExpenses := 0.0
+			var expenses []payroll.Expense
+			borderStimulus := false
 
 			if regime == "sueldos_salarios" {
 				// Check if this package has aguinaldo
@@ -334,11 +527,183 @@ func (app *application) home(w http.ResponseWriter, r *http.Request) {
 						break
 					}
 				}
-			} else if regime == "resico" {
-				// Parse unpaid vacation days for RESICO
+				if hasInfonavit {
+					if i < len(infonavitCreditTypes) && payroll.InfonavitCreditType(infonavitCreditTypes[i]).Valid() {
+						infonavitCredit.Type = payroll.InfonavitCreditType(infonavitCreditTypes[i])
+					}
+					if i < len(infonavitCreditValuesStr) {
+						fmt.Sscanf(infonavitCreditValuesStr[i], "%f", &infonavitCredit.Value)
+					}
+				}
+
+				// Check PTU (profit sharing)
+				for _, val := range hasPTUChecks {
+					if val == fmt.Sprintf("%d", i) {
+						hasPTU = true
+						break
+					}
+				}
+				if hasPTU {
+					if i < len(ptuModes) && payroll.PTUMode(ptuModes[i]).Valid() {
+						ptu.Mode = payroll.PTUMode(ptuModes[i])
+					}
+					if i < len(ptuValuesStr) {
+						fmt.Sscanf(ptuValuesStr[i], "%f", &ptu.Value)
+					}
+					if i < len(ptuAveragesStr) {
+						average := 0.0
+						fmt.Sscanf(ptuAveragesStr[i], "%f", &average)
+						ptu.ThreeYearAverage = money.FromFloat(average)
+					}
+					if i < len(ptuDaysWorkedStr) {
+						fmt.Sscanf(ptuDaysWorkedStr[i], "%f", &ptu.DaysWorked)
+					}
+					if i < len(ptuTotalDaysStr) {
+						fmt.Sscanf(ptuTotalDaysStr[i], "%f", &ptu.TotalDaysWorked)
+					}
+					if i < len(ptuTotalSalariesStr) {
+						totalSalaries := 0.0
+						fmt.Sscanf(ptuTotalSalariesStr[i], "%f", &totalSalaries)
+						ptu.TotalSalaries = money.FromFloat(totalSalaries)
+					}
+					ptu.Value = math.Max(0, ptu.Value)
+					ptu.ThreeYearAverage = max(0, ptu.ThreeYearAverage)
+
+					// The company totals must include the employee's own days and salary
+					if _, _, ptuErr := payroll.CalculatePTU(ptu, monthlySalary); ptuErr != nil {
+						form.Validator.AddFieldError("PTU", "Los días y salarios de toda la plantilla deben incluir los tuyos (máximo 366 días por trabajador)")
+
+						data := app.newTemplateData(r)
+						data["BorderMunicipalities"] = payroll.BorderMunicipalities()
+						data["StatePayrollTaxes"] = tables.StatePayrollTaxes()
+						data["RiskClasses"] = tables.RiskClasses()
+						data["FiscalYear"] = fiscalYear
+						data["Form"] = form
+						err = response.Page(w, http.StatusUnprocessableEntity, data, "pages/home.tmpl")
+						if err != nil {
+							app.serverError(w, r, err)
+						}
+						return
+					}
+				}
+
+				// Check overtime (horas extra) and prima dominical
+				for _, val := range hasOvertimeChecks {
+					if val == fmt.Sprintf("%d", i) {
+						hasOvertime = true
+						break
+					}
+				}
+				if hasOvertime {
+					if i < len(overtimeHoursStr) {
+						fmt.Sscanf(overtimeHoursStr[i], "%f", &overtime.HoursPerWeek)
+					}
+					if i < len(sundaysPerMonthStr) {
+						fmt.Sscanf(sundaysPerMonthStr[i], "%f", &overtime.SundaysPerMonth)
+					}
+					overtime.HoursPerWeek = math.Max(0, overtime.HoursPerWeek)
+					overtime.SundaysPerMonth = math.Max(0, overtime.SundaysPerMonth)
+				}
+
+				// Employer cost for the selected state
+				if i < len(states) {
+					if _, found := tables.StatePayrollTax(states[i]); found {
+						stateCode = states[i]
+					}
+				}
+
+				// AFORE projection of the retirement contributions
+				if i < len(aforeYearsStr) && aforeYearsStr[i] != "" {
+					fmt.Sscanf(aforeYearsStr[i], "%d", &afore.Years)
+					afore.Years = min(max(0, afore.Years), payroll.MaxAforeYears)
+				}
+				if i < len(aforeReturnsStr) && aforeReturnsStr[i] != "" {
+					fmt.Sscanf(aforeReturnsStr[i], "%f", &afore.RealReturn)
+				}
+				if i < len(aforeGrowthsStr) && aforeGrowthsStr[i] != "" {
+					fmt.Sscanf(aforeGrowthsStr[i], "%f", &afore.SalaryGrowth)
+				}
+				if i < len(aforeVoluntaryStr) {
+					voluntary := 0.0
+					fmt.Sscanf(aforeVoluntaryStr[i], "%f", &voluntary)
+					afore.VoluntaryMonthly = money.FromFloat(voluntary)
+				}
+				if i < len(aforeBalancesStr) {
+					balance := 0.0
+					fmt.Sscanf(aforeBalancesStr[i], "%f", &balance)
+					afore.InitialBalance = money.FromFloat(balance)
+				}
+				afore.VoluntaryMonthly = max(0, afore.VoluntaryMonthly)
+				afore.InitialBalance = max(0, afore.InitialBalance)
+				if afore.Years > 0 && (!(afore.RealReturn >= payroll.MinAforeRate && afore.RealReturn <= payroll.MaxAforeRate) ||
+					!(afore.SalaryGrowth >= payroll.MinAforeRate && afore.SalaryGrowth <= payroll.MaxAforeRate)) {
+					form.Validator.AddFieldError("Afore", fmt.Sprintf(
+						"El rendimiento real y el crecimiento salarial de la AFORE deben estar entre %d%% y %d%%",
+						payroll.MinAforeRate, payroll.MaxAforeRate,
+					))
+
+					data := app.newTemplateData(r)
+					data["BorderMunicipalities"] = payroll.BorderMunicipalities()
+					data["StatePayrollTaxes"] = tables.StatePayrollTaxes()
+					data["RiskClasses"] = tables.RiskClasses()
+					data["FiscalYear"] = fiscalYear
+					data["Form"] = form
+					err = response.Page(w, http.StatusUnprocessableEntity, data, "pages/home.tmpl")
+					if err != nil {
+						app.serverError(w, r, err)
+					}
+					return
+				}
+			} else if regime == "resico" || regime == "actividad_empresarial" {
+				// Parse unpaid vacation days and invoicing for independent regimes
 				if i < len(unpaidVacationDaysStr) && unpaidVacationDaysStr[i] != "" {
 					fmt.Sscanf(unpaidVacationDaysStr[i], "%d", &unpaidVacationDays)
 				}
+				if i < len(clientTypes) && payroll.ClientType(clientTypes[i]).Valid() {
+					clientType = payroll.ClientType(clientTypes[i])
+				}
+				for _, val := range chargesIVAChecks {
+					if val == fmt.Sprintf("%d", i) {
+						chargesIVA = true
+						break
+					}
+				}
+				if chargesIVA && i < len(ivaExpensesStr) {
+					fmt.Sscanf(ivaExpensesStr[i], "%f", &ivaExpenses)
+					ivaExpenses = math.Max(0, ivaExpenses)
+				}
+				if regime == "actividad_empresarial" {
+					for _, val := range borderStimulusChecks {
+						if val == fmt.Sprintf("%d", i) {
+							borderStimulus = true
+							break
+						}
+					}
+					for _, category := range payroll.ExpenseCategories() {
+						amount := 0.0
+						if i < len(expensesStr[category]) {
+							fmt.Sscanf(expensesStr[category][i], "%f", &amount)
+						}
+						if amount > 0 {
+							expenses = append(expenses, payroll.Expense{Category: category, Amount: money.FromFloat(amount)})
+						}
+					}
+				}
+			}
+
+			// Riesgo de Trabajo of the company; a prima outside the legal limits is ignored
+			if regime == "sueldos_salarios" || regime == "asimilados" {
+				if i < len(riskClasses) && payroll.RiskClass(riskClasses[i]).Valid() {
+					workRisk.Class = payroll.RiskClass(riskClasses[i])
+				}
+				if i < len(riskPremiumsStr) && riskPremiumsStr[i] != "" {
+					fmt.Sscanf(riskPremiumsStr[i], "%f", &workRisk.Premium)
+					if _, err := tables.RiskPremium(payroll.WorkRisk{Premium: workRisk.Premium}); err != nil {
+						workRisk.Premium = 0
+					} else {
+						riskPremiumStr = fmt.Sprintf("%g", workRisk.Premium)
+					}
+				}
 			}
 
 			// Parse "Otras prestaciones" for this package
@@ -410,27 +775,106 @@ func (app *application) home(w http.ResponseWriter, r *http.Request) {
 				})
 			}
 
-			// Calculate this package based on regime
-			var result database.SalaryCalculation
-			var err error
-			
-			if regime == "resico" {
-				// RESICO: Simple flat rate calculation, no IMSS, no subsidio
-				result, err = app.calculateRESICO(salary, unpaidVacationDays, otherBenefits, exchangeRate, fiscalYear)
-			} else {
-				// Sueldos y Salarios: Full calculation with benefits, IMSS, etc.
-				result, err = app.calculateSalaryWithBenefits(
-					salary,
-					hasAguin, aguinDays,
-					hasVales, valesAmount,
-					hasPrima, vacaDays, primaPercent,
-					hasFondo, fondoPercent,
-					hasInfonavit,
-					otherBenefits,
-					exchangeRate,
-					fiscalYear,
-				)
+			// Multi-year projection with raises, promotions and inflation
+			projection := payroll.ProjectionInput{AnnualRaise: 5, Inflation: 4}
+			hasProjection := false
+			for _, val := range hasProjectionChecks {
+				if val == fmt.Sprintf("%d", i) {
+					hasProjection = true
+					break
+				}
+			}
+			if i < len(projectionRaisesStr) && projectionRaisesStr[i] != "" {
+				fmt.Sscanf(projectionRaisesStr[i], "%f", &projection.AnnualRaise)
+			}
+			if i < len(projectionInflationsStr) && projectionInflationsStr[i] != "" {
+				fmt.Sscanf(projectionInflationsStr[i], "%f", &projection.Inflation)
+			}
+			projection.AnnualRaise = math.Min(payroll.MaxProjectionRate, math.Max(payroll.MinProjectionRate, projection.AnnualRaise))
+			projection.Inflation = math.Min(payroll.MaxProjectionRate, math.Max(payroll.MinProjectionRate, projection.Inflation))
+			promotionsStr := ""
+			if i < len(projectionPromotionsStr) {
+				promotionsStr = projectionPromotionsStr[i]
+				projection.Promotions = parsePromotions(promotionsStr, homeProjectionYears)
 			}
+			if hasProjection {
+				projection.Years = homeProjectionYears
+			}
+
+			// Calculate this package based on regime. The projection and the tax
+			// curve call it again for every year and salary, so only the package
+			// itself is recorded in the metrics.
+			calculate := func(grossMonthlySalary money.Money, yearsOfService int) (database.SalaryCalculation, error) {
+				if regime == "resico" {
+					// RESICO: Simple flat rate calculation, no IMSS, no subsidio
+					return payroll.CalculateRESICO(tables, payroll.RESICOInput{
+						MonthlyIncome:      grossMonthlySalary,
+						UnpaidVacationDays: unpaidVacationDays,
+						ClientType:         clientType,
+						ChargesIVA:         chargesIVA,
+						IVAExpenses:        money.FromFloat(ivaExpenses),
+						OtherBenefits:      otherBenefits,
+						ExchangeRate:       money.RateFromFloat(exchangeRate),
+					})
+				} else if regime == "actividad_empresarial" {
+					// Actividad Empresarial: progressive ISR on income minus deductible expenses
+					return payroll.CalculateActividadEmpresarial(tables, payroll.ActividadEmpresarialInput{
+						MonthlyIncome:      grossMonthlySalary,
+						Expenses:           expenses,
+						UnpaidVacationDays: unpaidVacationDays,
+						ClientType:         clientType,
+						ChargesIVA:         chargesIVA,
+						Zone:               zone,
+						BorderStimulus:     borderStimulus,
+						OtherBenefits:      otherBenefits,
+						ExchangeRate:       money.RateFromFloat(exchangeRate),
+						Explain:            true,
+					})
+				} else if regime == "asimilados" {
+					// Asimilados: salary ISR only, no IMSS and no statutory benefits
+					return payroll.CalculateAsimilados(tables, payroll.AsimiladosInput{
+						GrossMonthlySalary: grossMonthlySalary,
+						Zone:               zone,
+						PayPeriod:          payPeriod,
+						WorkRisk:           workRisk,
+						OtherBenefits:      otherBenefits,
+						ExchangeRate:       money.RateFromFloat(exchangeRate),
+						Explain:            true,
+					})
+				} else {
+					// Sueldos y Salarios: Full calculation with benefits, IMSS, etc.
+					return payroll.CalculateSalaryWithBenefits(tables, payroll.SalaryInput{
+						GrossMonthlySalary:     grossMonthlySalary,
+						YearsOfService:         yearsOfService,
+						Zone:                   zone,
+						PayPeriod:              payPeriod,
+						HasAguinaldo:           hasAguin,
+						AguinaldoDays:          aguinDays,
+						HasValesDespensa:       hasVales,
+						ValesDespensaAmount:    money.FromFloat(valesAmount),
+						HasPrimaVacacional:     hasPrima,
+						VacationDays:           vacaDays,
+						PrimaVacacionalPercent: primaPercent,
+						HasFondoAhorro:         hasFondo,
+						FondoAhorroPercent:     fondoPercent,
+						HasInfonavitCredit:     hasInfonavit,
+						InfonavitCredit:        infonavitCredit,
+						HasPTU:                 hasPTU,
+						PTU:                    ptu,
+						HasOvertime:            hasOvertime,
+						Overtime:               overtime,
+						WorkRisk:               workRisk,
+						State:                  stateCode,
+						Afore:                  afore,
+						OtherBenefits:          otherBenefits,
+						ExchangeRate:           money.RateFromFloat(exchangeRate),
+						Explain:                true,
+					})
+				}
+			}
+			start := time.Now()
+			result, err := calculate(monthlySalary, 1)
+			recordCalculation(start)
 			
 			if err != nil {
 				app.serverError(w, r, err)
@@ -442,9 +886,59 @@ func (app *application) home(w http.ResponseWriter, r *http.Request) {
 				packageName = packageNames[i]
 			}
 
+			// Vesting schedule: equal years by default, per-year percentages,
+			// a cliff and the vest frequency otherwise
+			vestingYears := 4
+			if i < len(vestingYearsStr) && vestingYearsStr[i] != "" {
+				fmt.Sscanf(vestingYearsStr[i], "%d", &vestingYears)
+			}
+			vestingPercentsVal := ""
+			if i < len(vestingPercentsStr) {
+				vestingPercentsVal = vestingPercentsStr[i]
+			}
+			cliffMonths := 0
+			if i < len(cliffMonthsStr) && cliffMonthsStr[i] != "" {
+				fmt.Sscanf(cliffMonthsStr[i], "%d", &cliffMonths)
+			}
+			vestFrequency := equity.VestAnnual
+			if i < len(vestFrequencies) && equity.VestFrequency(vestFrequencies[i]).Valid() {
+				vestFrequency = equity.VestFrequency(vestFrequencies[i])
+			}
+
+			// ISR on the vests: withheld through payroll or by selling shares
+			equityWithholding := equity.WithholdPayroll
+			if i < len(equityWithholdings) && equity.Withholding(equityWithholdings[i]).Valid() {
+				equityWithholding = equity.Withholding(equityWithholdings[i])
+			}
+			sellToCoverPercent := 0.0
+			if i < len(sellToCoverPercentsStr) && sellToCoverPercentsStr[i] != "" {
+				fmt.Sscanf(sellToCoverPercentsStr[i], "%f", &sellToCoverPercent)
+			}
+
+			// Share price scenarios, valued only when some growth is given
+			var priceGrowth equity.PriceGrowth
+			priceGrowthVals := make([]string, 4)
+			hasPriceGrowth := false
+			for j, field := range []struct {
+				values []string
+				target *float64
+			}{
+				{priceGrowthBearsStr, &priceGrowth.Bear},
+				{priceGrowthBasesStr, &priceGrowth.Base},
+				{priceGrowthBullsStr, &priceGrowth.Bull},
+				{priceVolatilitiesStr, &priceGrowth.Volatility},
+			} {
+				if i < len(field.values) && field.values[i] != "" {
+					priceGrowthVals[j] = field.values[i]
+					fmt.Sscanf(field.values[i], "%f", field.target)
+					hasPriceGrowth = true
+				}
+			}
+
 			// Calculate equity if provided
 			var equityConfig *equity.EquityConfig
 			var equitySchedule []equity.YearlyEquity
+			var equityOutlook *equity.ValueOutlook
 			
 			if i < len(initialEquityUSDStr) && initialEquityUSDStr[i] != "" {
 				initialEquity := 0.0
@@ -474,17 +968,87 @@ func (app *application) home(w http.ResponseWriter, r *http.Request) {
 						}
 					}
 					
+					vestingPercents, err := parseVestingPercents(vestingPercentsVal)
 					equityConfig = &equity.EquityConfig{
-						InitialGrantUSD: initialEquity,
-						HasRefreshers:   hasRefresh && refresherMin > 0 && refresherMax > 0,
-						RefresherMinUSD: refresherMin,
-						RefresherMaxUSD: refresherMax,
-						VestingYears:    4,
-						ExchangeRate:    fiscalYear.USDMXNRate,
+						InitialGrantUSD:    initialEquity,
+						HasRefreshers:      hasRefresh && refresherMin > 0 && refresherMax > 0,
+						RefresherMinUSD:    refresherMin,
+						RefresherMaxUSD:    refresherMax,
+						VestingYears:       vestingYears,
+						VestingPercents:    vestingPercents,
+						CliffMonths:        cliffMonths,
+						VestFrequency:      vestFrequency,
+						Withholding:        equityWithholding,
+						SellToCoverPercent: sellToCoverPercent,
+						ExchangeRate:       fiscalYear.USDMXNRate.Float64(),
+					}
+					if err == nil {
+						err = equityConfig.Validate()
+					}
+					if err == nil && hasPriceGrowth {
+						err = priceGrowth.Validate()
+					}
+					if err != nil {
+						form.Validator.AddFieldError("Equity", fmt.Sprintf(
+							"El calendario de vesting de %s no es válido: los porcentajes deben sumar 100%% en máximo %d años, el cliff no puede pasar del vesting, el sell-to-cover va de 0%% a 100%%, el crecimiento del precio va de %d%% a %d%% y la volatilidad de 0%% a %d%%",
+							packageName, equity.MaxVestingYears, equity.MinPriceGrowth, equity.MaxPriceGrowth, equity.MaxVolatility,
+						))
+
+						data := app.newTemplateData(r)
+						data["BorderMunicipalities"] = payroll.BorderMunicipalities()
+						data["StatePayrollTaxes"] = tables.StatePayrollTaxes()
+						data["RiskClasses"] = tables.RiskClasses()
+						data["FiscalYear"] = fiscalYear
+						data["Form"] = form
+						err = response.Page(w, http.StatusUnprocessableEntity, data, "pages/home.tmpl")
+						if err != nil {
+							app.serverError(w, r, err)
+						}
+						return
 					}
 					
-					equitySchedule = equity.CalculateEquitySchedule(*equityConfig, 4)
+					equitySchedule = equity.CalculateEquitySchedule(*equityConfig, max(4, equityConfig.Years()))
+					equitySchedule = payroll.TaxEquitySchedule(tables, packageRegime(regime), result, *equityConfig, equitySchedule)
+
+					if hasPriceGrowth {
+						outlook, err := equity.ProjectValue(*equityConfig, priceGrowth)
+						if err != nil {
+							app.serverError(w, r, err)
+							return
+						}
+						equityOutlook = &outlook
+					}
+				}
+			}
+
+			// Project the package with the equity vesting of every year, after its ISR
+			if projection.Years > 0 {
+				if equityConfig != nil {
+					schedule := payroll.TaxEquitySchedule(tables, packageRegime(regime), result, *equityConfig, equity.CalculateEquitySchedule(*equityConfig, projection.Years))
+					for _, year := range schedule[1:] {
+						projection.EquityMXN = append(projection.EquityMXN, money.FromFloat(year.NetVestedMXN))
+					}
+				}
+				compensation, err := payroll.ProjectCompensation(projection, monthlySalary, 1, calculate)
+				if err != nil {
+					app.serverError(w, r, err)
+					return
+				}
+				result.Projection = &compensation
+			}
+
+			// Tax curve from the minimum wage to twice the salary of the package
+			curveFrom := payroll.MinimumMonthlyWage(tables, zone)
+			if monthlySalary < curveFrom {
+				curveFrom = monthlySalary
+			}
+			if curveFrom > 0 {
+				curve, err := payroll.TaxCurve(payroll.CurveRange(curveFrom, monthlySalary*2, homeCurvePoints), 1, calculate)
+				if err != nil {
+					app.serverError(w, r, err)
+					return
 				}
+				result.Curve = &curve
 			}
 
 			packageResult := PackageResult{
@@ -492,6 +1056,7 @@ func (app *application) home(w http.ResponseWriter, r *http.Request) {
 				SalaryCalculation: &result,
 				EquityConfig:      equityConfig,
 				EquitySchedule:    equitySchedule,
+				EquityOutlook:     equityOutlook,
 			}
 
 			results = append(results, packageResult)
@@ -546,6 +1111,8 @@ func (app *application) home(w http.ResponseWriter, r *http.Request) {
 			packageInput := PackageInput{
 				Name:                   packageName,
 				Regime:                 regime,
+				Zone:                   string(zone),
+				Municipality:           municipality,
 				Currency:               currency,
 				ExchangeRate:           exchangeRateStr,
 				PaymentFrequency:       paymentFreq,
@@ -560,13 +1127,53 @@ func (app *application) home(w http.ResponseWriter, r *http.Request) {
 				PrimaVacacionalPercent: fmt.Sprintf("%.2f", primaPercent),
 				HasFondoAhorro:         hasFondo,
 				FondoAhorroPercent:     fmt.Sprintf("%.2f", fondoPercent),
+				HasInfonavitCredit:     hasInfonavit,
+				InfonavitCreditType:    string(infonavitCredit.Type),
+				InfonavitCreditValue:   fmt.Sprintf("%.2f", infonavitCredit.Value),
+				HasPTU:                 hasPTU,
+				PTUMode:                string(ptu.Mode),
+				PTUValue:               fmt.Sprintf("%.2f", ptu.Value),
+				PTUThreeYearAverage:    ptu.ThreeYearAverage.String(),
+				PTUDaysWorked:          fmt.Sprintf("%.2f", ptu.DaysWorked),
+				PTUTotalDaysWorked:     fmt.Sprintf("%.2f", ptu.TotalDaysWorked),
+				PTUTotalSalaries:       ptu.TotalSalaries.String(),
+				HasOvertime:            hasOvertime,
+				OvertimeHoursPerWeek:   fmt.Sprintf("%.2f", overtime.HoursPerWeek),
+				SundaysPerMonth:        fmt.Sprintf("%.2f", overtime.SundaysPerMonth),
+				State:                  stateCode,
+				RiskClass:              string(workRisk.Class),
+				RiskPremium:            riskPremiumStr,
+				AforeYears:             fmt.Sprintf("%d", afore.Years),
+				AforeRealReturn:        fmt.Sprintf("%.2f", afore.RealReturn),
+				AforeSalaryGrowth:      fmt.Sprintf("%.2f", afore.SalaryGrowth),
+				AforeVoluntary:         afore.VoluntaryMonthly.String(),
+				AforeInitialBalance:    afore.InitialBalance.String(),
+				HasProjection:          hasProjection,
+				ProjectionRaise:        fmt.Sprintf("%.2f", projection.AnnualRaise),
+				ProjectionInflation:    fmt.Sprintf("%.2f", projection.Inflation),
+				ProjectionPromotions:   promotionsStr,
 				UnpaidVacationDays:     fmt.Sprintf("%d", unpaidVacationDays),
+				ClientType:             string(clientType),
+				ChargesIVA:             chargesIVA,
+				IVAExpenses:            fmt.Sprintf("%.2f", ivaExpenses),
+				Expenses:               expenses,
+				BorderStimulus:         borderStimulus,
 				OtherBenefits:          otherBenefits,
 				HasEquity:              hasEquityChecked,
 				InitialEquityUSD:       initialEquityUSDVal,
 				HasRefreshers:          hasRefresh,
 				RefresherMinUSD:        refresherMinVal,
 				RefresherMaxUSD:        refresherMaxVal,
+				VestingYears:           fmt.Sprintf("%d", vestingYears),
+				VestingPercents:        vestingPercentsVal,
+				CliffMonths:            fmt.Sprintf("%d", cliffMonths),
+				VestFrequency:          string(vestFrequency),
+				EquityWithholding:      string(equityWithholding),
+				SellToCoverPercent:     fmt.Sprintf("%.2f", sellToCoverPercent),
+				PriceGrowthBear:        priceGrowthVals[0],
+				PriceGrowthBase:        priceGrowthVals[1],
+				PriceGrowthBull:        priceGrowthVals[2],
+				PriceVolatility:        priceGrowthVals[3],
 			}
 			
 			packageInputs = append(packageInputs, packageInput)
@@ -948,12 +1555,17 @@ func (app *application) salaryCalculator(w http.ResponseWriter, r *http.Request)
 	var form struct {
 		GrossMonthlySalary float64             `form:"GrossMonthlySalary"`
 		YearsOfService     int                 `form:"YearsOfService"`
+		Zone               string              `form:"Zone"`
+		Municipality       string              `form:"Municipality"`
 		Validator          validator.Validator `form:"-"`
 	}
 
 	switch r.Method {
 	case http.MethodGet:
+		form.Zone = string(payroll.ZoneGeneral)
+
 		data := app.newTemplateData(r)
+		data["BorderMunicipalities"] = payroll.BorderMunicipalities()
 		data["Form"] = form
 
 		err := response.Page(w, http.StatusOK, data, "pages/calculator.tmpl")
@@ -971,9 +1583,11 @@ func (app *application) salaryCalculator(w http.ResponseWriter, r *http.Request)
 		form.Validator.CheckField(form.GrossMonthlySalary > 0, "GrossMonthlySalary", "El salario debe ser mayor a 0")
 		form.Validator.CheckField(form.GrossMonthlySalary <= 1000000, "GrossMonthlySalary", "El salario es demasiado alto")
 		form.Validator.CheckField(form.YearsOfService >= 0, "YearsOfService", "Los años de servicio no pueden ser negativos")
+		form.Validator.CheckField(form.Zone == "" || payroll.Zone(form.Zone).Valid(), "Zone", "Selecciona una zona válida")
 
 		if form.Validator.HasErrors() {
 			data := app.newTemplateData(r)
+			data["BorderMunicipalities"] = payroll.BorderMunicipalities()
 			data["Form"] = form
 
 			err := response.Page(w, http.StatusUnprocessableEntity, data, "pages/calculator.tmpl")
@@ -994,14 +1608,35 @@ func (app *application) salaryCalculator(w http.ResponseWriter, r *http.Request)
 			return
 		}
 
-		// Calculate salary
-		result, err := app.calculateSalary(form.GrossMonthlySalary, form.YearsOfService, fiscalYear)
+		tables, err := app.loadRateTables(fiscalYear)
 		if err != nil {
 			app.serverError(w, r, err)
 			return
 		}
 
+		zone := resolveZone(form.Zone, form.Municipality)
+		form.Zone = string(zone)
+
+		minimumWage := payroll.MinimumMonthlyWage(tables, zone)
+		if money.FromFloat(form.GrossMonthlySalary) < minimumWage {
+			form.Validator.AddFieldError("GrossMonthlySalary", fmt.Sprintf("El salario es menor al salario mínimo mensual de la zona ($%s)", minimumWage))
+
+			data := app.newTemplateData(r)
+			data["BorderMunicipalities"] = payroll.BorderMunicipalities()
+			data["Form"] = form
+
+			err := response.Page(w, http.StatusUnprocessableEntity, data, "pages/calculator.tmpl")
+			if err != nil {
+				app.serverError(w, r, err)
+			}
+			return
+		}
+
+		// Calculate salary
+		result := payroll.CalculateSalary(tables, money.FromFloat(form.GrossMonthlySalary), form.YearsOfService, zone)
+
 		data := app.newTemplateData(r)
+		data["BorderMunicipalities"] = payroll.BorderMunicipalities()
 		data["Form"] = form
 		data["Result"] = result
 		data["FiscalYear"] = fiscalYear
@@ -1013,247 +1648,1724 @@ func (app *application) salaryCalculator(w http.ResponseWriter, r *http.Request)
 	}
 }
 
-// privacy displays the privacy policy (Aviso de Privacidad)
-func (app *application) privacy(w http.ResponseWriter, r *http.Request) {
-	data := app.newTemplateData(r)
-	
-	err := response.Page(w, http.StatusOK, data, "pages/privacy.tmpl")
-	if err != nil {
-		app.serverError(w, r, err)
+// grossFromNet finds the gross salary needed to reach a desired net (Neto a Bruto)
+func (app *application) grossFromNet(w http.ResponseWriter, r *http.Request) {
+	var form struct {
+		TargetNet            float64             `form:"TargetNet"`
+		Target               string              `form:"Target"`
+		Regime               string              `form:"Regime"`
+		IncludeLegalBenefits bool                `form:"IncludeLegalBenefits"`
+		Zone                 string              `form:"Zone"`
+		Municipality         string              `form:"Municipality"`
+		Validator            validator.Validator `form:"-"`
 	}
-}
 
-// terms displays the terms and conditions (Términos y Condiciones)
-func (app *application) terms(w http.ResponseWriter, r *http.Request) {
-	data := app.newTemplateData(r)
-	
-	err := response.Page(w, http.StatusOK, data, "pages/terms.tmpl")
-	if err != nil {
-		app.serverError(w, r, err)
-	}
-}
+	switch r.Method {
+	case http.MethodGet:
+		form.Target = string(payroll.NetFieldNetSalary)
+		form.Regime = "sueldos"
+		form.IncludeLegalBenefits = true
+		form.Zone = string(payroll.ZoneGeneral)
 
-// robotsTxt serves the robots.txt file for SEO
-func (app *application) robotsTxt(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-	w.WriteHeader(http.StatusOK)
-	
-	robotsContent := `User-agent: *
-Allow: /
+		data := app.newTemplateData(r)
+		data["BorderMunicipalities"] = payroll.BorderMunicipalities()
+		data["Form"] = form
 
-Sitemap: https://totalcomp.mx/sitemap.xml`
-	
-	w.Write([]byte(robotsContent))
-}
+		err := response.Page(w, http.StatusOK, data, "pages/gross-from-net.tmpl")
+		if err != nil {
+			app.serverError(w, r, err)
+		}
 
-// sitemapXML serves the sitemap.xml file for SEO
-func (app *application) sitemapXML(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
-	w.WriteHeader(http.StatusOK)
-	
-	sitemapContent := `<?xml version="1.0" encoding="UTF-8"?>
-<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
-  <url>
-    <loc>https://totalcomp.mx/</loc>
-    <lastmod>2025-11-19</lastmod>
-    <changefreq>weekly</changefreq>
-    <priority>1.0</priority>
-  </url>
-  <url>
-    <loc>https://totalcomp.mx/privacy</loc>
-    <lastmod>2025-11-19</lastmod>
-    <changefreq>monthly</changefreq>
-    <priority>0.5</priority>
-  </url>
-  <url>
-    <loc>https://totalcomp.mx/terms</loc>
-    <lastmod>2025-11-19</lastmod>
-    <changefreq>monthly</changefreq>
-    <priority>0.5</priority>
-  </url>
-</urlset>`
-	
-	w.Write([]byte(sitemapContent))
-}
+	case http.MethodPost:
+		err := request.DecodePostForm(r, &form)
+		if err != nil {
+			app.badRequest(w, r, err)
+			return
+		}
 
-// accountDeveloper displays the developer dashboard where users can manage their API keys
-func (app *application) accountDeveloper(w http.ResponseWriter, r *http.Request) {
-	authenticatedUser, found := contextGetAuthenticatedUser(r)
-	if !found {
-		app.notFound(w, r)
-		return
-	}
-	userID := authenticatedUser.ID
-	
-	user, found, err := app.db.GetUser(userID)
-	if err != nil {
-		app.serverError(w, r, err)
-		return
-	}
-	if !found {
-		app.notFound(w, r)
-		return
-	}
-	
-	data := app.newTemplateData(r)
-	data["User"] = user
-	
-	err = response.Page(w, http.StatusOK, data, "pages/developer.tmpl")
-	if err != nil {
-		app.serverError(w, r, err)
-	}
-}
+		form.Validator.CheckField(form.TargetNet > 0, "TargetNet", "El neto deseado debe ser mayor a 0")
+		form.Validator.CheckField(form.TargetNet <= 10000000, "TargetNet", "El neto deseado es demasiado alto")
+		form.Validator.CheckField(payroll.NetField(form.Target).Valid(), "Target", "Selecciona un tipo de neto válido")
+		form.Validator.CheckField(form.Regime == "sueldos" || form.Regime == "resico", "Regime", "Selecciona un régimen válido")
+		form.Validator.CheckField(form.Zone == "" || payroll.Zone(form.Zone).Valid(), "Zone", "Selecciona una zona válida")
 
-// generateAPIKey generates or regenerates an API key for the authenticated user
-func (app *application) generateAPIKey(w http.ResponseWriter, r *http.Request) {
-	authenticatedUser, found := contextGetAuthenticatedUser(r)
-	if !found {
-		app.notFound(w, r)
-		return
-	}
-	userID := authenticatedUser.ID
-	
-	// Generate a secure random API key (32 characters)
-	apiKey, err := app.generateSecureAPIKey()
-	if err != nil {
-		app.serverError(w, r, err)
-		return
-	}
-	
-	// Store the API key in the database (plain text for now, could hash later)
-	err = app.db.UpdateUserAPIKey(userID, apiKey)
+		if form.Validator.HasErrors() {
+			data := app.newTemplateData(r)
+			data["BorderMunicipalities"] = payroll.BorderMunicipalities()
+			data["Form"] = form
+
+			err := response.Page(w, http.StatusUnprocessableEntity, data, "pages/gross-from-net.tmpl")
+			if err != nil {
+				app.serverError(w, r, err)
+			}
+			return
+		}
+
+		fiscalYear, found, err := app.db.GetActiveFiscalYear()
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+		if !found {
+			app.serverError(w, r, err)
+			return
+		}
+
+		tables, err := app.loadRateTables(fiscalYear)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		zone := resolveZone(form.Zone, form.Municipality)
+		form.Zone = string(zone)
+
+		var solution payroll.GrossSolution
+		if form.Regime == "resico" {
+			solution, err = payroll.SolveRESICOGross(tables, payroll.RESICOInput{
+				ExchangeRate: money.One,
+			}, payroll.NetField(form.Target), money.FromFloat(form.TargetNet))
+		} else {
+			input := payroll.SalaryInput{
+				YearsOfService: 1,
+				Zone:           zone,
+				ExchangeRate:   money.One,
+			}
+			if form.IncludeLegalBenefits {
+				// Minimum benefits required by the LFT
+				input.HasAguinaldo = true
+				input.AguinaldoDays = 15
+				input.HasPrimaVacacional = true
+				input.VacationDays = 12
+				input.PrimaVacacionalPercent = 25
+			}
+			solution, err = payroll.SolveSalaryGross(tables, input, payroll.NetField(form.Target), money.FromFloat(form.TargetNet))
+		}
+		if errors.Is(err, payroll.ErrTargetUnreachable) {
+			form.Validator.AddFieldError("TargetNet", "No es posible alcanzar ese neto")
+		} else if err == nil && form.Regime == "sueldos" && solution.GrossMonthlySalary < payroll.MinimumMonthlyWage(tables, zone) {
+			form.Validator.AddFieldError("TargetNet", fmt.Sprintf("Ese neto requiere un salario menor al mínimo mensual de la zona ($%s)", payroll.MinimumMonthlyWage(tables, zone)))
+		}
+		if form.Validator.HasErrors() {
+			data := app.newTemplateData(r)
+			data["BorderMunicipalities"] = payroll.BorderMunicipalities()
+			data["Form"] = form
+
+			err := response.Page(w, http.StatusUnprocessableEntity, data, "pages/gross-from-net.tmpl")
+			if err != nil {
+				app.serverError(w, r, err)
+			}
+			return
+		}
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		data := app.newTemplateData(r)
+		data["BorderMunicipalities"] = payroll.BorderMunicipalities()
+		data["Form"] = form
+		data["Solution"] = solution
+		data["Result"] = solution.Calculation
+		data["FiscalYear"] = fiscalYear
+
+		err = response.Page(w, http.StatusOK, data, "pages/gross-from-net.tmpl")
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+	}
+}
+
+// annualReturn simulates the annual tax return (declaración anual) of a salaried
+// employee with the Article 151 personal deductions
+func (app *application) annualReturn(w http.ResponseWriter, r *http.Request) {
+	var form struct {
+		GrossMonthlySalary      float64             `form:"GrossMonthlySalary"`
+		YearsOfService          int                 `form:"YearsOfService"`
+		Zone                    string              `form:"Zone"`
+		Municipality            string              `form:"Municipality"`
+		MedicalExpenses         float64             `form:"MedicalExpenses"`
+		MortgageInterest        float64             `form:"MortgageInterest"`
+		RetirementContributions float64             `form:"RetirementContributions"`
+		TuitionLevel            []string            `form:"TuitionLevel"`  // One entry per student
+		TuitionAmount           []string            `form:"TuitionAmount"` // Parallel to TuitionLevel
+		Validator               validator.Validator `form:"-"`
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		form.Zone = string(payroll.ZoneGeneral)
+
+		data := app.newTemplateData(r)
+		data["BorderMunicipalities"] = payroll.BorderMunicipalities()
+		data["Form"] = form
+
+		err := response.Page(w, http.StatusOK, data, "pages/annual-return.tmpl")
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+
+	case http.MethodPost:
+		err := request.DecodePostForm(r, &form)
+		if err != nil {
+			app.badRequest(w, r, err)
+			return
+		}
+
+		form.Validator.CheckField(form.GrossMonthlySalary > 0, "GrossMonthlySalary", "El salario debe ser mayor a 0")
+		form.Validator.CheckField(form.GrossMonthlySalary <= 1000000, "GrossMonthlySalary", "El salario es demasiado alto")
+		form.Validator.CheckField(form.YearsOfService >= 0, "YearsOfService", "Los años de servicio no pueden ser negativos")
+		form.Validator.CheckField(form.Zone == "" || payroll.Zone(form.Zone).Valid(), "Zone", "Selecciona una zona válida")
+		form.Validator.CheckField(form.MedicalExpenses >= 0, "MedicalExpenses", "El monto no puede ser negativo")
+		form.Validator.CheckField(form.MortgageInterest >= 0, "MortgageInterest", "El monto no puede ser negativo")
+		form.Validator.CheckField(form.RetirementContributions >= 0, "RetirementContributions", "El monto no puede ser negativo")
+		form.Validator.CheckField(money.ValidAmounts(form.MedicalExpenses), "MedicalExpenses", "El monto es demasiado alto")
+		form.Validator.CheckField(money.ValidAmounts(form.MortgageInterest), "MortgageInterest", "El monto es demasiado alto")
+		form.Validator.CheckField(money.ValidAmounts(form.RetirementContributions), "RetirementContributions", "El monto es demasiado alto")
+
+		// Colegiaturas: rows without an amount are ignored
+		var deductions payroll.PersonalDeductions
+		for i, amountStr := range form.TuitionAmount {
+			if amountStr == "" {
+				continue
+			}
+			var amount float64
+			_, err := fmt.Sscanf(amountStr, "%f", &amount)
+			level := ""
+			if i < len(form.TuitionLevel) {
+				level = form.TuitionLevel[i]
+			}
+			if err != nil || amount < 0 || !money.ValidAmounts(amount) {
+				form.Validator.AddFieldError("Tuition", "Ingresa montos de colegiatura válidos")
+				break
+			}
+			if !payroll.SchoolLevel(level).Valid() {
+				form.Validator.AddFieldError("Tuition", "Selecciona el nivel escolar de cada colegiatura")
+				break
+			}
+			deductions.Tuition = append(deductions.Tuition, payroll.Tuition{Level: payroll.SchoolLevel(level), Amount: money.FromFloat(amount)})
+		}
+
+		if form.Validator.HasErrors() {
+			data := app.newTemplateData(r)
+			data["BorderMunicipalities"] = payroll.BorderMunicipalities()
+			data["Form"] = form
+
+			err := response.Page(w, http.StatusUnprocessableEntity, data, "pages/annual-return.tmpl")
+			if err != nil {
+				app.serverError(w, r, err)
+			}
+			return
+		}
+
+		// Get fiscal year configuration
+		fiscalYear, found, err := app.db.GetActiveFiscalYear()
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+		if !found {
+			app.serverError(w, r, err)
+			return
+		}
+
+		tables, err := app.loadRateTables(fiscalYear)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		zone := resolveZone(form.Zone, form.Municipality)
+		form.Zone = string(zone)
+
+		minimumWage := payroll.MinimumMonthlyWage(tables, zone)
+		if money.FromFloat(form.GrossMonthlySalary) < minimumWage {
+			form.Validator.AddFieldError("GrossMonthlySalary", fmt.Sprintf("El salario es menor al salario mínimo mensual de la zona ($%s)", minimumWage))
+			data := app.newTemplateData(r)
+			data["BorderMunicipalities"] = payroll.BorderMunicipalities()
+			data["Form"] = form
+
+			err := response.Page(w, http.StatusUnprocessableEntity, data, "pages/annual-return.tmpl")
+			if err != nil {
+				app.serverError(w, r, err)
+			}
+			return
+		}
+
+		// A year of salary with the statutory aguinaldo and prima vacacional
+		calculation, err := app.calculateSalaryWithBenefits(tables, payroll.SalaryInput{
+			GrossMonthlySalary: money.FromFloat(form.GrossMonthlySalary),
+			YearsOfService:     form.YearsOfService,
+			Zone:               zone,
+			HasAguinaldo:       true,
+			HasPrimaVacacional: true,
+			ExchangeRate:       money.One, // MXN
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		deductions.MedicalExpenses = money.FromFloat(form.MedicalExpenses)
+		deductions.MortgageInterest = money.FromFloat(form.MortgageInterest)
+		deductions.RetirementContributions = money.FromFloat(form.RetirementContributions)
+
+		data := app.newTemplateData(r)
+		data["BorderMunicipalities"] = payroll.BorderMunicipalities()
+		data["Form"] = form
+		data["Calculation"] = calculation
+		data["Result"] = payroll.CalculateAnnualReturn(tables, calculation, deductions)
+		data["FiscalYear"] = fiscalYear
+
+		err = response.Page(w, http.StatusOK, data, "pages/annual-return.tmpl")
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+	}
+}
+
+// privacy displays the privacy policy (Aviso de Privacidad)
+func (app *application) severance(w http.ResponseWriter, r *http.Request) {
+	var form struct {
+		GrossMonthlySalary     float64             `form:"GrossMonthlySalary"`
+		HireDate               string              `form:"HireDate"` // YYYY-MM-DD
+		ExitDate               string              `form:"ExitDate"` // YYYY-MM-DD
+		Type                   string              `form:"Type"`
+		Zone                   string              `form:"Zone"`
+		Municipality           string              `form:"Municipality"`
+		AguinaldoDays          int                 `form:"AguinaldoDays"`
+		PrimaVacacionalPercent float64             `form:"PrimaVacacionalPercent"`
+		VacationDaysTaken      float64             `form:"VacationDaysTaken"`
+		PendingVacationDays    float64             `form:"PendingVacationDays"`
+		UnpaidSalaryDays       int                 `form:"UnpaidSalaryDays"`
+		Validator              validator.Validator `form:"-"`
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		form.Zone = string(payroll.ZoneGeneral)
+		form.Type = string(payroll.SeparationResignation)
+		form.ExitDate = time.Now().Format("2006-01-02")
+
+		data := app.newTemplateData(r)
+		data["BorderMunicipalities"] = payroll.BorderMunicipalities()
+		data["Form"] = form
+
+		err := response.Page(w, http.StatusOK, data, "pages/severance.tmpl")
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+
+	case http.MethodPost:
+		err := request.DecodePostForm(r, &form)
+		if err != nil {
+			app.badRequest(w, r, err)
+			return
+		}
+
+		hireDate, hireErr := time.Parse("2006-01-02", form.HireDate)
+		exitDate, exitErr := time.Parse("2006-01-02", form.ExitDate)
+
+		form.Validator.CheckField(form.GrossMonthlySalary > 0, "GrossMonthlySalary", "El salario debe ser mayor a 0")
+		form.Validator.CheckField(form.GrossMonthlySalary <= 1000000, "GrossMonthlySalary", "El salario es demasiado alto")
+		form.Validator.CheckField(hireErr == nil, "HireDate", "Ingresa una fecha de ingreso válida")
+		form.Validator.CheckField(exitErr == nil, "ExitDate", "Ingresa una fecha de salida válida")
+		form.Validator.CheckField(hireErr != nil || exitErr != nil || !exitDate.Before(hireDate), "ExitDate", "La fecha de salida debe ser posterior a la de ingreso")
+		form.Validator.CheckField(payroll.SeparationType(form.Type).Valid(), "Type", "Selecciona el motivo de salida")
+		form.Validator.CheckField(form.Zone == "" || payroll.Zone(form.Zone).Valid(), "Zone", "Selecciona una zona válida")
+		form.Validator.CheckField(form.AguinaldoDays >= 0, "AguinaldoDays", "Los días no pueden ser negativos")
+		form.Validator.CheckField(form.PrimaVacacionalPercent >= 0, "PrimaVacacionalPercent", "El porcentaje no puede ser negativo")
+		form.Validator.CheckField(form.VacationDaysTaken >= 0, "VacationDaysTaken", "Los días no pueden ser negativos")
+		form.Validator.CheckField(form.PendingVacationDays >= 0, "PendingVacationDays", "Los días no pueden ser negativos")
+		form.Validator.CheckField(form.UnpaidSalaryDays >= 0, "UnpaidSalaryDays", "Los días no pueden ser negativos")
+
+		if form.Validator.HasErrors() {
+			data := app.newTemplateData(r)
+			data["BorderMunicipalities"] = payroll.BorderMunicipalities()
+			data["Form"] = form
+
+			err := response.Page(w, http.StatusUnprocessableEntity, data, "pages/severance.tmpl")
+			if err != nil {
+				app.serverError(w, r, err)
+			}
+			return
+		}
+
+		// Get fiscal year configuration
+		fiscalYear, found, err := app.db.GetActiveFiscalYear()
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+		if !found {
+			app.serverError(w, r, err)
+			return
+		}
+
+		tables, err := app.loadRateTables(fiscalYear)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		zone := resolveZone(form.Zone, form.Municipality)
+		form.Zone = string(zone)
+
+		minimumWage := payroll.MinimumMonthlyWage(tables, zone)
+		if money.FromFloat(form.GrossMonthlySalary) < minimumWage {
+			form.Validator.AddFieldError("GrossMonthlySalary", fmt.Sprintf("El salario es menor al salario mínimo mensual de la zona ($%s)", minimumWage))
+			data := app.newTemplateData(r)
+			data["BorderMunicipalities"] = payroll.BorderMunicipalities()
+			data["Form"] = form
+
+			err := response.Page(w, http.StatusUnprocessableEntity, data, "pages/severance.tmpl")
+			if err != nil {
+				app.serverError(w, r, err)
+			}
+			return
+		}
+
+		input := payroll.SeveranceInput{
+			GrossMonthlySalary:     money.FromFloat(form.GrossMonthlySalary),
+			Zone:                   zone,
+			HireDate:               hireDate,
+			ExitDate:               exitDate,
+			Type:                   payroll.SeparationType(form.Type),
+			AguinaldoDays:          form.AguinaldoDays,
+			PrimaVacacionalPercent: form.PrimaVacacionalPercent,
+			VacationDaysTaken:      form.VacationDaysTaken,
+			PendingVacationDays:    form.PendingVacationDays,
+			UnpaidSalaryDays:       form.UnpaidSalaryDays,
+		}
+
+		result, err := payroll.CalculateSeverance(tables, input)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		// Keep the last calculation for the PDF export
+		app.sessionManager.Put(r.Context(), "severanceInput", input)
+		app.sessionManager.Put(r.Context(), "severanceResult", result)
+
+		data := app.newTemplateData(r)
+		data["BorderMunicipalities"] = payroll.BorderMunicipalities()
+		data["Form"] = form
+		data["Result"] = result
+		data["FiscalYear"] = fiscalYear
+
+		err = response.Page(w, http.StatusOK, data, "pages/severance.tmpl")
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+	}
+}
+
+func (app *application) privacy(w http.ResponseWriter, r *http.Request) {
+	data := app.newTemplateData(r)
+	
+	err := response.Page(w, http.StatusOK, data, "pages/privacy.tmpl")
 	if err != nil {
 		app.serverError(w, r, err)
-		return
 	}
-	
-	// Redirect back to developer dashboard
-	http.Redirect(w, r, "/account/developer", http.StatusSeeOther)
 }
 
-// developersPage renders the public marketing page for the API
-func (app *application) developersPage(w http.ResponseWriter, r *http.Request) {
+// terms displays the terms and conditions (Términos y Condiciones)
+func (app *application) terms(w http.ResponseWriter, r *http.Request) {
 	data := app.newTemplateData(r)
 	
-	err := response.Page(w, http.StatusOK, data, "pages/developers.tmpl")
+	err := response.Page(w, http.StatusOK, data, "pages/terms.tmpl")
 	if err != nil {
 		app.serverError(w, r, err)
 	}
 }
 
-// verifyEmail handles the email verification flow
-func (app *application) verifyEmail(w http.ResponseWriter, r *http.Request) {
-	// Get verification token from URL
-	plaintextToken := r.PathValue("plaintextToken")
+// robotsTxt serves the robots.txt file for SEO
+func (app *application) robotsTxt(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	
+	robotsContent := `User-agent: *
+Allow: /
 
-	// Hash the token to compare with database
-	hashedToken := token.Hash(plaintextToken)
+Sitemap: https://totalcomp.mx/sitemap.xml`
+	
+	w.Write([]byte(robotsContent))
+}
+
+// sitemapXML serves the sitemap.xml file for SEO
+func (app *application) sitemapXML(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	
+	sitemapContent := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>https://totalcomp.mx/</loc>
+    <lastmod>2025-11-19</lastmod>
+    <changefreq>weekly</changefreq>
+    <priority>1.0</priority>
+  </url>
+  <url>
+    <loc>https://totalcomp.mx/privacy</loc>
+    <lastmod>2025-11-19</lastmod>
+    <changefreq>monthly</changefreq>
+    <priority>0.5</priority>
+  </url>
+  <url>
+    <loc>https://totalcomp.mx/terms</loc>
+    <lastmod>2025-11-19</lastmod>
+    <changefreq>monthly</changefreq>
+    <priority>0.5</priority>
+  </url>
+</urlset>`
+	
+	w.Write([]byte(sitemapContent))
+}
+
+// accountDeveloper displays the developer dashboard where users can manage their API keys
+func (app *application) accountDeveloper(w http.ResponseWriter, r *http.Request) {
+	authenticatedUser, found := contextGetAuthenticatedUser(r)
+	if !found {
+		app.notFound(w, r)
+		return
+	}
+	userID := authenticatedUser.ID
+	
+	user, found, err := app.db.GetUser(userID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+	if !found {
+		app.notFound(w, r)
+		return
+	}
+	
+	data := app.newTemplateData(r)
+	data["User"] = user
+	
+	err = response.Page(w, http.StatusOK, data, "pages/developer.tmpl")
+	if err != nil {
+		app.serverError(w, r, err)
+	}
+}
+
+// generateAPIKey generates or regenerates an API key for the authenticated user
+func (app *application) generateAPIKey(w http.ResponseWriter, r *http.Request) {
+	authenticatedUser, found := contextGetAuthenticatedUser(r)
+	if !found {
+		app.notFound(w, r)
+		return
+	}
+	userID := authenticatedUser.ID
+	
+	// Generate a secure random API key (32 characters)
+	apiKey, err := app.generateSecureAPIKey()
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+	
+	// Store the API key in the database (plain text for now, could hash later)
+	err = app.db.UpdateUserAPIKey(userID, apiKey)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+	
+	// Redirect back to developer dashboard
+	http.Redirect(w, r, "/account/developer", http.StatusSeeOther)
+}
+
+// developersPage renders the public marketing page for the API
+func (app *application) developersPage(w http.ResponseWriter, r *http.Request) {
+	data := app.newTemplateData(r)
+	
+	err := response.Page(w, http.StatusOK, data, "pages/developers.tmpl")
+	if err != nil {
+		app.serverError(w, r, err)
+	}
+}
+
+// verifyEmail handles the email verification flow
+func (app *application) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	// Get verification token from URL
+	plaintextToken := r.PathValue("plaintextToken")
+
+	// Hash the token to compare with database
+	hashedToken := token.Hash(plaintextToken)
+
+	// Get user ID from token (validates expiry - 24 hours)
+	userID, found, err := app.db.GetUserIDFromVerificationToken(hashedToken)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if !found {
+		data := app.newTemplateData(r)
+		data["Message"] = "El enlace de verificación es inválido o ha expirado."
+		err := response.Page(w, http.StatusBadRequest, data, "pages/email-verification-error.tmpl")
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	// Mark email as verified
+	err = app.db.VerifyUserEmail(userID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// Show success page
+	data := app.newTemplateData(r)
+	err = response.Page(w, http.StatusOK, data, "pages/email-verification-success.tmpl")
+	if err != nil {
+		app.serverError(w, r, err)
+	}
+}
+
+// resendVerificationEmail generates a new verification token and sends it to the user's email
+func (app *application) resendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	// Get authenticated user ID from session
+	userID := app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
+	
+	// Get user email from database
+	user, found, err := app.db.GetUser(userID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+	
+	if !found {
+		app.sessionManager.Put(r.Context(), "flash", "Usuario no encontrado")
+		http.Redirect(w, r, "/account/developer", http.StatusSeeOther)
+		return
+	}
+	
+	// Check if already verified
+	if user.EmailVerified {
+		app.sessionManager.Put(r.Context(), "flash", "Tu email ya está verificado")
+		http.Redirect(w, r, "/account/developer", http.StatusSeeOther)
+		return
+	}
+	
+	// Generate new verification token
+	plaintextToken := token.New()
+	hashedToken := token.Hash(plaintextToken)
+	
+	// Delete any existing verification tokens for this user
+	err = app.db.DeleteEmailVerificationTokensForUser(userID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+	
+	// Store new verification token in database
+	err = app.db.InsertEmailVerificationToken(userID, hashedToken)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+	
+	// Send verification email in background
+	app.backgroundTask(r, func() error {
+		data := app.newEmailData()
+		data["Email"] = user.Email
+		data["VerificationToken"] = plaintextToken
+		return app.mailer.Send(user.Email, data, "welcome.tmpl")
+	})
+	
+	app.sessionManager.Put(r.Context(), "flash", "Email de verificación reenviado. Revisa tu bandeja de entrada.")
+	http.Redirect(w, r, "/account/developer", http.StatusSeeOther)
+}
+
+// apiCalculate is the main API endpoint for salary calculations (JSON API)
+func (app *application) apiCalculate(w http.ResponseWriter, r *http.Request) {
+	// Parse JSON request body
+	var req struct {
+		Salary                  float64 `json:"salary"`     // Paid every pay_period
+		PayPeriod               string  `json:"pay_period"` // "MONTHLY" (default), "SEMIMONTHLY", "BIWEEKLY", "WEEKLY" or "DAILY"
+		Regime                  string  `json:"regime"` // "sueldos", "asimilados", "resico" or "actividad_empresarial"
+		YearsOfService          int     `json:"years_of_service"` // Drives default vacation days and the SBC
+		Zone                    string  `json:"zone"`         // "GENERAL", "FRONTERA_NORTE" or "FRONTERA_SUR"
+		Municipality            string  `json:"municipality"` // Optional; a border municipality overrides zone
+		HasAguinaldo            bool    `json:"has_aguinaldo"`
+		AguinaldoDays           int     `json:"aguinaldo_days"`
+		HasValesDespensa        bool    `json:"has_vales_despensa"`
+		ValesDespensaAmount     float64 `json:"vales_despensa_amount"`
+		HasPrimaVacacional      bool    `json:"has_prima_vacacional"`
+		VacationDays            int     `json:"vacation_days"`
+		PrimaVacacionalPercent  float64 `json:"prima_vacacional_percent"`
+		HasFondoAhorro          bool    `json:"has_fondo_ahorro"`
+		FondoAhorroPercent      float64 `json:"fondo_ahorro_percent"`
+		HasInfonavitCredit      bool    `json:"has_infonavit_credit"`
+		InfonavitCreditType     string  `json:"infonavit_credit_type"`  // "PERCENTAGE", "FIXED_PESOS" or "VSM"
+		InfonavitCreditValue    float64 `json:"infonavit_credit_value"` // Percent of SBC, monthly pesos or UMI factor
+		HasPTU                  bool    `json:"has_ptu"`
+		PTUMode                 string  `json:"ptu_mode"`               // "EMPLOYEE_SHARE", "DAYS" or "COMPANY_POOL"
+		PTUValue                float64 `json:"ptu_value"`              // The employee's own PTU pesos, days of salary or the company's distributable PTU
+		PTUThreeYearAverage     float64 `json:"ptu_three_year_average"` // Optional; raises the three-month cap
+		PTUDaysWorked           float64 `json:"ptu_days_worked"`        // COMPANY_POOL: the employee's days worked, 365 by default
+		PTUTotalDaysWorked      float64 `json:"ptu_total_days_worked"`  // COMPANY_POOL: days worked by all the workers
+		PTUTotalSalaries        float64 `json:"ptu_total_salaries"`     // COMPANY_POOL: salaries earned by all the workers in the year
+		HasOvertime             bool    `json:"has_overtime"`
+		OvertimeHoursPerWeek    float64 `json:"overtime_hours_per_week"` // Double up to 9, triple after
+		SundaysPerMonth         float64 `json:"sundays_per_month"`       // Paid with 25% prima dominical
+		State                   string  `json:"state"`                   // Optional state code, e.g. "CDMX"; adds the employer cost with its ISN
+		RiskClass               string  `json:"risk_class"`              // Optional IMSS risk class "I" to "V"; uses the prima media of the class
+		RiskPremium             float64 `json:"risk_premium"`            // Optional exact prima de riesgo in percent; wins over risk_class
+		AforeYears              int     `json:"afore_years"`             // Optional; adds the AFORE projection over this many years
+		AforeRealReturn         float64 `json:"afore_real_return"`       // Yearly real return in percent
+		AforeSalaryGrowth       float64 `json:"afore_salary_growth"`     // Yearly real salary growth in percent
+		AforeVoluntaryMonthly   float64 `json:"afore_voluntary_monthly"` // Monthly voluntary contributions
+		AforeInitialBalance     float64 `json:"afore_initial_balance"`   // Current AFORE balance
+		ProjectionYears         int     `json:"projection_years"`        // Optional; adds the multi-year projection over this many years
+		AnnualRaise             float64 `json:"annual_raise"`            // Projection: nominal raise every year in percent
+		Inflation               float64 `json:"inflation"`               // Projection: expected yearly INPC inflation in percent
+		Promotions              []struct {
+			Year  int     `json:"year"`  // Projection year it takes effect, from 2
+			Raise float64 `json:"raise"` // Percent on top of the yearly raise
+		} `json:"promotions"`
+		InitialEquityUSD        float64   `json:"initial_equity_usd"` // Optional; adds the equity vesting schedule
+		RefresherMinUSD         float64   `json:"refresher_min_usd"`  // Optional yearly refresher range; the average is granted
+		RefresherMaxUSD         float64   `json:"refresher_max_usd"`
+		VestingYears            int       `json:"vesting_years"`    // Equal vesting over this many years; 4 by default
+		VestingPercents         []float64 `json:"vesting_percents"` // Percent vesting each year, e.g. [5, 15, 40, 40]; wins over vesting_years
+		CliffMonths             int       `json:"cliff_months"`     // Nothing from the initial grant vests before the cliff
+		VestFrequency           string    `json:"vest_frequency"`   // "ANNUAL" (default), "QUARTERLY" or "MONTHLY"
+		EquityWithholding       string    `json:"equity_withholding"`    // "PAYROLL" (default) or "SELL_TO_COVER"
+		SellToCoverPercent      float64   `json:"sell_to_cover_percent"` // Percent of every vest sold; the marginal ISR rate when 0
+		PriceGrowthBear         float64   `json:"price_growth_bear"`     // Optional expected yearly share price growth in percent; adds the scenarios
+		PriceGrowthBase         float64   `json:"price_growth_base"`
+		PriceGrowthBull         float64   `json:"price_growth_bull"`
+		PriceVolatility         float64   `json:"price_volatility"` // Yearly volatility in percent; adds the Monte Carlo percentiles
+		UnpaidVacationDays      int     `json:"unpaid_vacation_days"` // RESICO only
+		ClientType              string  `json:"client_type"`          // RESICO only: "PERSONA_FISICA" (default) or "PERSONA_MORAL"
+		ChargesIVA              bool    `json:"charges_iva"`          // RESICO only: invoices add 16% IVA
+		IVAExpenses             float64 `json:"iva_expenses"`         // RESICO only: monthly expenses with acreditable IVA
+		Expenses                map[string]float64 `json:"expenses"` // Actividad Empresarial only: deductible expenses by category
+		BorderStimulus          bool    `json:"border_stimulus"`      // Actividad Empresarial only: registered in the padrón of the border ISR stimulus
+	}
+	
+	err := request.DecodeJSON(w, r, &req)
+	if err != nil {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid JSON request body",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	
+	// ?explain=true adds the step-by-step trace of the calculation
+	explain, _ := strconv.ParseBool(r.URL.Query().Get("explain"))
+
+	// Validate input
+	if req.Salary <= 0 {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Salary must be greater than 0",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	amounts := []float64{req.Salary, req.ValesDespensaAmount, req.InfonavitCreditValue, req.PTUValue, req.PTUThreeYearAverage, req.PTUTotalSalaries,
+		req.AforeVoluntaryMonthly, req.AforeInitialBalance, req.InitialEquityUSD, req.RefresherMinUSD, req.RefresherMaxUSD, req.IVAExpenses}
+	for _, amount := range req.Expenses {
+		amounts = append(amounts, amount)
+	}
+	if !money.ValidAmounts(amounts...) {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("amounts cannot exceed %s", money.MaxAmount),
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.HasInfonavitCredit && req.InfonavitCreditValue > 0 && !payroll.InfonavitCreditType(req.InfonavitCreditType).Valid() {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "infonavit_credit_type must be one of PERCENTAGE, FIXED_PESOS, VSM",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.HasPTU && !payroll.PTUMode(req.PTUMode).Valid() {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "ptu_mode must be one of EMPLOYEE_SHARE, DAYS, COMPANY_POOL",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.PTUValue < 0 || req.PTUThreeYearAverage < 0 {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "ptu_value and ptu_three_year_average cannot be negative",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if !(req.PTUDaysWorked >= 0 && req.PTUDaysWorked <= 366) {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "ptu_days_worked must be between 0 and 366",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.OvertimeHoursPerWeek < 0 || req.SundaysPerMonth < 0 {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "overtime_hours_per_week and sundays_per_month cannot be negative",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.AforeYears < 0 || req.AforeYears > payroll.MaxAforeYears {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("afore_years must be between 0 and %d", payroll.MaxAforeYears),
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.AforeRealReturn < payroll.MinAforeRate || req.AforeRealReturn > payroll.MaxAforeRate ||
+		req.AforeSalaryGrowth < payroll.MinAforeRate || req.AforeSalaryGrowth > payroll.MaxAforeRate ||
+		req.AforeVoluntaryMonthly < 0 || req.AforeInitialBalance < 0 {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("afore_real_return and afore_salary_growth must be between %d and %d; afore_voluntary_monthly and afore_initial_balance cannot be negative", payroll.MinAforeRate, payroll.MaxAforeRate),
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.ProjectionYears < 0 || req.ProjectionYears > payroll.MaxProjectionYears {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("projection_years must be between 0 and %d", payroll.MaxProjectionYears),
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.AnnualRaise < payroll.MinProjectionRate || req.AnnualRaise > payroll.MaxProjectionRate ||
+		req.Inflation < payroll.MinProjectionRate || req.Inflation > payroll.MaxProjectionRate {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("annual_raise and inflation must be between %d and %d", payroll.MinProjectionRate, payroll.MaxProjectionRate),
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	var promotions []payroll.Promotion
+	for _, promotion := range req.Promotions {
+		if promotion.Year < 2 || promotion.Year > req.ProjectionYears || promotion.Raise <= -100 {
+			err := response.JSON(w, http.StatusBadRequest, map[string]string{
+				"error": "promotions must take effect between year 2 and projection_years with a raise above -100",
+			})
+			if err != nil {
+				app.serverError(w, r, err)
+			}
+			return
+		}
+		promotions = append(promotions, payroll.Promotion{Year: promotion.Year, Raise: promotion.Raise})
+	}
+	equityConfig := equity.EquityConfig{
+		InitialGrantUSD:    req.InitialEquityUSD,
+		HasRefreshers:      req.RefresherMinUSD > 0 && req.RefresherMaxUSD > 0,
+		RefresherMinUSD:    req.RefresherMinUSD,
+		RefresherMaxUSD:    req.RefresherMaxUSD,
+		VestingYears:       req.VestingYears,
+		VestingPercents:    req.VestingPercents,
+		CliffMonths:        req.CliffMonths,
+		VestFrequency:      equity.VestFrequency(req.VestFrequency),
+		Withholding:        equity.Withholding(req.EquityWithholding),
+		SellToCoverPercent: req.SellToCoverPercent,
+	}
+	if err := equityConfig.Validate(); err != nil {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	priceGrowth := equity.PriceGrowth{
+		Bear:       req.PriceGrowthBear,
+		Base:       req.PriceGrowthBase,
+		Bull:       req.PriceGrowthBull,
+		Volatility: req.PriceVolatility,
+	}
+	if err := priceGrowth.Validate(); err != nil {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.Zone != "" && !payroll.Zone(req.Zone).Valid() {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "zone must be one of GENERAL, FRONTERA_NORTE, FRONTERA_SUR",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.ClientType != "" && !payroll.ClientType(req.ClientType).Valid() {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "client_type must be one of PERSONA_FISICA, PERSONA_MORAL",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.IVAExpenses < 0 {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "iva_expenses cannot be negative",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	for category, amount := range req.Expenses {
+		if !payroll.ExpenseCategory(category).Valid() || amount < 0 {
+			err := response.JSON(w, http.StatusBadRequest, map[string]string{
+				"error": "expenses keys must be one of RENT, SERVICES, SUPPLIES, PROFESSIONAL_FEES, TRAVEL, EQUIPMENT with non-negative amounts",
+			})
+			if err != nil {
+				app.serverError(w, r, err)
+			}
+			return
+		}
+	}
+	if req.PayPeriod == "" {
+		req.PayPeriod = string(payroll.PeriodMonthly)
+	}
+	if !payroll.Periodicity(req.PayPeriod).IsPayPeriod() {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "pay_period must be one of MONTHLY, SEMIMONTHLY, BIWEEKLY, WEEKLY, DAILY",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	
+	// Get fiscal year configuration
+	fiscalYear, found, err := app.db.GetActiveFiscalYear()
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+	if !found {
+		err := response.JSON(w, http.StatusInternalServerError, map[string]string{
+			"error": "No active fiscal year configuration found",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	
+	tables, err := app.loadRateTables(fiscalYear)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+	if _, found := tables.StatePayrollTax(req.State); req.State != "" && !found {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("Unknown state %q", req.State),
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	workRisk := payroll.WorkRisk{Class: payroll.RiskClass(req.RiskClass), Premium: req.RiskPremium}
+	if _, err := tables.RiskPremium(workRisk); err != nil {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	
+	zone := resolveZone(req.Zone, req.Municipality)
+	payPeriod := payroll.Periodicity(req.PayPeriod)
+	monthlySalary := payroll.MonthlyFromPeriod(money.FromFloat(req.Salary), payPeriod)
+	if req.Regime != "resico" && req.Regime != "actividad_empresarial" && req.Regime != "asimilados" && monthlySalary < payroll.MinimumMonthlyWage(tables, zone) {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("Salary is below the monthly minimum wage of the zone (%s)", payroll.MinimumMonthlyWage(tables, zone)),
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	
+	// Calculate based on regime. The projection calls it again with the salary
+	// and seniority of every year.
+	calculate := func(grossMonthlySalary money.Money, yearsOfService int) (database.SalaryCalculation, error) {
+		if req.Regime == "resico" {
+			// RESICO calculation
+			return app.calculateRESICO(tables, payroll.RESICOInput{
+				MonthlyIncome:      grossMonthlySalary,
+				UnpaidVacationDays: req.UnpaidVacationDays,
+				ClientType:         payroll.ClientType(req.ClientType),
+				ChargesIVA:         req.ChargesIVA,
+				IVAExpenses:        money.FromFloat(req.IVAExpenses),
+				ExchangeRate:       money.One,
+			})
+		} else if req.Regime == "actividad_empresarial" {
+			// Actividad Empresarial: progressive ISR on income minus deductible expenses
+			var expenses []payroll.Expense
+			for _, category := range payroll.ExpenseCategories() {
+				if amount := req.Expenses[string(category)]; amount > 0 {
+					expenses = append(expenses, payroll.Expense{Category: category, Amount: money.FromFloat(amount)})
+				}
+			}
+			return app.calculateActividadEmpresarial(tables, payroll.ActividadEmpresarialInput{
+				MonthlyIncome:      grossMonthlySalary,
+				Expenses:           expenses,
+				UnpaidVacationDays: req.UnpaidVacationDays,
+				ClientType:         payroll.ClientType(req.ClientType),
+				ChargesIVA:         req.ChargesIVA,
+				Zone:               zone,
+				BorderStimulus:     req.BorderStimulus,
+				ExchangeRate:       money.One,
+				Explain:            explain,
+			})
+		} else if req.Regime == "asimilados" {
+			// Asimilados: salary ISR only, no IMSS and no statutory benefits
+			return app.calculateAsimilados(tables, payroll.AsimiladosInput{
+				GrossMonthlySalary: grossMonthlySalary,
+				Zone:               zone,
+				PayPeriod:          payPeriod,
+				WorkRisk:           workRisk,
+				ExchangeRate:       money.One,
+				Explain:            explain,
+			})
+		} else {
+			// Sueldos y Salarios calculation (default)
+			return app.calculateSalaryWithBenefits(tables, payroll.SalaryInput{
+				GrossMonthlySalary:     grossMonthlySalary,
+				YearsOfService:         yearsOfService,
+				Zone:                   zone,
+				PayPeriod:              payPeriod,
+				HasAguinaldo:           req.HasAguinaldo,
+				AguinaldoDays:          req.AguinaldoDays,
+				HasValesDespensa:       req.HasValesDespensa,
+				ValesDespensaAmount:    money.FromFloat(req.ValesDespensaAmount),
+				HasPrimaVacacional:     req.HasPrimaVacacional,
+				VacationDays:           req.VacationDays,
+				PrimaVacacionalPercent: req.PrimaVacacionalPercent,
+				HasFondoAhorro:         req.HasFondoAhorro,
+				FondoAhorroPercent:     req.FondoAhorroPercent,
+				HasInfonavitCredit:     req.HasInfonavitCredit,
+				InfonavitCredit: payroll.InfonavitCredit{
+					Type:  payroll.InfonavitCreditType(req.InfonavitCreditType),
+					Value: req.InfonavitCreditValue,
+				},
+				HasPTU: req.HasPTU,
+				PTU: payroll.PTU{
+					Mode:             payroll.PTUMode(req.PTUMode),
+					Value:            req.PTUValue,
+					ThreeYearAverage: money.FromFloat(req.PTUThreeYearAverage),
+					DaysWorked:       req.PTUDaysWorked,
+					TotalDaysWorked:  req.PTUTotalDaysWorked,
+					TotalSalaries:    money.FromFloat(req.PTUTotalSalaries),
+				},
+				HasOvertime: req.HasOvertime,
+				Overtime: payroll.Overtime{
+					HoursPerWeek:    req.OvertimeHoursPerWeek,
+					SundaysPerMonth: req.SundaysPerMonth,
+				},
+				WorkRisk: workRisk,
+				State:    req.State,
+				Afore: payroll.AforeInput{
+					Years:            req.AforeYears,
+					RealReturn:       req.AforeRealReturn,
+					SalaryGrowth:     req.AforeSalaryGrowth,
+					VoluntaryMonthly: money.FromFloat(req.AforeVoluntaryMonthly),
+					InitialBalance:   money.FromFloat(req.AforeInitialBalance),
+				},
+				ExchangeRate: money.One, // MXN
+				Explain:      explain,
+			})
+		}
+	}
+	result, err := calculate(monthlySalary, req.YearsOfService)
+	if errors.Is(err, payroll.ErrPTUCompanyTotals) {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "ptu_total_days_worked and ptu_total_salaries must include the employee's days and salary",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+	
+	// Equity vesting converted at the Banxico exchange rate, net of its ISR
+	var equitySchedule []equity.YearlyEquity
+	var equityOutlook *equity.ValueOutlook
+	var equityMXN []money.Money
+	if req.InitialEquityUSD > 0 {
+		equityConfig.ExchangeRate = fiscalYear.USDMXNRate.Float64()
+		equitySchedule = equity.CalculateEquitySchedule(equityConfig, max(equityConfig.Years(), req.ProjectionYears))
+		equitySchedule = payroll.TaxEquitySchedule(tables, packageRegime(req.Regime), result, equityConfig, equitySchedule)
+		for _, year := range equitySchedule[1:] {
+			equityMXN = append(equityMXN, money.FromFloat(year.NetVestedMXN))
+		}
+		if priceGrowth != (equity.PriceGrowth{}) {
+			outlook, err := equity.ProjectValue(equityConfig, priceGrowth)
+			if err != nil {
+				app.serverError(w, r, err)
+				return
+			}
+			equityOutlook = &outlook
+		}
+	}
+	if req.ProjectionYears > 0 {
+		projection, err := payroll.ProjectCompensation(payroll.ProjectionInput{
+			Years:       req.ProjectionYears,
+			AnnualRaise: req.AnnualRaise,
+			Inflation:   req.Inflation,
+			Promotions:  promotions,
+			EquityMXN:   equityMXN,
+		}, monthlySalary, req.YearsOfService, calculate)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+		result.Projection = &projection
+	}
+	
+	// Return JSON response
+	jsonResponse := map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"regime":              req.Regime,
+			"zone":                zone,
+			"pay_period":          payPeriod,
+			"gross_salary":        result.GrossSalary,
+			"net_salary":          result.NetSalary,
+			"isr_tax":             result.ISRTax,
+			"subsidio_empleo":     result.SubsidioEmpleo,
+			"border_isr_credit":   result.BorderISRCredit,
+			"imss_worker":         result.IMSSWorker,
+			"infonavit_discount":  result.InfonavitDiscount,
+			"infonavit_insurance": result.InfonavitInsurance,
+			"sbc":                 result.SBC,
+			"integration_factor":  result.IntegrationFactor,
+			"vacation_days":       result.VacationDays,
+			"yearly_gross_base":   result.YearlyGrossBase,
+			"yearly_gross":        result.YearlyGross,
+			"yearly_net":          result.YearlyNet,
+			"monthly_adjusted":    result.MonthlyAdjusted,
+			"payslip":             payslipJSON(result.Payslip),
+			"invoice":             invoiceJSON(result.Invoice),
+			"employer_cost":       employerCostJSON(result.EmployerCost),
+			"afore":               aforeJSON(result.Afore),
+			"projection":          projectionJSON(result.Projection),
+			"trace":               traceJSON(result.Trace),
+			"equity":              equityJSON(equityConfig, equitySchedule, equityOutlook),
+			"breakdown": map[string]interface{}{
+				"aguinaldo_gross": result.AguinaldoGross,
+				"aguinaldo_isr":   result.AguinaldoISR,
+				"aguinaldo_net":   result.AguinaldoNet,
+				"prima_vacacional_gross": result.PrimaVacacionalGross,
+				"prima_vacacional_isr":   result.PrimaVacacionalISR,
+				"prima_vacacional_net":   result.PrimaVacacionalNet,
+				"ptu_gross":              result.PTUGross,
+				"ptu_isr":                result.PTUISR,
+				"ptu_net":                result.PTUNet,
+				"ptu_capped":             result.PTUCapped,
+				"overtime_double":        result.OvertimeDouble,
+				"overtime_triple":        result.OvertimeTriple,
+				"overtime_exempt":        result.OvertimeExempt,
+				"overtime_isr":           result.OvertimeISR,
+				"overtime_net":           result.OvertimeNet,
+				"prima_dominical_gross":  result.PrimaDominicalGross,
+				"prima_dominical_exempt": result.PrimaDominicalExempt,
+				"prima_dominical_isr":    result.PrimaDominicalISR,
+				"prima_dominical_net":    result.PrimaDominicalNet,
+				"client_type":             result.ClientType,
+				"isr_retained":            result.ISRRetained,
+				"isr_provisional_payment": result.ISRProvisionalPayment,
+				"cash_received":           result.CashReceived,
+				"exceeds_resico_cap":      result.ExceedsRESICOCap,
+				"deductible_expenses":     result.DeductibleExpenses,
+				"taxable_profit":          result.TaxableProfit,
+				"missing_imss_employer_monthly":      result.MissingIMSSEmployerMonthly,
+				"missing_infonavit_employer_monthly": result.MissingInfonavitEmployerMonthly,
+				"missing_social_security_annual":     result.MissingSocialSecurityAnnual,
+				"fondo_ahorro_yearly":    result.FondoAhorroYearly,
+				"infonavit_employer_annual": result.InfonavitEmployerAnnual,
+				"imss_employer_annual":      result.IMSSEmployerAnnual,
+				"risk_class":                result.RiskClass,
+				"risk_premium":              result.RiskPremium,
+			},
+		},
+		"meta": map[string]interface{}{
+			"fiscal_year":          fiscalYear.Year,
+			"uma_monthly":          fiscalYear.UMAMonthly,
+			"minimum_wage_monthly": payroll.MinimumMonthlyWage(tables, zone),
+		},
+	}
+	
+	err = response.JSON(w, http.StatusOK, jsonResponse)
+	if err != nil {
+		app.serverError(w, r, err)
+	}
+}
+
+// apiGrossFromNet solves for the gross salary that produces a desired net (JSON API)
+func (app *application) apiGrossFromNet(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TargetNet              float64 `json:"target_net"`
+		Target                 string  `json:"target"`           // "net_salary", "yearly_net" or "monthly_adjusted"
+		PayPeriod              string  `json:"pay_period"`       // Payslip periodicity; target_net stays monthly
+		Regime                 string  `json:"regime"`           // "sueldos" or "resico"
+		YearsOfService         int     `json:"years_of_service"` // Drives default vacation days and the SBC
+		Zone                   string  `json:"zone"`             // "GENERAL", "FRONTERA_NORTE" or "FRONTERA_SUR"
+		Municipality           string  `json:"municipality"`     // Optional; a border municipality overrides zone
+		HasAguinaldo           bool    `json:"has_aguinaldo"`
+		AguinaldoDays          int     `json:"aguinaldo_days"`
+		HasValesDespensa       bool    `json:"has_vales_despensa"`
+		ValesDespensaAmount    float64 `json:"vales_despensa_amount"`
+		HasPrimaVacacional     bool    `json:"has_prima_vacacional"`
+		VacationDays           int     `json:"vacation_days"`
+		PrimaVacacionalPercent float64 `json:"prima_vacacional_percent"`
+		HasFondoAhorro         bool    `json:"has_fondo_ahorro"`
+		FondoAhorroPercent     float64 `json:"fondo_ahorro_percent"`
+		HasInfonavitCredit     bool    `json:"has_infonavit_credit"`
+		InfonavitCreditType    string  `json:"infonavit_credit_type"`  // "PERCENTAGE", "FIXED_PESOS" or "VSM"
+		InfonavitCreditValue   float64 `json:"infonavit_credit_value"` // Percent of SBC, monthly pesos or UMI factor
+		HasPTU                 bool    `json:"has_ptu"`
+		PTUMode                string  `json:"ptu_mode"`               // "EMPLOYEE_SHARE", "DAYS" or "COMPANY_POOL"
+		PTUValue               float64 `json:"ptu_value"`              // The employee's own PTU pesos, days of salary or the company's distributable PTU
+		PTUThreeYearAverage    float64 `json:"ptu_three_year_average"` // Optional; raises the three-month cap
+		PTUDaysWorked          float64 `json:"ptu_days_worked"`        // COMPANY_POOL: the employee's days worked, 365 by default
+		PTUTotalDaysWorked     float64 `json:"ptu_total_days_worked"`  // COMPANY_POOL: days worked by all the workers
+		PTUTotalSalaries       float64 `json:"ptu_total_salaries"`     // COMPANY_POOL: salaries earned by all the workers in the year
+		HasOvertime            bool    `json:"has_overtime"`
+		OvertimeHoursPerWeek   float64 `json:"overtime_hours_per_week"` // Double up to 9, triple after
+		SundaysPerMonth        float64 `json:"sundays_per_month"`       // Paid with 25% prima dominical
+		UnpaidVacationDays     int     `json:"unpaid_vacation_days"`   // RESICO only
+		ClientType             string  `json:"client_type"`            // RESICO only: "PERSONA_FISICA" (default) or "PERSONA_MORAL"
+		ChargesIVA             bool    `json:"charges_iva"`            // RESICO only: invoices add 16% IVA
+		IVAExpenses            float64 `json:"iva_expenses"`           // RESICO only: monthly expenses with acreditable IVA
+	}
+
+	err := request.DecodeJSON(w, r, &req)
+	if err != nil {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid JSON request body",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	if req.Regime == "" {
+		req.Regime = "sueldos"
+	}
+	if req.Target == "" {
+		req.Target = string(payroll.NetFieldNetSalary)
+	}
+
+	// Validate input
+	if req.TargetNet <= 0 {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "target_net must be greater than 0",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if !money.ValidAmounts(req.TargetNet, req.ValesDespensaAmount, req.InfonavitCreditValue, req.PTUValue, req.PTUThreeYearAverage, req.PTUTotalSalaries, req.IVAExpenses) {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("amounts cannot exceed %s", money.MaxAmount),
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.Regime != "sueldos" && req.Regime != "resico" {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "regime must be one of sueldos, resico",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if !payroll.NetField(req.Target).Valid() {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "target must be one of net_salary, yearly_net, monthly_adjusted",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.HasInfonavitCredit && req.InfonavitCreditValue > 0 && !payroll.InfonavitCreditType(req.InfonavitCreditType).Valid() {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "infonavit_credit_type must be one of PERCENTAGE, FIXED_PESOS, VSM",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.HasPTU && !payroll.PTUMode(req.PTUMode).Valid() {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "ptu_mode must be one of EMPLOYEE_SHARE, DAYS, COMPANY_POOL",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.PTUValue < 0 || req.PTUThreeYearAverage < 0 {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "ptu_value and ptu_three_year_average cannot be negative",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if !(req.PTUDaysWorked >= 0 && req.PTUDaysWorked <= 366) {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "ptu_days_worked must be between 0 and 366",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.OvertimeHoursPerWeek < 0 || req.SundaysPerMonth < 0 {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "overtime_hours_per_week and sundays_per_month cannot be negative",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.Zone != "" && !payroll.Zone(req.Zone).Valid() {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "zone must be one of GENERAL, FRONTERA_NORTE, FRONTERA_SUR",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.ClientType != "" && !payroll.ClientType(req.ClientType).Valid() {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "client_type must be one of PERSONA_FISICA, PERSONA_MORAL",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.IVAExpenses < 0 {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "iva_expenses cannot be negative",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.PayPeriod == "" {
+		req.PayPeriod = string(payroll.PeriodMonthly)
+	}
+	if !payroll.Periodicity(req.PayPeriod).IsPayPeriod() {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "pay_period must be one of MONTHLY, SEMIMONTHLY, BIWEEKLY, WEEKLY, DAILY",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	// Get fiscal year configuration
+	fiscalYear, found, err := app.db.GetActiveFiscalYear()
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+	if !found {
+		err := response.JSON(w, http.StatusInternalServerError, map[string]string{
+			"error": "No active fiscal year configuration found",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	tables, err := app.loadRateTables(fiscalYear)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	zone := resolveZone(req.Zone, req.Municipality)
+
+	// Solve based on regime
+	var solution payroll.GrossSolution
+
+	if req.Regime == "resico" {
+		solution, err = payroll.SolveRESICOGross(tables, payroll.RESICOInput{
+			UnpaidVacationDays: req.UnpaidVacationDays,
+			ClientType:         payroll.ClientType(req.ClientType),
+			ChargesIVA:         req.ChargesIVA,
+			IVAExpenses:        money.FromFloat(req.IVAExpenses),
+			ExchangeRate:       money.One,
+		}, payroll.NetField(req.Target), money.FromFloat(req.TargetNet))
+	} else {
+		solution, err = payroll.SolveSalaryGross(tables, payroll.SalaryInput{
+			YearsOfService:         req.YearsOfService,
+			Zone:                   zone,
+			PayPeriod:              payroll.Periodicity(req.PayPeriod),
+			HasAguinaldo:           req.HasAguinaldo,
+			AguinaldoDays:          req.AguinaldoDays,
+			HasValesDespensa:       req.HasValesDespensa,
+			ValesDespensaAmount:    money.FromFloat(req.ValesDespensaAmount),
+			HasPrimaVacacional:     req.HasPrimaVacacional,
+			VacationDays:           req.VacationDays,
+			PrimaVacacionalPercent: req.PrimaVacacionalPercent,
+			HasFondoAhorro:         req.HasFondoAhorro,
+			FondoAhorroPercent:     req.FondoAhorroPercent,
+			HasInfonavitCredit:     req.HasInfonavitCredit,
+			InfonavitCredit: payroll.InfonavitCredit{
+				Type:  payroll.InfonavitCreditType(req.InfonavitCreditType),
+				Value: req.InfonavitCreditValue,
+			},
+			HasPTU: req.HasPTU,
+			PTU: payroll.PTU{
+				Mode:             payroll.PTUMode(req.PTUMode),
+				Value:            req.PTUValue,
+				ThreeYearAverage: money.FromFloat(req.PTUThreeYearAverage),
+				DaysWorked:       req.PTUDaysWorked,
+				TotalDaysWorked:  req.PTUTotalDaysWorked,
+				TotalSalaries:    money.FromFloat(req.PTUTotalSalaries),
+			},
+			HasOvertime: req.HasOvertime,
+			Overtime: payroll.Overtime{
+				HoursPerWeek:    req.OvertimeHoursPerWeek,
+				SundaysPerMonth: req.SundaysPerMonth,
+			},
+			ExchangeRate: money.One, // MXN
+		}, payroll.NetField(req.Target), money.FromFloat(req.TargetNet))
+	}
+	if errors.Is(err, payroll.ErrPTUCompanyTotals) {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "ptu_total_days_worked and ptu_total_salaries must include the employee's days and salary",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if errors.Is(err, payroll.ErrTargetUnreachable) {
+		err := response.JSON(w, http.StatusUnprocessableEntity, map[string]string{
+			"error": "target_net is not reachable",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+	if req.Regime != "resico" && solution.GrossMonthlySalary < payroll.MinimumMonthlyWage(tables, zone) {
+		err := response.JSON(w, http.StatusUnprocessableEntity, map[string]string{
+			"error": "target_net requires a salary below the monthly minimum wage of the zone",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	result := solution.Calculation
+
+	// Return JSON response
+	jsonResponse := map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"regime":              req.Regime,
+			"target":              req.Target,
+			"target_net":          req.TargetNet,
+			"zone":                zone,
+			"pay_period":          req.PayPeriod,
+			"gross_salary":        solution.GrossMonthlySalary,
+			"net_salary":          result.NetSalary,
+			"isr_tax":             result.ISRTax,
+			"subsidio_empleo":     result.SubsidioEmpleo,
+			"imss_worker":         result.IMSSWorker,
+			"infonavit_discount":  result.InfonavitDiscount,
+			"ptu_net":             result.PTUNet,
+			"overtime_net":        result.OvertimeNet,
+			"prima_dominical_net": result.PrimaDominicalNet,
+			"isr_retained":        result.ISRRetained,
+			"cash_received":       result.CashReceived,
+			"exceeds_resico_cap":  result.ExceedsRESICOCap,
+			"yearly_gross":        result.YearlyGross,
+			"yearly_net":          result.YearlyNet,
+			"monthly_adjusted":    result.MonthlyAdjusted,
+			"cliff_at":            solution.CliffAt,
+			"payslip":             payslipJSON(result.Payslip),
+			"invoice":             invoiceJSON(result.Invoice),
+		},
+		"meta": map[string]interface{}{
+			"fiscal_year":          fiscalYear.Year,
+			"uma_monthly":          fiscalYear.UMAMonthly,
+			"minimum_wage_monthly": payroll.MinimumMonthlyWage(tables, zone),
+		},
+	}
+
+	err = response.JSON(w, http.StatusOK, jsonResponse)
+	if err != nil {
+		app.serverError(w, r, err)
+	}
+}
+
+// apiCurves sweeps the gross salary of a package and returns net, effective and
+// marginal tax rates at every point (JSON API)
+func (app *application) apiCurves(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		MinGross               float64 `form:"min_gross"`        // Gross monthly salary of the first point
+		MaxGross               float64 `form:"max_gross"`        // Gross monthly salary of the last point
+		Step                   float64 `form:"step"`             // Optional; spreads about 50 points when 0
+		Regime                 string  `form:"regime"`           // "sueldos" (default) or "resico"
+		YearsOfService         int     `form:"years_of_service"` // Drives default vacation days and the SBC
+		Zone                   string  `form:"zone"`             // "GENERAL", "FRONTERA_NORTE" or "FRONTERA_SUR"
+		Municipality           string  `form:"municipality"`     // Optional; a border municipality overrides zone
+		HasAguinaldo           bool    `form:"has_aguinaldo"`
+		AguinaldoDays          int     `form:"aguinaldo_days"`
+		HasValesDespensa       bool    `form:"has_vales_despensa"`
+		ValesDespensaAmount    float64 `form:"vales_despensa_amount"`
+		HasPrimaVacacional     bool    `form:"has_prima_vacacional"`
+		VacationDays           int     `form:"vacation_days"`
+		PrimaVacacionalPercent float64 `form:"prima_vacacional_percent"`
+		HasFondoAhorro         bool    `form:"has_fondo_ahorro"`
+		FondoAhorroPercent     float64 `form:"fondo_ahorro_percent"`
+		UnpaidVacationDays     int     `form:"unpaid_vacation_days"` // RESICO only
+		ClientType             string  `form:"client_type"`          // RESICO only: "PERSONA_FISICA" (default) or "PERSONA_MORAL"
+	}
 
-	// Get user ID from token (validates expiry - 24 hours)
-	userID, found, err := app.db.GetUserIDFromVerificationToken(hashedToken)
+	err := request.DecodeQueryString(r, &req)
 	if err != nil {
-		app.serverError(w, r, err)
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid query parameters",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
 		return
 	}
 
-	if !found {
-		data := app.newTemplateData(r)
-		data["Message"] = "El enlace de verificación es inválido o ha expirado."
-		err := response.Page(w, http.StatusBadRequest, data, "pages/email-verification-error.tmpl")
+	if req.Regime == "" {
+		req.Regime = "sueldos"
+	}
+
+	// Validate input
+	if req.MinGross <= 0 || req.MaxGross < req.MinGross || req.Step < 0 {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "min_gross must be greater than 0, max_gross cannot be below min_gross and step cannot be negative",
+		})
 		if err != nil {
 			app.serverError(w, r, err)
 		}
 		return
 	}
-
-	// Mark email as verified
-	err = app.db.VerifyUserEmail(userID)
-	if err != nil {
-		app.serverError(w, r, err)
+	if !money.ValidAmounts(req.MinGross, req.MaxGross, req.Step, req.ValesDespensaAmount) {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("amounts cannot exceed %s", money.MaxAmount),
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.Regime != "sueldos" && req.Regime != "resico" {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "regime must be one of sueldos, resico",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.Zone != "" && !payroll.Zone(req.Zone).Valid() {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "zone must be one of GENERAL, FRONTERA_NORTE, FRONTERA_SUR",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.ClientType != "" && !payroll.ClientType(req.ClientType).Valid() {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "client_type must be one of PERSONA_FISICA, PERSONA_MORAL",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
 		return
 	}
 
-	// Show success page
-	data := app.newTemplateData(r)
-	err = response.Page(w, http.StatusOK, data, "pages/email-verification-success.tmpl")
-	if err != nil {
-		app.serverError(w, r, err)
+	curveInput := payroll.CurveRange(money.FromFloat(req.MinGross), money.FromFloat(req.MaxGross), 50)
+	if req.Step > 0 {
+		curveInput.Step = money.FromFloat(req.Step)
+	}
+	if curveInput.Points() > payroll.MaxCurvePoints {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("step is too small; the curve cannot have more than %d points", payroll.MaxCurvePoints),
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
 	}
-}
 
-// resendVerificationEmail generates a new verification token and sends it to the user's email
-func (app *application) resendVerificationEmail(w http.ResponseWriter, r *http.Request) {
-	// Get authenticated user ID from session
-	userID := app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
-	
-	// Get user email from database
-	user, found, err := app.db.GetUser(userID)
+	// Get fiscal year configuration
+	fiscalYear, found, err := app.db.GetActiveFiscalYear()
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
-	
 	if !found {
-		app.sessionManager.Put(r.Context(), "flash", "Usuario no encontrado")
-		http.Redirect(w, r, "/account/developer", http.StatusSeeOther)
+		err := response.JSON(w, http.StatusInternalServerError, map[string]string{
+			"error": "No active fiscal year configuration found",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
 		return
 	}
-	
-	// Check if already verified
-	if user.EmailVerified {
-		app.sessionManager.Put(r.Context(), "flash", "Tu email ya está verificado")
-		http.Redirect(w, r, "/account/developer", http.StatusSeeOther)
+
+	tables, err := app.loadRateTables(fiscalYear)
+	if err != nil {
+		app.serverError(w, r, err)
 		return
 	}
-	
-	// Generate new verification token
-	plaintextToken := token.New()
-	hashedToken := token.Hash(plaintextToken)
-	
-	// Delete any existing verification tokens for this user
-	err = app.db.DeleteEmailVerificationTokensForUser(userID)
+
+	zone := resolveZone(req.Zone, req.Municipality)
+
+	// Sweep based on regime, recorded in the metrics as a single calculation
+	start := time.Now()
+	curve, err := payroll.TaxCurve(curveInput, req.YearsOfService, func(grossMonthlySalary money.Money, yearsOfService int) (database.SalaryCalculation, error) {
+		if req.Regime == "resico" {
+			return payroll.CalculateRESICO(tables, payroll.RESICOInput{
+				MonthlyIncome:      grossMonthlySalary,
+				UnpaidVacationDays: req.UnpaidVacationDays,
+				ClientType:         payroll.ClientType(req.ClientType),
+				ExchangeRate:       money.One,
+			})
+		}
+		return payroll.CalculateSalaryWithBenefits(tables, payroll.SalaryInput{
+			GrossMonthlySalary:     grossMonthlySalary,
+			YearsOfService:         yearsOfService,
+			Zone:                   zone,
+			HasAguinaldo:           req.HasAguinaldo,
+			AguinaldoDays:          req.AguinaldoDays,
+			HasValesDespensa:       req.HasValesDespensa,
+			ValesDespensaAmount:    money.FromFloat(req.ValesDespensaAmount),
+			HasPrimaVacacional:     req.HasPrimaVacacional,
+			VacationDays:           req.VacationDays,
+			PrimaVacacionalPercent: req.PrimaVacacionalPercent,
+			HasFondoAhorro:         req.HasFondoAhorro,
+			FondoAhorroPercent:     req.FondoAhorroPercent,
+			ExchangeRate:           money.One, // MXN
+		})
+	})
+	recordCalculation(start)
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
-	
-	// Store new verification token in database
-	err = app.db.InsertEmailVerificationToken(userID, hashedToken)
+
+	// Return JSON response
+	jsonResponse := map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"regime": req.Regime,
+			"zone":   zone,
+			"curve":  curveJSON(&curve),
+		},
+		"meta": map[string]interface{}{
+			"fiscal_year":               fiscalYear.Year,
+			"uma_monthly":               fiscalYear.UMAMonthly,
+			"minimum_wage_monthly":      payroll.MinimumMonthlyWage(tables, zone),
+			"subsidy_threshold_monthly": fiscalYear.SubsidyThresholdMonthly,
+		},
+	}
+
+	err = response.JSON(w, http.StatusOK, jsonResponse)
 	if err != nil {
 		app.serverError(w, r, err)
-		return
 	}
-	
-	// Send verification email in background
-	app.backgroundTask(r, func() error {
-		data := app.newEmailData()
-		data["Email"] = user.Email
-		data["VerificationToken"] = plaintextToken
-		return app.mailer.Send(user.Email, data, "welcome.tmpl")
-	})
-	
-	app.sessionManager.Put(r.Context(), "flash", "Email de verificación reenviado. Revisa tu bandeja de entrada.")
-	http.Redirect(w, r, "/account/developer", http.StatusSeeOther)
 }
 
-// apiCalculate is the main API endpoint for salary calculations (JSON API)
-func (app *application) apiCalculate(w http.ResponseWriter, r *http.Request) {
-	// Parse JSON request body
+// apiSeverance calculates the finiquito and liquidación for an employment that ended
+func (app *application) apiSeverance(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Salary                  float64 `json:"salary"`
-		Regime                  string  `json:"regime"` // "sueldos" or "resico"
-		HasAguinaldo            bool    `json:"has_aguinaldo"`
-		AguinaldoDays           int     `json:"aguinaldo_days"`
-		HasValesDespensa        bool    `json:"has_vales_despensa"`
-		ValesDespensaAmount     float64 `json:"vales_despensa_amount"`
-		HasPrimaVacacional      bool    `json:"has_prima_vacacional"`
-		VacationDays            int     `json:"vacation_days"`
-		PrimaVacacionalPercent  float64 `json:"prima_vacacional_percent"`
-		HasFondoAhorro          bool    `json:"has_fondo_ahorro"`
-		FondoAhorroPercent      float64 `json:"fondo_ahorro_percent"`
-		UnpaidVacationDays      int     `json:"unpaid_vacation_days"` // RESICO only
+		GrossMonthlySalary     float64 `json:"gross_monthly_salary"`
+		HireDate               string  `json:"hire_date"`      // YYYY-MM-DD
+		ExitDate               string  `json:"exit_date"`      // YYYY-MM-DD
+		Type                   string  `json:"type"`           // "RENUNCIA" or "DESPIDO_INJUSTIFICADO"
+		Zone                   string  `json:"zone"`           // "GENERAL", "FRONTERA_NORTE" or "FRONTERA_SUR"
+		Municipality           string  `json:"municipality"`   // Optional; a border municipality overrides zone
+		AguinaldoDays          int     `json:"aguinaldo_days"` // Statutory when 0
+		PrimaVacacionalPercent float64 `json:"prima_vacacional_percent"`
+		VacationDaysTaken      float64 `json:"vacation_days_taken"`
+		PendingVacationDays    float64 `json:"pending_vacation_days"`
+		UnpaidSalaryDays       int     `json:"unpaid_salary_days"`
 	}
-	
+
 	err := request.DecodeJSON(w, r, &req)
 	if err != nil {
 		err := response.JSON(w, http.StatusBadRequest, map[string]string{
@@ -1264,18 +3376,74 @@ func (app *application) apiCalculate(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	
+
 	// Validate input
-	if req.Salary <= 0 {
+	if req.GrossMonthlySalary <= 0 {
 		err := response.JSON(w, http.StatusBadRequest, map[string]string{
-			"error": "Salary must be greater than 0",
+			"error": "gross_monthly_salary must be greater than 0",
 		})
 		if err != nil {
 			app.serverError(w, r, err)
 		}
 		return
 	}
-	
+	if !money.ValidAmounts(req.GrossMonthlySalary) {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("amounts cannot exceed %s", money.MaxAmount),
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	hireDate, hireErr := time.Parse("2006-01-02", req.HireDate)
+	exitDate, exitErr := time.Parse("2006-01-02", req.ExitDate)
+	if hireErr != nil || exitErr != nil {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "hire_date and exit_date must be dates in YYYY-MM-DD format",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if exitDate.Before(hireDate) {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "exit_date must not be before hire_date",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if !payroll.SeparationType(req.Type).Valid() {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "type must be one of RENUNCIA, DESPIDO_INJUSTIFICADO",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.Zone != "" && !payroll.Zone(req.Zone).Valid() {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "zone must be one of GENERAL, FRONTERA_NORTE, FRONTERA_SUR",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+	if req.AguinaldoDays < 0 || req.PrimaVacacionalPercent < 0 || req.VacationDaysTaken < 0 || req.PendingVacationDays < 0 || req.UnpaidSalaryDays < 0 {
+		err := response.JSON(w, http.StatusBadRequest, map[string]string{
+			"error": "days and percentages cannot be negative",
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
 	// Get fiscal year configuration
 	fiscalYear, found, err := app.db.GetActiveFiscalYear()
 	if err != nil {
@@ -1291,69 +3459,71 @@ func (app *application) apiCalculate(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	
-	// Calculate based on regime
-	var result database.SalaryCalculation
-	
-	if req.Regime == "resico" {
-		// RESICO calculation
-		result, err = app.calculateRESICO(req.Salary, req.UnpaidVacationDays, []OtherBenefit{}, 1.0, fiscalYear)
-		if err != nil {
-			app.serverError(w, r, err)
-			return
-		}
-	} else {
-		// Sueldos y Salarios calculation (default)
-		result, err = app.calculateSalaryWithBenefits(
-			req.Salary,
-			req.HasAguinaldo, req.AguinaldoDays,
-			req.HasValesDespensa, req.ValesDespensaAmount,
-			req.HasPrimaVacacional, req.VacationDays, req.PrimaVacacionalPercent,
-			req.HasFondoAhorro, req.FondoAhorroPercent,
-			false, // hasInfonavitCredit - API users can add this later
-			[]OtherBenefit{},
-			1.0, // Exchange rate (MXN)
-			fiscalYear,
-		)
-		if err != nil {
-			app.serverError(w, r, err)
-			return
-		}
+
+	tables, err := app.loadRateTables(fiscalYear)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
 	}
-	
+
+	zone := resolveZone(req.Zone, req.Municipality)
+
+	result, err := payroll.CalculateSeverance(tables, payroll.SeveranceInput{
+		GrossMonthlySalary:     money.FromFloat(req.GrossMonthlySalary),
+		Zone:                   zone,
+		HireDate:               hireDate,
+		ExitDate:               exitDate,
+		Type:                   payroll.SeparationType(req.Type),
+		AguinaldoDays:          req.AguinaldoDays,
+		PrimaVacacionalPercent: req.PrimaVacacionalPercent,
+		VacationDaysTaken:      req.VacationDaysTaken,
+		PendingVacationDays:    req.PendingVacationDays,
+		UnpaidSalaryDays:       req.UnpaidSalaryDays,
+	})
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
 	// Return JSON response
 	jsonResponse := map[string]interface{}{
 		"success": true,
 		"data": map[string]interface{}{
-			"regime":            req.Regime,
-			"gross_salary":      result.GrossSalary,
-			"net_salary":        result.NetSalary,
-			"isr_tax":           result.ISRTax,
-			"subsidio_empleo":   result.SubsidioEmpleo,
-			"imss_worker":       result.IMSSWorker,
-			"sbc":               result.SBC,
-			"yearly_gross_base": result.YearlyGrossBase,
-			"yearly_gross":      result.YearlyGross,
-			"yearly_net":        result.YearlyNet,
-			"monthly_adjusted":  result.MonthlyAdjusted,
-			"breakdown": map[string]interface{}{
-				"aguinaldo_gross": result.AguinaldoGross,
-				"aguinaldo_isr":   result.AguinaldoISR,
-				"aguinaldo_net":   result.AguinaldoNet,
-				"prima_vacacional_gross": result.PrimaVacacionalGross,
-				"prima_vacacional_isr":   result.PrimaVacacionalISR,
-				"prima_vacacional_net":   result.PrimaVacacionalNet,
-				"fondo_ahorro_yearly":    result.FondoAhorroYearly,
-				"infonavit_employer_annual": result.InfonavitEmployerAnnual,
-				"imss_employer_annual":      result.IMSSEmployerAnnual,
+			"type":                    req.Type,
+			"zone":                    zone,
+			"years_of_service":        result.YearsOfService,
+			"daily_salary":            result.DailySalary,
+			"integrated_daily_salary": result.IntegratedDailySalary,
+			"finiquito": map[string]interface{}{
+				"unpaid_salary":     result.UnpaidSalary,
+				"aguinaldo_days":    result.AguinaldoDays,
+				"aguinaldo":         result.Aguinaldo,
+				"vacation_days":     result.VacationDays,
+				"vacation":          result.Vacation,
+				"prima_vacacional":  result.PrimaVacacional,
+				"seniority_premium": result.SeniorityPremium,
+				"gross":             result.FiniquitoGross,
+				"exempt":            result.FiniquitoExempt,
+				"isr":               result.FiniquitoISR,
+			},
+			"liquidacion": map[string]interface{}{
+				"three_months_indemnity": result.ThreeMonthsIndemnity,
+				"twenty_days_indemnity":  result.TwentyDaysIndemnity,
+				"gross":                  result.LiquidacionGross,
 			},
+			"separation_exempt": result.SeparationExempt,
+			"separation_isr":    result.SeparationISR,
+			"total_gross":       result.TotalGross,
+			"total_isr":         result.TotalISR,
+			"total_net":         result.TotalNet,
 		},
 		"meta": map[string]interface{}{
-			"fiscal_year": fiscalYear.Year,
-			"uma_monthly": fiscalYear.UMAMonthly,
+			"fiscal_year":        fiscalYear.Year,
+			"uma_daily":          fiscalYear.UMADaily,
+			"minimum_wage_daily": payroll.MinimumDailyWage(tables, zone),
 		},
 	}
-	
+
 	err = response.JSON(w, http.StatusOK, jsonResponse)
 	if err != nil {
 		app.serverError(w, r, err)
@@ -1424,6 +3594,8 @@ func (app *application) exportPDF(w http.ResponseWriter, r *http.Request) {
 			pdfInput = pdf.PackageInput{
 				Name:                    packageInputs[i].Name,
 				Regime:                  packageInputs[i].Regime,
+				Zone:                    packageInputs[i].Zone,
+				Municipality:            packageInputs[i].Municipality,
 				Currency:                packageInputs[i].Currency,
 				ExchangeRate:            packageInputs[i].ExchangeRate,
 				PaymentFrequency:        packageInputs[i].PaymentFrequency,
@@ -1438,20 +3610,48 @@ func (app *application) exportPDF(w http.ResponseWriter, r *http.Request) {
 				PrimaVacacionalPercent:  packageInputs[i].PrimaVacacionalPercent,
 				HasFondoAhorro:          packageInputs[i].HasFondoAhorro,
 				FondoAhorroPercent:      packageInputs[i].FondoAhorroPercent,
+				HasInfonavitCredit:      packageInputs[i].HasInfonavitCredit,
+				InfonavitCreditType:     packageInputs[i].InfonavitCreditType,
+				InfonavitCreditValue:    packageInputs[i].InfonavitCreditValue,
+				HasPTU:                  packageInputs[i].HasPTU,
+				PTUMode:                 packageInputs[i].PTUMode,
+				PTUValue:                packageInputs[i].PTUValue,
+				PTUThreeYearAverage:     packageInputs[i].PTUThreeYearAverage,
+				HasOvertime:             packageInputs[i].HasOvertime,
+				OvertimeHoursPerWeek:    packageInputs[i].OvertimeHoursPerWeek,
+				SundaysPerMonth:         packageInputs[i].SundaysPerMonth,
+				State:                   packageInputs[i].State,
 				UnpaidVacationDays:      packageInputs[i].UnpaidVacationDays,
+				ClientType:              packageInputs[i].ClientType,
+				ChargesIVA:              packageInputs[i].ChargesIVA,
+				IVAExpenses:             packageInputs[i].IVAExpenses,
+				Expenses:                packageInputs[i].Expenses,
 				OtherBenefits:           pdfOtherBenefits,
 				HasEquity:               packageInputs[i].HasEquity,
 				InitialEquityUSD:        packageInputs[i].InitialEquityUSD,
 				HasRefreshers:           packageInputs[i].HasRefreshers,
 				RefresherMinUSD:         packageInputs[i].RefresherMinUSD,
 				RefresherMaxUSD:         packageInputs[i].RefresherMaxUSD,
+				VestingYears:            packageInputs[i].VestingYears,
+				VestingPercents:         packageInputs[i].VestingPercents,
+				CliffMonths:             packageInputs[i].CliffMonths,
+				VestFrequency:           packageInputs[i].VestFrequency,
+				EquityWithholding:       packageInputs[i].EquityWithholding,
+				SellToCoverPercent:      packageInputs[i].SellToCoverPercent,
+				PriceGrowthBear:         packageInputs[i].PriceGrowthBear,
+				PriceGrowthBase:         packageInputs[i].PriceGrowthBase,
+				PriceGrowthBull:         packageInputs[i].PriceGrowthBull,
+				PriceVolatility:         packageInputs[i].PriceVolatility,
 			}
 		}
 		
 		pdfPackages[i] = pdf.PackageResult{
-			Name:        result.PackageName,
-			Input:       pdfInput,
-			Calculation: result.SalaryCalculation,
+			Name:           result.PackageName,
+			Input:          pdfInput,
+			Calculation:    result.SalaryCalculation,
+			EquityConfig:   result.EquityConfig,
+			EquitySchedule: result.EquitySchedule,
+			EquityOutlook:  result.EquityOutlook,
 		}
 	}
 	
@@ -1475,6 +3675,47 @@ func (app *application) exportPDF(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// exportSeverancePDF downloads the last severance calculation of the session as a PDF
+func (app *application) exportSeverancePDF(w http.ResponseWriter, r *http.Request) {
+	input, ok := app.sessionManager.Get(r.Context(), "severanceInput").(payroll.SeveranceInput)
+	if !ok {
+		app.badRequest(w, r, fmt.Errorf("no severance calculation in session"))
+		return
+	}
+
+	result, ok := app.sessionManager.Get(r.Context(), "severanceResult").(payroll.Severance)
+	if !ok {
+		app.serverError(w, r, fmt.Errorf("invalid severance result in session"))
+		return
+	}
+
+	fiscalYear, found, err := app.db.GetActiveFiscalYear()
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+	if !found {
+		app.serverError(w, r, fmt.Errorf("no active fiscal year"))
+		return
+	}
+
+	pdfBytes, err := pdf.GenerateSeveranceReport(input, result, fiscalYear)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	filename := fmt.Sprintf("TotalComp_Finiquito_%s.pdf", input.ExitDate.Format("2006-01-02"))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	w.Header().Set("Content-Length", strconv.Itoa(len(pdfBytes)))
+
+	_, err = w.Write(pdfBytes)
+	if err != nil {
+		app.logger.Error("failed to write PDF response", "error", err)
+	}
+}
+
 // sanitizeFilename removes special characters from filename
 func sanitizeFilename(name string) string {
 	if name == "" {
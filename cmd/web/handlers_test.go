@@ -2,8 +2,10 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -500,3 +502,25 @@ func TestRestrictedBasicAuth(t *testing.T) {
 		assert.True(t, containsPageTag(t, res.Body, "restricted"))
 	})
 }
+
+func TestAPIGrossFromNet(t *testing.T) {
+	t.Run("Rejects unknown regimes", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		apiKey := "test-api-key"
+		err := app.db.UpdateUserAPIKey(testUsers["alice"].id, apiKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, regime := range []string{"asimilados", "actividad_empresarial", "SUELDOS"} {
+			req := newTestRequest(t, http.MethodPost, "/api/v1/gross-from-net")
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+			req.Body = io.NopCloser(strings.NewReader(fmt.Sprintf(`{"regime": %q, "target_net": 30000}`, regime)))
+
+			res := send(t, req, app.routes())
+			assert.Equal(t, res.StatusCode, http.StatusBadRequest)
+			assert.True(t, strings.Contains(res.Body, "regime must be one of sueldos, resico"))
+		}
+	})
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/jcroyoaun/totalcompmx/internal/database"
 	"github.com/jcroyoaun/totalcompmx/internal/env"
+	"github.com/jcroyoaun/totalcompmx/internal/payroll"
 	"github.com/jcroyoaun/totalcompmx/internal/smtp"
 	"github.com/jcroyoaun/totalcompmx/internal/version"
 	"github.com/jcroyoaun/totalcompmx/internal/worker"
@@ -33,6 +34,8 @@ func init() {
 	gob.Register(database.FiscalYear{})
 	gob.Register([]database.OtherBenefitResult{})
 	gob.Register(database.OtherBenefitResult{})
+	gob.Register(payroll.SeveranceInput{})
+	gob.Register(payroll.Severance{})
 }
 
 func main() {
@@ -2,21 +2,80 @@ package main
 
 import (
 	"fmt"
-	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jcroyoaun/totalcompmx/internal/database"
+	"github.com/jcroyoaun/totalcompmx/internal/equity"
 	"github.com/jcroyoaun/totalcompmx/internal/metrics"
+	"github.com/jcroyoaun/totalcompmx/internal/payroll"
 )
 
+// homeProjectionYears is the length of the projection in the comparison page,
+// enough for the 4 and 5 year totals offers are decided on
+const homeProjectionYears = 5
+
+// homeCurvePoints is the number of salaries the comparison page samples to draw
+// the tax curve of a package, from the minimum wage to twice its salary
+const homeCurvePoints = 40
+
+// loadRateTables reads every bracket table for the fiscal year once and returns
+// an immutable snapshot for the payroll engine
+func (app *application) loadRateTables(fiscalYear database.FiscalYear) (*payroll.RateTables, error) {
+	isrBrackets, err := app.db.GetISRBrackets(fiscalYear.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	imssConcepts, err := app.db.GetIMSSConcepts()
+	if err != nil {
+		return nil, err
+	}
+
+	cesantiaBrackets, err := app.db.GetCesantiaBrackets(fiscalYear.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	resicoBrackets, err := app.db.GetRESICOBrackets(fiscalYear.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	retentionRules, err := app.db.GetRetentionRules(fiscalYear.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	seniorityBenefits, err := app.db.GetSeniorityBenefits(fiscalYear.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	stateTaxes, err := app.db.GetStatePayrollTaxes(fiscalYear.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	riskClasses, err := app.db.GetRiskClasses(fiscalYear.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return payroll.NewRateTables(fiscalYear, isrBrackets, imssConcepts, cesantiaBrackets, resicoBrackets, retentionRules, seniorityBenefits, stateTaxes, riskClasses), nil
+}
+
+// recordCalculation records a compensation calculation that started at start.
+// Sweeps that evaluate many salaries record once so they do not inflate the
+// calculation counter.
+func recordCalculation(start time.Time) {
+	metrics.TotalCompCalculations.Inc()
+	metrics.CalculationDuration.Observe(time.Since(start).Seconds())
+}
+
 // calculateRESICO performs RESICO regime calculation (flat rate, no IMSS, no subsidio)
-func (app *application) calculateRESICO(
-	monthlyIncome float64,
-	unpaidVacationDays int,
-	otherBenefits []OtherBenefit,
-	exchangeRate float64,
-	fiscalYear database.FiscalYear,
-) (database.SalaryCalculation, error) {
+func (app *application) calculateRESICO(tables *payroll.RateTables, input payroll.RESICOInput) (database.SalaryCalculation, error) {
 	start := time.Now()
 	defer func() {
 		duration := time.Since(start).Seconds()
@@ -24,509 +83,424 @@ func (app *application) calculateRESICO(
 		metrics.CalculationDuration.Observe(duration)
 	}()
 
-	result := database.SalaryCalculation{
-		GrossSalary:        monthlyIncome,
-		UnpaidVacationDays: unpaidVacationDays,
-	}
+	return payroll.CalculateRESICO(tables, input)
+}
 
-	// Get RESICO bracket
-	resicoBracket, found, err := app.db.GetRESICOBracket(fiscalYear.ID, monthlyIncome)
-	if err != nil {
-		return result, err
-	}
-	if !found {
-		return result, fmt.Errorf("no RESICO bracket found for income %.2f", monthlyIncome)
-	}
-
-	// RESICO: Apply flat rate to TOTAL income
-	result.ISRTax = monthlyIncome * resicoBracket.ApplicableRate
-
-	// RESICO has NO:
-	// - IMSS (result.IMSSWorker = 0)
-	// - Subsidio al Empleo (result.SubsidioEmpleo = 0)
-	// - SBC (result.SBC = 0)
-	
-	// Calculate Net Salary
-	result.NetSalary = monthlyIncome - result.ISRTax
-
-	// Process Other Benefits (Otras prestaciones) - separate monthly and annual
-	var otherBenefitsMonthlyNet float64
-	var otherBenefitsAnnualNet float64
-	
-	// Calculate gross annual salary for percentage calculations
-	grossAnnualSalary := monthlyIncome * 12.0
-	
-	for _, benefit := range otherBenefits {
-		// Calculate benefit amount (handle percentage vs fixed)
-		benefitAmount := benefit.Amount
-		if benefit.IsPercentage {
-			// Percentage of gross annual salary
-			benefitAmount = grossAnnualSalary * (benefit.Amount / 100.0)
-		} else {
-			// Fixed amount - convert to MXN if needed
-			if benefit.Currency == "USD" {
-				benefitAmount = benefit.Amount * exchangeRate
-			}
-		}
-		
-		benefitResult := database.OtherBenefitResult{
-			Name:    benefit.Name,
-			Amount:  benefitAmount,
-			TaxFree: benefit.TaxFree,
-			Cadence: benefit.Cadence,
-		}
-		
-		if benefit.TaxFree {
-			// Tax-free benefits
-			benefitResult.ISR = 0
-			benefitResult.Net = benefitAmount
-			app.logger.Info("RESICO other benefit (tax-free)", "name", benefit.Name, "gross", benefitAmount, "net", benefitResult.Net)
-		} else {
-			// Taxable benefits - apply RESICO rate
-			benefitResult.ISR = benefitAmount * resicoBracket.ApplicableRate
-			benefitResult.Net = benefitAmount - benefitResult.ISR
-			app.logger.Info("RESICO other benefit (taxable)", "name", benefit.Name, "gross", benefitAmount, "isr", benefitResult.ISR, "net", benefitResult.Net, "rate", resicoBracket.ApplicableRate)
-		}
-		
-		result.OtherBenefits = append(result.OtherBenefits, benefitResult)
-		
-		// Add to monthly or annual based on cadence
-		if benefit.Cadence == "annual" {
-			otherBenefitsAnnualNet += benefitResult.Net
-		} else {
-			// Default to monthly
-			otherBenefitsMonthlyNet += benefitResult.Net
-		}
-	}
-	
-	// Add monthly other benefits to monthly net
-	result.OtherBenefitsMonthlyNet = otherBenefitsMonthlyNet
-	result.NetSalary += otherBenefitsMonthlyNet
-
-	// Calculate yearly totals (include annual benefits)
-	result.YearlyGrossBase = monthlyIncome * 12
-	result.YearlyGross = result.YearlyGrossBase
-	
-	// RESICO Unpaid Vacation Adjustment
-	// Freelancers don't get paid when they don't work - this is "opportunity cost"
-	if unpaidVacationDays > 0 {
-		dailyRate := monthlyIncome / 30.4 // Average days per month
-		result.UnpaidVacationLoss = dailyRate * float64(unpaidVacationDays)
-		
-		// Reduce yearly gross and net by the lost income
-		result.YearlyGrossBase -= result.UnpaidVacationLoss
-		result.YearlyGross -= result.UnpaidVacationLoss
-	}
-	
-	result.YearlyNet = (result.NetSalary * 12) + otherBenefitsAnnualNet - result.UnpaidVacationLoss
-	result.MonthlyAdjusted = result.YearlyNet / 12.0
-
-	return result, nil
+// calculateActividadEmpresarial performs the Actividad Empresarial y Profesional
+// calculation (progressive ISR on income minus deductible expenses)
+func (app *application) calculateActividadEmpresarial(tables *payroll.RateTables, input payroll.ActividadEmpresarialInput) (database.SalaryCalculation, error) {
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start).Seconds()
+		metrics.TotalCompCalculations.Inc()
+		metrics.CalculationDuration.Observe(duration)
+	}()
+
+	return payroll.CalculateActividadEmpresarial(tables, input)
+}
+
+// calculateAsimilados performs the Asimilados a Salarios calculation (salary
+// ISR without IMSS, subsidio or statutory benefits)
+func (app *application) calculateAsimilados(tables *payroll.RateTables, input payroll.AsimiladosInput) (database.SalaryCalculation, error) {
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start).Seconds()
+		metrics.TotalCompCalculations.Inc()
+		metrics.CalculationDuration.Observe(duration)
+	}()
+
+	return payroll.CalculateAsimilados(tables, input)
 }
 
 // calculateSalaryWithBenefits performs the full Mexican payroll calculation with benefits
-func (app *application) calculateSalaryWithBenefits(
-	grossMonthlySalary float64,
-	hasAguinaldo bool, aguinaldoDays int,
-	hasValesDespensa bool, valesDespensaAmount float64,
-	hasPrimaVacacional bool, vacationDays int, primaVacacionalPercent float64,
-	hasFondoAhorro bool, fondoAhorroPercent float64,
-	hasInfonavitCredit bool,
-	otherBenefits []OtherBenefit,
-	exchangeRate float64,
-	fiscalYear database.FiscalYear,
-) (database.SalaryCalculation, error) {
+func (app *application) calculateSalaryWithBenefits(tables *payroll.RateTables, input payroll.SalaryInput) (database.SalaryCalculation, error) {
 	start := time.Now()
 	defer func() {
 		duration := time.Since(start).Seconds()
 		metrics.TotalCompCalculations.Inc()
 		metrics.CalculationDuration.Observe(duration)
 	}()
-	
-	// Calculate monthly first
-	result, err := app.calculateSalary(grossMonthlySalary, 1, fiscalYear)
-	if err != nil {
-		return result, err
-	}
-	
-	// Apply Fondo de Ahorro monthly deduction
-	if hasFondoAhorro {
-		monthlyDeduction := grossMonthlySalary * (fondoAhorroPercent / 100.0)
-		// Cap at 1.3 UMA annually / 12
-		maxMonthlyDeduction := (fiscalYear.UMAAnnual * 1.3) / 12.0
-		if monthlyDeduction > maxMonthlyDeduction {
-			monthlyDeduction = maxMonthlyDeduction
-		}
-		result.FondoAhorroEmployee = monthlyDeduction
-		result.NetSalary -= monthlyDeduction
-	}
-	
-	// Add Vales de Despensa to monthly net (tax-free, max 1 UMA monthly)
-	if hasValesDespensa {
-		monthlyVales := valesDespensaAmount
-		if monthlyVales > fiscalYear.UMAMonthly {
-			monthlyVales = fiscalYear.UMAMonthly
-		}
-		result.ValesDespensaMonthly = monthlyVales
-		result.NetSalary += monthlyVales
-	}
-	
-	// Process Other Benefits (Otras prestaciones) - separate monthly and annual
-	var otherBenefitsMonthlyNet float64
-	var otherBenefitsAnnualNet float64
-	
-	// Calculate gross annual salary for percentage calculations
-	grossAnnualSalary := grossMonthlySalary * 12.0
-	
-	for _, benefit := range otherBenefits {
-		// Calculate benefit amount (handle percentage vs fixed)
-		benefitAmount := benefit.Amount
-		if benefit.IsPercentage {
-			// Percentage of gross annual salary
-			benefitAmount = grossAnnualSalary * (benefit.Amount / 100.0)
-		} else {
-			// Fixed amount - convert to MXN if needed
-			if benefit.Currency == "USD" {
-				benefitAmount = benefit.Amount * exchangeRate
-			}
-		}
-		
-		benefitResult := database.OtherBenefitResult{
-			Name:    benefit.Name,
-			Amount:  benefitAmount,
-			TaxFree: benefit.TaxFree,
-			Cadence: benefit.Cadence,
-		}
-		
-		if benefit.TaxFree {
-			// Tax-free benefits
-			benefitResult.ISR = 0
-			benefitResult.Net = benefitAmount
-			app.logger.Info("Other benefit (tax-free)", "name", benefit.Name, "gross", benefitAmount, "net", benefitResult.Net)
-		} else {
-			// Taxable benefits
-			isrBrackets, err := app.db.GetISRBrackets(fiscalYear.ID)
-			if err != nil {
-				return result, err
-			}
-			
-			// Use Article 174 method for annual bonuses (considers base salary)
-			// Use standard ISR for monthly benefits (isolated calculation)
-			if benefit.Cadence == "annual" {
-				benefitResult.ISR = calculateTaxArt174(grossMonthlySalary, benefitAmount, isrBrackets)
-				app.logger.Info("Other benefit (annual taxable)", "name", benefit.Name, "gross", benefitAmount, "isr", benefitResult.ISR, "net", benefitAmount-benefitResult.ISR, "monthly_salary", grossMonthlySalary)
-			} else {
-				benefitResult.ISR = calculateISR(benefitAmount, isrBrackets)
-				app.logger.Info("Other benefit (monthly taxable)", "name", benefit.Name, "gross", benefitAmount, "isr", benefitResult.ISR, "net", benefitAmount-benefitResult.ISR)
-			}
-			benefitResult.Net = benefitAmount - benefitResult.ISR
-		}
-		
-		result.OtherBenefits = append(result.OtherBenefits, benefitResult)
-		
-		// Add to monthly or annual based on cadence
-		if benefit.Cadence == "annual" {
-			otherBenefitsAnnualNet += benefitResult.Net
-		} else {
-			// Default to monthly
-			otherBenefitsMonthlyNet += benefitResult.Net
+
+	return payroll.CalculateSalaryWithBenefits(tables, input)
+}
+
+// packageRegime maps the regime of a form or API package to the payroll regime.
+// Like the calculation itself, anything that is not asimilados, RESICO or
+// Actividad Empresarial is a Sueldos y Salarios package.
+func packageRegime(regime string) payroll.Regime {
+	switch payroll.Regime(regime) {
+	case payroll.RegimeAsimilados, payroll.RegimeRESICO, payroll.RegimeActividadEmpresarial:
+		return payroll.Regime(regime)
+	}
+	return payroll.RegimeSueldos
+}
+
+// resolveZone returns the zone of a package: a known border municipality wins
+// over the selected zone, and anything unrecognised falls back to the general zone
+func resolveZone(zone, municipality string) payroll.Zone {
+	if m, found := payroll.LookupMunicipality(municipality); found {
+		return m.Zone
+	}
+	if payroll.Zone(zone).Valid() {
+		return payroll.Zone(zone)
+	}
+	return payroll.ZoneGeneral
+}
+
+// parsePromotions reads the promotions of the comparison form, written as
+// "year:raise" pairs separated by commas (e.g. "3:15, 5:10"). Pairs that do not
+// parse or fall outside the projection are ignored.
+func parsePromotions(value string, years int) []payroll.Promotion {
+	var promotions []payroll.Promotion
+	for _, pair := range strings.Split(value, ",") {
+		var promotion payroll.Promotion
+		_, err := fmt.Sscanf(strings.TrimSpace(pair), "%d:%f", &promotion.Year, &promotion.Raise)
+		if err != nil || promotion.Year < 2 || promotion.Year > years || promotion.Raise <= -100 {
+			continue
 		}
+		promotions = append(promotions, promotion)
 	}
-	
-	// Add monthly other benefits to monthly net
-	result.OtherBenefitsMonthlyNet = otherBenefitsMonthlyNet
-	result.NetSalary += otherBenefitsMonthlyNet
-	
-	// Calculate yearly components (paid once per year)
-	dailySalary := grossMonthlySalary / 30.4
-	
-	// 1. Aguinaldo (subject to ISR with 30 UMA exemption per LISR Article 93, not subject to IMSS)
-	if hasAguinaldo {
-		result.AguinaldoGross = dailySalary * float64(aguinaldoDays)
-		
-		// LISR Article 93: Aguinaldo is exempt from ISR up to 30 UMAs
-		exemptAmount := 30.0 * fiscalYear.UMADaily
-		
-		// Only the amount exceeding the exemption is taxable
-		taxableBase := math.Max(0, result.AguinaldoGross-exemptAmount)
-		
-		// Calculate ISR on taxable base using Article 174 (progressive method)
-		if taxableBase > 0 {
-			isrBrackets, err := app.db.GetISRBrackets(fiscalYear.ID)
-			if err != nil {
-				return result, err
-			}
-			result.AguinaldoISR = calculateTaxArt174(grossMonthlySalary, taxableBase, isrBrackets)
-		} else {
-			result.AguinaldoISR = 0
+	return promotions
+}
+
+// parseVestingPercents reads the yearly vesting percentages of the comparison
+// form, separated by slashes or commas (e.g. "5/15/40/40"). An empty value means
+// equal vesting.
+func parseVestingPercents(value string) ([]float64, error) {
+	var percents []float64
+	for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == '/' || r == ',' }) {
+		field = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(field), "%"))
+		if field == "" {
+			continue
 		}
-		
-		result.AguinaldoNet = result.AguinaldoGross - result.AguinaldoISR
-	}
-	
-	// 2. Prima Vacacional (subject to ISR with 15 UMA exemption per LISR Article 93, not subject to IMSS)
-	if hasPrimaVacacional {
-		vacationSalary := dailySalary * float64(vacationDays)
-		result.PrimaVacacionalGross = vacationSalary * (primaVacacionalPercent / 100.0)
-		
-		// LISR Article 93: Prima Vacacional is exempt from ISR up to 15 UMAs
-		exemptAmount := 15.0 * fiscalYear.UMADaily
-		
-		// Only the amount exceeding the exemption is taxable
-		taxableBase := math.Max(0, result.PrimaVacacionalGross-exemptAmount)
-		
-		// Calculate ISR on taxable base using Article 174 (progressive method)
-		if taxableBase > 0 {
-			isrBrackets, err := app.db.GetISRBrackets(fiscalYear.ID)
-			if err != nil {
-				return result, err
-			}
-			result.PrimaVacacionalISR = calculateTaxArt174(grossMonthlySalary, taxableBase, isrBrackets)
-		} else {
-			result.PrimaVacacionalISR = 0
+		percent, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vesting percentage %q", field)
 		}
-		
-		result.PrimaVacacionalNet = result.PrimaVacacionalGross - result.PrimaVacacionalISR
-	}
-	
-	// 3. Fondo de Ahorro yearly return (company returns 2x employee contribution)
-	if hasFondoAhorro {
-		yearlyEmployeeContribution := result.FondoAhorroEmployee * 12
-		// Company matches 100% (returns 2x what was deducted)
-		result.FondoAhorroYearly = yearlyEmployeeContribution * 2
-	}
-	
-	// 4. Infonavit Employer Contribution (Art 29, Ley Infonavit)
-	// Employers pay 5% of SBC (already capped at 25 UMAs)
-	// Paid bimonthly but shown as monthly equivalent
-	// This is NON-LIQUID (goes to housing fund, not employee's pocket)
-	monthlySBC := result.SBC * 30.4 // Daily SBC to Monthly
-	result.InfonavitEmployerMonthly = monthlySBC * 0.05
-	result.InfonavitEmployerAnnual = result.InfonavitEmployerMonthly * 12
-	result.HasInfonavitCredit = hasInfonavitCredit // Flag to determine if it's mortgage payment or savings
-	
-	// 5. IMSS Employer Contributions (Non-liquid, part of total comp)
-	imssEmployer, err := app.calculateIMSSEmployer(grossMonthlySalary, fiscalYear)
-	if err != nil {
-		return result, err
-	}
-	result.IMSSEmployerMonthly = imssEmployer
-	result.IMSSEmployerAnnual = imssEmployer * 12
-	
-	// Calculate yearly totals
-	result.YearlyGrossBase = grossMonthlySalary * 12 // Compensation bruta anual (solo salario)
-	
-	// YearlyGross includes:
-	// - Base salary (12 months)
-	// - Aguinaldo
-	// - Prima Vacacional
-	// - Infonavit Employer (12 months) - Non-liquid but part of total comp
-	// - IMSS Employer (12 months) - Non-liquid but part of total comp
-	result.YearlyGross = result.YearlyGrossBase + result.AguinaldoGross + result.PrimaVacacionalGross + 
-		(result.InfonavitEmployerMonthly * 12) + (result.IMSSEmployerMonthly * 12)
-	result.YearlyNet = (result.NetSalary * 12) + result.AguinaldoNet + result.PrimaVacacionalNet + result.FondoAhorroYearly + otherBenefitsAnnualNet
-	result.MonthlyAdjusted = result.YearlyNet / 12.0
-	
-	return result, nil
+		percents = append(percents, percent)
+	}
+	return percents, nil
 }
 
-// calculateSalary performs the full Mexican payroll calculation
-func (app *application) calculateSalary(grossMonthlySalary float64, yearsOfService int, fiscalYear database.FiscalYear) (database.SalaryCalculation, error) {
-	result := database.SalaryCalculation{
-		GrossSalary: grossMonthlySalary,
+// payslipJSON renders the per-period withholding for the JSON API; monthly
+// payrolls have no separate payslip and render as null
+func payslipJSON(slip *database.PeriodWithholding) map[string]interface{} {
+	if slip == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"periodicity":     slip.Periodicity,
+		"gross":           slip.Gross,
+		"isr_tax":         slip.ISRTax,
+		"subsidio_empleo": slip.SubsidioEmpleo,
+		"imss_worker":     slip.IMSSWorker,
+		"net":             slip.Net,
 	}
+}
 
-	// Calculate ISR Tax
-	isrBrackets, err := app.db.GetISRBrackets(fiscalYear.ID)
-	if err != nil {
-		return result, err
+// invoiceJSON renders the monthly invoice of independent packages for the JSON
+// API; packages that do not charge IVA render as null
+func invoiceJSON(invoice *database.Invoice) map[string]interface{} {
+	if invoice == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"subtotal":        invoice.Subtotal,
+		"iva_trasladado":  invoice.IVATrasladado,
+		"iva_retenido":    invoice.IVARetenido,
+		"isr_retenido":    invoice.ISRRetenido,
+		"total":           invoice.Total,
+		"iva_acreditable": invoice.IVAAcreditable,
+		"iva_payable":     invoice.IVAPayable,
+		"iva_in_favor":    invoice.IVAInFavor,
+		"taxes_owed":      invoice.TaxesOwed,
 	}
+}
 
-	result.ISRTax = calculateISR(grossMonthlySalary, isrBrackets)
+// employerCostJSON renders the monthly employer cost for the JSON API; packages
+// without a state render as null
+func employerCostJSON(cost *database.EmployerCost) map[string]interface{} {
+	if cost == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"state_code":     cost.StateCode,
+		"state_name":     cost.StateName,
+		"salary":         cost.Salary,
+		"benefits":       cost.Benefits,
+		"imss":           cost.IMSS,
+		"retiro":         cost.Retiro,
+		"cesantia":       cost.Cesantia,
+		"riesgo_trabajo": cost.RiesgoTrabajo,
+		"infonavit":      cost.Infonavit,
+		"isn_base":       cost.ISNBase,
+		"isn_rate":       cost.ISNRate,
+		"isn":            cost.ISN,
+		"total":          cost.Total,
+		"total_annual":   cost.TotalAnnual,
+		"factor":         cost.Factor,
+	}
+}
 
-	// Calculate Subsidio al Empleo (if applicable)
-	if grossMonthlySalary <= fiscalYear.SubsidyThresholdMonthly {
-		result.SubsidioEmpleo = grossMonthlySalary * fiscalYear.SubsidyFactor
+// aforeJSON renders the AFORE projection for the JSON API; packages without a
+// projection render as null
+func aforeJSON(projection *database.AforeProjection) map[string]interface{} {
+	if projection == nil {
+		return nil
 	}
 
-	// Calculate IMSS Worker contributions
-	imssWorker, err := app.calculateIMSSWorker(grossMonthlySalary, fiscalYear)
-	if err != nil {
-		return result, err
+	schedule := make([]map[string]interface{}, 0, len(projection.Schedule))
+	for _, year := range projection.Schedule {
+		schedule = append(schedule, map[string]interface{}{
+			"year":          year.Year,
+			"daily_sbc":     year.DailySBC,
+			"retiro":        year.Retiro,
+			"cesantia":      year.Cesantia,
+			"social_quota":  year.SocialQuota,
+			"voluntary":     year.Voluntary,
+			"contributions": year.Contributions,
+			"returns":       year.Returns,
+			"balance":       year.Balance,
+			"capped":        year.Capped,
+		})
 	}
-	result.IMSSWorker = imssWorker
 
-	// Calculate SBC (Salario Base de Cotización)
-	// For simplicity in MVP, using gross salary as base
-	// In production, you'd calculate with aguinaldo, prima vacacional, etc.
-	result.SBC = calculateSBC(grossMonthlySalary, yearsOfService, fiscalYear)
+	return map[string]interface{}{
+		"years":               projection.Years,
+		"real_return":         projection.RealReturn,
+		"salary_growth":       projection.SalaryGrowth,
+		"initial_balance":     projection.InitialBalance,
+		"total_contributions": projection.TotalContributions,
+		"total_returns":       projection.TotalReturns,
+		"balance":             projection.Balance,
+		"sbc_capped":          projection.SBCCapped,
+		"uncapped_sbc":        projection.UncappedSBC,
+		"lost_balance":        projection.LostBalance,
+		"schedule":            schedule,
+	}
+}
 
-	// Calculate Net Salary
-	// Net = Gross - ISR + Subsidio - IMSS - Other Deductions
-	result.NetSalary = grossMonthlySalary - result.ISRTax + result.SubsidioEmpleo - result.IMSSWorker
+// projectionJSON renders the multi-year projection for the JSON API; packages
+// without a projection render as null
+func projectionJSON(projection *database.CompensationProjection) map[string]interface{} {
+	if projection == nil {
+		return nil
+	}
 
-	return result, nil
-}
+	schedule := make([]map[string]interface{}, 0, len(projection.Schedule))
+	for _, year := range projection.Schedule {
+		schedule = append(schedule, map[string]interface{}{
+			"year":                 year.Year,
+			"price_level":          year.PriceLevel,
+			"gross_monthly_salary": year.GrossMonthlySalary,
+			"isr_tax":              year.ISRTax,
+			"imss_worker":          year.IMSSWorker,
+			"yearly_net":           year.YearlyNet,
+			"yearly_net_real":      year.YearlyNetReal,
+			"equity":               year.Equity,
+			"equity_real":          year.EquityReal,
+			"total":                year.Total,
+			"total_real":           year.TotalReal,
+			"cumulative":           year.Cumulative,
+			"cumulative_real":      year.CumulativeReal,
+		})
+	}
 
-// calculateISR calculates the ISR tax based on progressive brackets
-func calculateISR(grossSalary float64, brackets []database.ISRBracket) float64 {
-	for _, bracket := range brackets {
-		if grossSalary >= bracket.LowerLimit && grossSalary <= bracket.UpperLimit {
-			surplus := grossSalary - bracket.LowerLimit
-			isr := bracket.FixedFee + (surplus * bracket.SurplusPercent)
-			return math.Round(isr*100) / 100 // Round to 2 decimals
-		}
+	return map[string]interface{}{
+		"years":                   projection.Years,
+		"annual_raise":            projection.AnnualRaise,
+		"inflation":               projection.Inflation,
+		"cumulative_4_years":      projection.Cumulative4Years,
+		"cumulative_4_years_real": projection.Cumulative4YearsReal,
+		"cumulative_5_years":      projection.Cumulative5Years,
+		"cumulative_5_years_real": projection.Cumulative5YearsReal,
+		"schedule":                schedule,
 	}
-	return 0
 }
 
-// calculateTaxArt174 calculates ISR on annual bonuses using Article 174 methodology
-// This prevents under-taxation by considering that the base salary has already consumed lower brackets
-func calculateTaxArt174(grossMonthlySalary, annualBonusAmount float64, brackets []database.ISRBracket) float64 {
-	if annualBonusAmount <= 0 {
-		return 0
-	}
-	
-	// Step A: Convert bonus to daily rate, then to monthly equivalent
-	// This represents what the bonus would be if spread over the year
-	remuneracionMensual := (annualBonusAmount / 365.0) * 30.4
-	
-	// Step B: Calculate partial tax
-	// Tax on (Salary + Monthly Share of Bonus)
-	taxOnTotal := calculateISR(grossMonthlySalary+remuneracionMensual, brackets)
-	
-	// Tax on Salary alone
-	taxOnSalary := calculateISR(grossMonthlySalary, brackets)
-	
-	// Tax attributable to the monthly share of the bonus
-	taxOnShare := taxOnTotal - taxOnSalary
-	
-	// Step C: Calculate effective rate
-	// This is the marginal rate at which this bonus should be taxed
-	var effectiveRate float64
-	if remuneracionMensual > 0 {
-		effectiveRate = taxOnShare / remuneracionMensual
-	}
-	
-	// Step D: Apply rate to full bonus
-	taxToWithhold := annualBonusAmount * effectiveRate
-	
-	return math.Round(taxToWithhold*100) / 100 // Round to 2 decimals
-}
+// traceJSON renders the step-by-step trace for the JSON API; calculations
+// without ?explain=true render as null
+func traceJSON(trace *database.CalculationTrace) map[string]interface{} {
+	if trace == nil {
+		return nil
+	}
 
-// calculateIMSSWorker calculates the worker's IMSS contributions
-func (app *application) calculateIMSSWorker(grossSalary float64, fiscalYear database.FiscalYear) (float64, error) {
-	concepts, err := app.db.GetIMSSConcepts()
-	if err != nil {
-		return 0, err
+	isr := make([]map[string]interface{}, 0, len(trace.ISR))
+	for _, step := range trace.ISR {
+		isr = append(isr, map[string]interface{}{
+			"label":           step.Label,
+			"periodicity":     step.Periodicity,
+			"base":            step.Base,
+			"lower_limit":     step.LowerLimit,
+			"upper_limit":     step.UpperLimit,
+			"fixed_fee":       step.FixedFee,
+			"surplus_percent": step.SurplusPercent,
+			"surplus":         step.Surplus,
+			"tax":             step.Tax,
+		})
 	}
 
-	dailySalary := grossSalary / 30.4 // Average days in a month
-	var total float64
+	art174 := make([]map[string]interface{}, 0, len(trace.Art174))
+	for _, step := range trace.Art174 {
+		art174 = append(art174, map[string]interface{}{
+			"label":          step.Label,
+			"amount":         step.Amount,
+			"monthly_share":  step.MonthlyShare,
+			"tax_on_total":   step.TaxOnTotal,
+			"tax_on_salary":  step.TaxOnSalary,
+			"tax_on_share":   step.TaxOnShare,
+			"effective_rate": step.EffectiveRate,
+			"tax":            step.Tax,
+		})
+	}
 
-	for _, concept := range concepts {
-		// Cap the base salary at 25 UMAs (or whatever the concept specifies)
-		baseForCalculation := dailySalary
-		if concept.BaseCapInUMAs > 0 {
-			maxBase := float64(concept.BaseCapInUMAs) * fiscalYear.UMADaily
-			if dailySalary > maxBase {
-				baseForCalculation = maxBase
-			}
-		}
+	imss := make([]map[string]interface{}, 0, len(trace.IMSS))
+	for _, step := range trace.IMSS {
+		imss = append(imss, map[string]interface{}{
+			"concept":          step.Concept,
+			"base_type":        step.BaseType,
+			"daily_base":       step.DailyBase,
+			"capped":           step.Capped,
+			"monthly_base":     step.MonthlyBase,
+			"worker_percent":   step.WorkerPercent,
+			"worker":           step.Worker,
+			"employer_percent": step.EmployerPercent,
+			"employer":         step.Employer,
+		})
+	}
 
-		// Calculate monthly contribution
-		monthlyBase := baseForCalculation * 30.4
-		contribution := monthlyBase * concept.WorkerPercent
-
-		// Special handling for Cesantía (progressive)
-		if !concept.IsFixedRate && concept.ConceptName == "Cesantía en Edad Avanzada y Vejez" {
-			salaryInUMAs := dailySalary / fiscalYear.UMADaily
-			_, found, err := app.db.GetCesantiaBracket(fiscalYear.ID, salaryInUMAs)
-			if err != nil {
-				return 0, err
-			}
-			if found {
-				// Worker pays fixed 1.125%, but we already calculated it above
-				// The progressive part is for employer
-				contribution = monthlyBase * concept.WorkerPercent
-			}
+	var cesantia map[string]interface{}
+	if trace.Cesantia != nil {
+		cesantia = map[string]interface{}{
+			"salary_in_umas":   trace.Cesantia.SalaryInUMAs,
+			"lower_bound_uma":  trace.Cesantia.LowerBoundUMA,
+			"upper_bound_uma":  trace.Cesantia.UpperBoundUMA,
+			"employer_percent": trace.Cesantia.EmployerPercent,
 		}
+	}
 
-		total += contribution
+	exemptions := make([]map[string]interface{}, 0, len(trace.Exemptions))
+	for _, step := range trace.Exemptions {
+		exemptions = append(exemptions, map[string]interface{}{
+			"concept": step.Concept,
+			"rule":    step.Rule,
+			"limit":   step.Limit,
+			"gross":   step.Gross,
+			"exempt":  step.Exempt,
+			"taxable": step.Taxable,
+		})
 	}
 
-	return math.Round(total*100) / 100, nil
+	return map[string]interface{}{
+		"isr":        isr,
+		"art174":     art174,
+		"imss":       imss,
+		"cesantia":   cesantia,
+		"exemptions": exemptions,
+	}
 }
 
-// calculateIMSSEmployer calculates the employer's IMSS contributions
-// This is NON-LIQUID compensation (doesn't go to employee's pocket)
-func (app *application) calculateIMSSEmployer(grossSalary float64, fiscalYear database.FiscalYear) (float64, error) {
-	concepts, err := app.db.GetIMSSConcepts()
-	if err != nil {
-		return 0, err
+// curveJSON renders a tax curve for the JSON API
+func curveJSON(curve *database.TaxCurve) map[string]interface{} {
+	if curve == nil {
+		return nil
 	}
 
-	dailySalary := grossSalary / 30.4 // Average days in a month
-	var total float64
-
-	for _, concept := range concepts {
-		// Cap the base salary at 25 UMAs (or whatever the concept specifies)
-		baseForCalculation := dailySalary
-		if concept.BaseCapInUMAs > 0 {
-			maxBase := float64(concept.BaseCapInUMAs) * fiscalYear.UMADaily
-			if dailySalary > maxBase {
-				baseForCalculation = maxBase
-			}
-		}
+	points := make([]map[string]interface{}, 0, len(curve.Points))
+	for _, point := range curve.Points {
+		points = append(points, map[string]interface{}{
+			"gross_salary":       point.GrossMonthlySalary,
+			"net_salary":         point.NetSalary,
+			"yearly_net":         point.YearlyNet,
+			"isr_tax":            point.ISRTax,
+			"imss_worker":        point.IMSSWorker,
+			"effective_rate":     point.EffectiveRate,
+			"marginal_isr_rate":  point.MarginalISRRate,
+			"marginal_imss_rate": point.MarginalIMSSRate,
+			"net_drops":          point.NetDrops,
+		})
+	}
 
-		// Calculate monthly contribution
-		monthlyBase := baseForCalculation * 30.4
-		contribution := monthlyBase * concept.EmployerPercent
-
-		// Special handling for Cesantía (progressive for employer)
-		if !concept.IsFixedRate && concept.ConceptName == "Cesantía en Edad Avanzada y Vejez" {
-			salaryInUMAs := dailySalary / fiscalYear.UMADaily
-			bracket, found, err := app.db.GetCesantiaBracket(fiscalYear.ID, salaryInUMAs)
-			if err != nil {
-				return 0, err
-			}
-			if found {
-				// Employer pays progressive rate based on bracket
-				contribution = monthlyBase * bracket.EmployerPercent
-			}
-		}
+	return map[string]interface{}{
+		"step":   curve.Step,
+		"points": points,
+	}
+}
 
-		total += contribution
+// equityJSON renders the vesting schedule for the JSON API; null without a grant
+func equityJSON(config equity.EquityConfig, schedule []equity.YearlyEquity, outlook *equity.ValueOutlook) map[string]interface{} {
+	if len(schedule) == 0 {
+		return nil
 	}
 
-	return math.Round(total*100) / 100, nil
-}
+	years := make([]map[string]interface{}, 0, len(schedule)-1)
+	for _, year := range schedule[1:] {
+		years = append(years, map[string]interface{}{
+			"year":                  year.Year,
+			"initial_grant_vested":  year.InitialGrantVested,
+			"refresher_vested":      year.RefresherTotal,
+			"new_refresher_granted": year.NewRefresherGranted,
+			"total_vested_usd":      year.TotalVested,
+			"total_vested_mxn":      year.TotalVestedMXN,
+			"isr_mxn":               year.ISRMXN,
+			"net_vested_mxn":        year.NetVestedMXN,
+			"sold_to_cover_mxn":     year.SoldToCoverMXN,
+			"settlement_mxn":        year.SettlementMXN,
+		})
+	}
 
-// calculateSBC calculates the Salario Base de Cotización
-// This is a simplified version for MVP
-func calculateSBC(grossMonthlySalary float64, yearsOfService int, fiscalYear database.FiscalYear) float64 {
-	// Simplified: In reality, you'd need to calculate with aguinaldo, prima vacacional, etc.
-	// For MVP, we'll use a basic factor
-	
-	// Default integration factor (aguinaldo 15 days + prima vac 12 days * 25% = 18 days / 365)
-	integrationFactor := 1.0493 // Approximately 4.93% additional for benefits
+	frequency := config.VestFrequency
+	if frequency == "" {
+		frequency = equity.VestAnnual
+	}
+	withholding := config.Withholding
+	if withholding == "" {
+		withholding = equity.WithholdPayroll
+	}
 
-	// If years of service > 0, adjust slightly (simplified)
-	if yearsOfService > 0 {
-		// Each year adds slight increase due to more vacation days
-		additionalFactor := float64(yearsOfService) * 0.001
-		integrationFactor += additionalFactor
+	return map[string]interface{}{
+		"initial_grant_usd":     config.InitialGrantUSD,
+		"vesting_years":         config.Years(),
+		"vesting_percents":      config.VestingPercents,
+		"cliff_months":          config.CliffMonths,
+		"vest_frequency":        frequency,
+		"withholding":           withholding,
+		"sell_to_cover_percent": config.SellToCoverPercent,
+		"exchange_rate":         config.ExchangeRate,
+		"schedule":              years,
+		"outlook":               outlookJSON(outlook),
 	}
+}
 
-	dailySalary := grossMonthlySalary / 30.4
-	sbc := dailySalary * integrationFactor
+// outlookJSON renders the share price scenarios for the JSON API; null without them
+func outlookJSON(outlook *equity.ValueOutlook) map[string]interface{} {
+	if outlook == nil {
+		return nil
+	}
 
-	// Cap at 25 UMAs
-	maxSBC := 25 * fiscalYear.UMADaily
-	if sbc > maxSBC {
-		sbc = maxSBC
+	scenario := func(s equity.Scenario) map[string]interface{} {
+		return map[string]interface{}{
+			"growth":     s.Growth,
+			"vested_mxn": s.VestedMXN,
+			"total_mxn":  s.TotalMXN,
+		}
 	}
 
-	return math.Round(sbc*100) / 100
+	data := map[string]interface{}{
+		"years":    equity.OutlookYears,
+		"flat_mxn": outlook.FlatMXN,
+		"bear":     scenario(outlook.Bear),
+		"base":     scenario(outlook.Base),
+		"bull":     scenario(outlook.Bull),
+	}
+	if outlook.Simulations > 0 {
+		data["monte_carlo"] = map[string]interface{}{
+			"volatility":  outlook.Volatility,
+			"simulations": outlook.Simulations,
+			"p10_mxn":     outlook.P10,
+			"p50_mxn":     outlook.P50,
+			"p90_mxn":     outlook.P90,
+		}
+	}
+	return data
 }
-
@@ -29,6 +29,9 @@ func (app *application) routes() http.Handler {
 		mux.Use(app.requireAPIKey)
 
 		mux.HandleFunc("/api/v1/calculate", app.apiCalculate, "POST")
+		mux.HandleFunc("/api/v1/gross-from-net", app.apiGrossFromNet, "POST")
+		mux.HandleFunc("/api/v1/curves", app.apiCurves, "GET")
+		mux.HandleFunc("/api/v1/severance", app.apiSeverance, "POST")
 	})
 
 	// Web routes - WITH session, CSRF, and authentication
@@ -41,6 +44,10 @@ func (app *application) routes() http.Handler {
 		mux.HandleFunc("/", app.home, "GET", "POST")
 		mux.HandleFunc("/clear", app.clearSession, "POST")
 		mux.HandleFunc("/calculator", app.salaryCalculator, "GET", "POST")
+		mux.HandleFunc("/calculator/gross-from-net", app.grossFromNet, "GET", "POST")
+		mux.HandleFunc("/calculator/annual-return", app.annualReturn, "GET", "POST")
+		mux.HandleFunc("/calculator/severance", app.severance, "GET", "POST")
+		mux.HandleFunc("/calculator/severance/pdf", app.exportSeverancePDF, "GET")
 		mux.HandleFunc("/export-pdf", app.exportPDF, "GET")
 		mux.HandleFunc("/privacy", app.privacy, "GET")
 		mux.HandleFunc("/terms", app.terms, "GET")
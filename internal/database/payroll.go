@@ -3,103 +3,394 @@ package database
 import (
 	"context"
 	"database/sql"
+
+	"github.com/jcroyoaun/totalcompmx/internal/money"
 )
 
 type FiscalYear struct {
-	ID                        int
-	Year                      int
-	UMADaily                  float64
-	UMAMonthly                float64
-	UMAAnnual                 float64
-	UMIValue                  float64
-	SMGGeneral                float64
-	SMGBorder                 float64
-	SubsidyFactor             float64
-	SubsidyThresholdMonthly   float64
-	FALegalCapUMAFactor       float64
-	FALegalMaxPercentage      float64
-	PantryVouchersUMACap      float64
-	USDMXNRate                float64 // Exchange rate USD/MXN
+	ID                          int
+	Year                        int
+	UMADaily                    money.Money
+	UMAMonthly                  money.Money
+	UMAAnnual                   money.Money
+	UMIValue                    money.Money
+	SMGGeneral                  money.Money
+	SMGBorder                   money.Money
+	SubsidyFactor               money.Rate
+	SubsidyThresholdMonthly     money.Money
+	FALegalCapUMAFactor         money.Rate
+	FALegalMaxPercentage        money.Rate
+	PantryVouchersUMACap        money.Rate
+	USDMXNRate                  money.Rate  // Exchange rate USD/MXN
+	InfonavitInsuranceBimonthly money.Money // Infonavit seguro de daños fee per bimester
 }
 
 type ISRBracket struct {
-	LowerLimit     float64
-	UpperLimit     float64
-	FixedFee       float64
-	SurplusPercent float64
+	Periodicity    string // periodicity_enum value; empty means MONTHLY
+	LowerLimit     money.Money
+	UpperLimit     money.Money
+	FixedFee       money.Money
+	SurplusPercent money.Rate
 }
 
 type IMSSConcept struct {
 	ConceptName     string
-	WorkerPercent   float64
-	EmployerPercent float64
+	WorkerPercent   money.Rate
+	EmployerPercent money.Rate
 	BaseCapInUMAs   int
 	IsFixedRate     bool
+	BaseType        string // SBC, UMA_FIXED or EXCESS_UMA
+	ExcessOverUMAs  int    // UMAs of the SBC exempt from an EXCESS_UMA concept
 }
 
 type CesantiaBracket struct {
-	LowerBoundUMA   float64
-	UpperBoundUMA   float64
-	EmployerPercent float64
+	LowerBoundUMA    money.Rate
+	UpperBoundUMA    money.Rate
+	EmployerPercent  money.Rate
+	SocialQuotaDaily money.Money // Cuota social the government deposits per day; 0 above 4 UMAs
 }
 
 type RESICOBracket struct {
-	UpperLimit      float64
-	ApplicableRate  float64
+	UpperLimit     money.Money
+	ApplicableRate money.Rate
+}
+
+// RetentionRule is the ISR a client retains when paying an invoice, by regime and client type
+type RetentionRule struct {
+	RegimeName    string // e.g. RESICO
+	ClientType    string // PERSONA_FISICA or PERSONA_MORAL
+	RetentionRate money.Rate
+}
+
+// StatePayrollTax is the Impuesto Sobre Nóminas a state charges on the payroll
+type StatePayrollTax struct {
+	StateCode string // e.g. CDMX
+	StateName string
+	Rate      money.Rate
+}
+
+// RiskClass is the prima media de riesgo de trabajo of an IMSS company risk class
+type RiskClass struct {
+	Class       string // I to V
+	Description string
+	Premium     money.Rate
+}
+
+// SeniorityBenefit holds the statutory minimum benefits for a given year of service (Vacaciones Dignas)
+type SeniorityBenefit struct {
+	YearsOfService         int
+	VacationDays           int
+	PrimaVacacionalPercent money.Rate // Fraction, e.g. 0.25
+	AguinaldoDays          int
 }
 
 type SalaryCalculation struct {
 	// Monthly
-	GrossSalary             float64
-	ISRTax                  float64
-	SubsidioEmpleo          float64
-	IMSSWorker              float64
-	FondoAhorroEmployee     float64
-	InfonavitDiscount       float64
-	ValesDespensaMonthly    float64 // Added to monthly net
-	OtherBenefitsMonthlyNet float64 // Monthly otras prestaciones added to net
-	NetSalary               float64
-	SBC                     float64 // Salario Base de Cotización
-	
+	GrossSalary             money.Money
+	ISRTax                  money.Money
+	SubsidioEmpleo          money.Money
+	BorderISRCredit         money.Money // Border region ISR stimulus of Actividad Empresarial, added to net
+	IMSSWorker              money.Money
+	FondoAhorroEmployee     money.Money
+	InfonavitDiscount       money.Money // Monthly credit discount, including the seguro de daños
+	InfonavitInsurance      money.Money // Seguro de daños portion of InfonavitDiscount
+	ValesDespensaMonthly    money.Money // Added to monthly net
+	OtherBenefitsMonthlyNet money.Money // Monthly otras prestaciones added to net
+	OvertimeDouble          money.Money // Horas dobles: first 9 overtime hours of each week
+	OvertimeTriple          money.Money // Horas triples: overtime beyond 9 hours a week
+	OvertimeExempt          money.Money // ISR-exempt portion of the double hours
+	OvertimeISR             money.Money
+	OvertimeNet             money.Money // Added to monthly net
+	PrimaDominicalGross     money.Money // 25% premium on the Sundays worked
+	PrimaDominicalExempt    money.Money // 1 UMA per Sunday
+	PrimaDominicalISR       money.Money
+	PrimaDominicalNet       money.Money // Added to monthly net
+	NetSalary               money.Money
+	SBC                     money.Money // Salario Base de Cotización
+	IntegrationFactor       money.Rate  // Factor de Integración used to build the SBC
+	VacationDays            int         // Vacation days per year (statutory for the seniority unless the company grants more)
+	Zone                    string      // GENERAL, FRONTERA_NORTE or FRONTERA_SUR
+
 	// Yearly Components (paid once a year)
-	AguinaldoGross       float64
-	AguinaldoISR         float64
-	AguinaldoNet         float64
-	PrimaVacacionalGross float64
-	PrimaVacacionalISR   float64
-	PrimaVacacionalNet   float64
-	FondoAhorroYearly    float64 // What company returns (2x employee contribution)
-	
+	AguinaldoGross       money.Money
+	AguinaldoISR         money.Money
+	AguinaldoNet         money.Money
+	PrimaVacacionalGross money.Money
+	PrimaVacacionalISR   money.Money
+	PrimaVacacionalNet   money.Money
+	PTUGross             money.Money // Profit sharing after the three-month cap
+	PTUISR               money.Money
+	PTUNet               money.Money
+	PTUCapped            bool        // True when the cap reduced the expected PTU
+	FondoAhorroYearly    money.Money // What company returns (2x employee contribution)
+
 	// Employer Contributions (Non-Liquid, Total Comp only)
-	InfonavitEmployerMonthly float64 // 5% of capped SBC, paid bimonthly but shown as monthly
-	InfonavitEmployerAnnual  float64 // Infonavit x 12
-	IMSSEmployerMonthly      float64 // Total employer IMSS contributions
-	IMSSEmployerAnnual       float64 // IMSS x 12
-	HasInfonavitCredit       bool    // True if employee has an Infonavit mortgage
-	
+	InfonavitEmployerMonthly money.Money // 5% of capped SBC, paid bimonthly but shown as monthly
+	InfonavitEmployerAnnual  money.Money // Infonavit x 12
+	IMSSEmployerMonthly      money.Money // Total employer IMSS contributions
+	IMSSEmployerAnnual       money.Money // IMSS x 12
+	RiskClass                string      // Company risk class of the prima de riesgo; empty when not given
+	RiskPremium              money.Rate  // Prima de riesgo de trabajo applied, e.g. 0.0054355
+	HasInfonavitCredit       bool        // True if employee has an Infonavit mortgage
+
 	// Totals
-	YearlyGrossBase     float64 // Salary * 12 (no benefits)
-	YearlyGross         float64 // Total including all benefits
-	YearlyNet           float64
-	MonthlyAdjusted     float64 // Monthly net + (yearly benefits / 12)
-	
-	// RESICO Specific
-	UnpaidVacationDays  int     // RESICO only: days off without pay
-	UnpaidVacationLoss  float64 // RESICO only: income lost due to unpaid days off
-	
+	YearlyGrossBase money.Money // Salary * 12 (no benefits)
+	YearlyGross     money.Money // Total including all benefits
+	YearlyNet       money.Money
+	MonthlyAdjusted money.Money // Monthly net + (yearly benefits / 12)
+
+	// Independent Specific (RESICO and Actividad Empresarial)
+	UnpaidVacationDays    int         // Independent only: days off without pay
+	UnpaidVacationLoss    money.Money // Independent only: income lost due to unpaid days off
+	ClientType            string      // Independent only: PERSONA_FISICA or PERSONA_MORAL
+	ISRRetained           money.Money // Independent only: monthly ISR retained by the client
+	ISRProvisionalPayment money.Money // Independent only: monthly ISR paid to the SAT after the retention
+	CashReceived          money.Money // Independent only: monthly deposit from the client (income plus IVA minus retentions)
+	ExceedsRESICOCap      bool        // RESICO only: annual income above the regime's 3.5M ceiling
+	DeductibleExpenses    money.Money // Actividad Empresarial only: monthly deductions
+	TaxableProfit         money.Money // Actividad Empresarial only: income minus deductions, base of the ISR
+	Expenses              []ExpenseResult
+
+	// Asimilados Specific: employer social security the package does not include
+	MissingIMSSEmployerMonthly      money.Money // IMSS an employer would pay for the same salary
+	MissingInfonavitEmployerMonthly money.Money // Infonavit 5% an employer would pay for the same salary
+	MissingSocialSecurityAnnual     money.Money // Both x 12, excluded from YearlyGross
+
 	// Other Benefits
 	OtherBenefits []OtherBenefitResult
+
+	// Payslip of a single pay period when not paid monthly; the monthly figures
+	// above are this payslip times the periods in a month
+	Payslip *PeriodWithholding
+
+	// Monthly invoice of independent packages that charge IVA; nil otherwise
+	Invoice *Invoice
+
+	// Cost to the company of a Sueldos y Salarios package when a state is given; nil otherwise
+	EmployerCost *EmployerCost
+
+	// Retirement account projection of a Sueldos y Salarios package when requested; nil otherwise
+	Afore *AforeProjection
+
+	// Multi-year projection with raises, inflation and equity when requested; nil otherwise
+	Projection *CompensationProjection
+
+	// Step-by-step record of the calculation in explain mode; nil otherwise
+	Trace *CalculationTrace
+
+	// Net and tax rates across a range of gross salaries when requested; nil otherwise
+	Curve *TaxCurve
+}
+
+// EmployerCost is the monthly cost of an employee to the company (costo patronal)
+type EmployerCost struct {
+	StateCode     string
+	StateName     string
+	Salary        money.Money // Gross salary plus overtime and prima dominical
+	Benefits      money.Money // Monthly share of aguinaldo, prima vacacional, PTU, vales, fondo de ahorro and other benefits
+	IMSS          money.Money // Enfermedad y maternidad, invalidez y vida and guarderías
+	Retiro        money.Money // SAR
+	Cesantia      money.Money // Employer share of cesantía en edad avanzada y vejez
+	RiesgoTrabajo money.Money
+	Infonavit     money.Money
+	ISNBase       money.Money // Remuneraciones taxed by the state
+	ISNRate       money.Rate
+	ISN           money.Money
+	Total         money.Money
+	TotalAnnual   money.Money
+	Factor        money.Rate // Total over the gross salary
+}
+
+// AforeProjection is the projected balance of the retirement account (AFORE) in
+// today's pesos, from the Retiro, Cesantía and voluntary contributions of a package
+type AforeProjection struct {
+	Years              int
+	RealReturn         money.Rate // Yearly real return, e.g. 0.04
+	SalaryGrowth       money.Rate // Yearly real salary growth, e.g. 0.01
+	InitialBalance     money.Money
+	TotalContributions money.Money
+	TotalReturns       money.Money
+	Balance            money.Money
+	SBCCapped          bool        // True when the SBC hits 25 UMAs in some year
+	UncappedSBC        money.Money // Daily SBC the salary would have without the cap
+	LostBalance        money.Money // Balance the contributions above the cap would have added
+	Schedule           []AforeYear
+}
+
+// AforeYear is one year of an AFORE projection
+type AforeYear struct {
+	Year          int
+	DailySBC      money.Money
+	Retiro        money.Money // Employer 2%
+	Cesantia      money.Money // Employer and worker shares
+	SocialQuota   money.Money // Cuota social paid by the government
+	Voluntary     money.Money
+	Contributions money.Money
+	Returns       money.Money
+	Balance       money.Money
+	Capped        bool
+}
+
+// CompensationProjection is the year-by-year outlook of a package with raises,
+// promotions, inflation and equity vesting. Nominal amounts are in the pesos of
+// each year; real amounts are deflated to today's pesos with the expected INPC.
+type CompensationProjection struct {
+	Years                int
+	AnnualRaise          money.Rate  // Fraction, e.g. 0.05
+	Inflation            money.Rate  // Fraction, e.g. 0.04
+	Cumulative4Years     money.Money // Nominal net plus equity of the first 4 years; zero when shorter
+	Cumulative4YearsReal money.Money
+	Cumulative5Years     money.Money // Nominal net plus equity of the first 5 years; zero when shorter
+	Cumulative5YearsReal money.Money
+	Schedule             []ProjectionYear
+}
+
+// ProjectionYear is one year of a compensation projection
+type ProjectionYear struct {
+	Year               int
+	PriceLevel         money.Rate  // INPC relative to today
+	GrossMonthlySalary money.Money // Nominal
+	ISRTax             money.Money // Monthly, nominal
+	IMSSWorker         money.Money // Monthly, nominal
+	YearlyNet          money.Money // Nominal
+	YearlyNetReal      money.Money
+	Equity             money.Money // Vested this year in nominal MXN
+	EquityReal         money.Money
+	Total              money.Money // Net plus equity, nominal
+	TotalReal          money.Money
+	Cumulative         money.Money // Total of this and the previous years, nominal
+	CumulativeReal     money.Money
+}
+
+// TaxCurve samples a package across a range of gross monthly salaries with the
+// same benefit options, so bracket jumps and the Subsidio al Empleo cliff show
+// up as spikes of the marginal rates
+type TaxCurve struct {
+	Step   money.Money // Gross between two points
+	Points []CurvePoint
+}
+
+// CurvePoint is one salary of a tax curve. The marginal rates are the share
+// of the next Step of gross lost to each deduction.
+type CurvePoint struct {
+	GrossMonthlySalary money.Money
+	NetSalary          money.Money // Monthly
+	YearlyNet          money.Money
+	ISRTax             money.Money // Monthly, after the subsidio
+	IMSSWorker         money.Money // Monthly
+	EffectiveRate      money.Rate  // ISR and IMSS over gross
+	MarginalISRRate    money.Rate
+	MarginalIMSSRate   money.Rate
+	NetDrops           bool // Net is lower one step above, e.g. past the Subsidio al Empleo threshold
+}
+
+// PeriodWithholding is the withholding of one payslip (semana, catorcena, quincena...)
+type PeriodWithholding struct {
+	Periodicity    string // periodicity_enum value
+	Gross          money.Money
+	ISRTax         money.Money // From the period's own ISR tariff
+	SubsidioEmpleo money.Money
+	IMSSWorker     money.Money
+	Net            money.Money
+}
+
+// Invoice is the monthly CFDI an independent worker issues to the client, with
+// the taxes transferred and retained and the IVA left to pay
+type Invoice struct {
+	Subtotal       money.Money
+	IVATrasladado  money.Money // IVA charged to the client
+	IVARetenido    money.Money // IVA retained by a Persona Moral client
+	ISRRetenido    money.Money // ISR retained by a Persona Moral client
+	Total          money.Money // Subtotal plus IVA trasladado, what the CFDI shows
+	IVAAcreditable money.Money // IVA paid on the month's expenses
+	IVAPayable     money.Money // IVA trasladado minus retained and acreditable IVA
+	IVAInFavor     money.Money // Acreditable IVA left over when it exceeds the IVA owed
+	TaxesOwed      money.Money // ISR provisional payment plus IVA payable
+}
+
+// ExpenseResult is one deductible expense of an Actividad Empresarial package
+type ExpenseResult struct {
+	Category  string
+	Amount    money.Money // As entered: monthly spend, or purchase price for equipment
+	Deduction money.Money // Deducted from the month's income
 }
 
 type OtherBenefitResult struct {
 	Name    string
-	Amount  float64
+	Amount  money.Money
 	TaxFree bool
-	ISR     float64
-	Net     float64
+	ISR     money.Money
+	Net     money.Money
 	Cadence string // "monthly" or "annual"
 }
 
+// CalculationTrace records how a result was calculated (explain mode): every
+// ISR bracket applied, the Article 174 steps of the yearly payments, each IMSS
+// concept with its capped base, the Cesantía bracket and the UMA exemptions
+type CalculationTrace struct {
+	ISR        []ISRStep
+	Art174     []Art174Step
+	IMSS       []IMSSStep
+	Cesantia   *CesantiaStep // nil when no IMSS was calculated
+	Exemptions []ExemptionStep
+}
+
+// ISRStep is one lookup in an ISR tariff
+type ISRStep struct {
+	Label          string      // What was taxed, e.g. "Sueldo" or an other benefit's name
+	Periodicity    string      // Tariff used, periodicity_enum value
+	Base           money.Money // Taxable income
+	LowerLimit     money.Money
+	UpperLimit     money.Money
+	FixedFee       money.Money // Cuota fija
+	SurplusPercent money.Rate  // Tasa sobre el excedente
+	Surplus        money.Money // Base minus the lower limit
+	Tax            money.Money // Cuota fija plus the tax on the surplus
+}
+
+// Art174Step is the RLISR Article 174 calculation of a yearly payment
+type Art174Step struct {
+	Label         string      // e.g. "Aguinaldo"
+	Amount        money.Money // Taxable part of the payment
+	MonthlyShare  money.Money // Amount / 365 x 30.4
+	TaxOnTotal    money.Money // ISR of the salary plus the monthly share
+	TaxOnSalary   money.Money // ISR of the salary alone
+	TaxOnShare    money.Money // TaxOnTotal minus TaxOnSalary
+	EffectiveRate money.Rate  // TaxOnShare over MonthlyShare
+	Tax           money.Money // Amount times the effective rate
+}
+
+// IMSSStep is one IMSS concept on the daily SBC
+type IMSSStep struct {
+	Concept         string
+	BaseType        string      // SBC, UMA_FIXED or EXCESS_UMA
+	DailyBase       money.Money // Daily amount the percentages apply to
+	Capped          bool        // True when the concept's UMA cap limited the SBC
+	MonthlyBase     money.Money
+	WorkerPercent   money.Rate
+	Worker          money.Money
+	EmployerPercent money.Rate // After the prima de riesgo or the Cesantía bracket
+	Employer        money.Money
+}
+
+// CesantiaStep is the employer Cesantía bracket the SBC falls in
+type CesantiaStep struct {
+	SalaryInUMAs    money.Rate
+	LowerBoundUMA   money.Rate
+	UpperBoundUMA   money.Rate
+	EmployerPercent money.Rate
+}
+
+// ExemptionStep is an ISR exemption measured in UMAs (LISR Art. 93)
+type ExemptionStep struct {
+	Concept string      // e.g. "Aguinaldo"
+	Rule    string      // Limit as the law states it, e.g. "30 UMA"
+	Limit   money.Money // Rule in pesos
+	Gross   money.Money
+	Exempt  money.Money
+	Taxable money.Money
+}
+
 // GetActiveFiscalYear retrieves the active fiscal year configuration
 func (db *DB) GetActiveFiscalYear() (FiscalYear, bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
@@ -109,7 +400,8 @@ func (db *DB) GetActiveFiscalYear() (FiscalYear, bool, error) {
 		SELECT id, year, uma_daily, uma_monthly, uma_annual, umi_value,
 		       smg_general, smg_border, subsidy_factor, subsidy_threshold_monthly,
 		       fa_legal_cap_uma_factor, fa_legal_max_percentage, pantry_vouchers_uma_cap,
-		       COALESCE(usd_mxn_rate, 20.00) as usd_mxn_rate
+		       COALESCE(usd_mxn_rate, 20.00) as usd_mxn_rate,
+		       COALESCE(infonavit_insurance_bimonthly, 15.00) as infonavit_insurance_bimonthly
 		FROM fiscal_years
 		WHERE is_active = true
 		LIMIT 1`
@@ -119,7 +411,7 @@ func (db *DB) GetActiveFiscalYear() (FiscalYear, bool, error) {
 		&fy.ID, &fy.Year, &fy.UMADaily, &fy.UMAMonthly, &fy.UMAAnnual, &fy.UMIValue,
 		&fy.SMGGeneral, &fy.SMGBorder, &fy.SubsidyFactor, &fy.SubsidyThresholdMonthly,
 		&fy.FALegalCapUMAFactor, &fy.FALegalMaxPercentage, &fy.PantryVouchersUMACap,
-		&fy.USDMXNRate,
+		&fy.USDMXNRate, &fy.InfonavitInsuranceBimonthly,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -131,16 +423,16 @@ func (db *DB) GetActiveFiscalYear() (FiscalYear, bool, error) {
 	return fy, true, nil
 }
 
-// GetISRBrackets retrieves all ISR tax brackets for monthly calculations
+// GetISRBrackets retrieves the ISR tax brackets of every periodicity (Anexo 8 tables)
 func (db *DB) GetISRBrackets(fiscalYearID int) ([]ISRBracket, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 	defer cancel()
 
 	query := `
-		SELECT lower_limit, upper_limit, fixed_fee, surplus_percent
+		SELECT periodicity, lower_limit, upper_limit, fixed_fee, surplus_percent
 		FROM isr_brackets
-		WHERE fiscal_year_id = $1 AND periodicity = 'MONTHLY'
-		ORDER BY lower_limit ASC`
+		WHERE fiscal_year_id = $1
+		ORDER BY periodicity, lower_limit ASC`
 
 	rows, err := db.QueryContext(ctx, query, fiscalYearID)
 	if err != nil {
@@ -151,7 +443,7 @@ func (db *DB) GetISRBrackets(fiscalYearID int) ([]ISRBracket, error) {
 	var brackets []ISRBracket
 	for rows.Next() {
 		var b ISRBracket
-		err := rows.Scan(&b.LowerLimit, &b.UpperLimit, &b.FixedFee, &b.SurplusPercent)
+		err := rows.Scan(&b.Periodicity, &b.LowerLimit, &b.UpperLimit, &b.FixedFee, &b.SurplusPercent)
 		if err != nil {
 			return nil, err
 		}
@@ -167,7 +459,7 @@ func (db *DB) GetIMSSConcepts() ([]IMSSConcept, error) {
 	defer cancel()
 
 	query := `
-		SELECT concept_name, worker_percent, employer_percent, base_cap_in_umas, is_fixed_rate
+		SELECT concept_name, worker_percent, employer_percent, base_cap_in_umas, is_fixed_rate, base_type, excess_over_umas
 		FROM imss_concepts`
 
 	rows, err := db.QueryContext(ctx, query)
@@ -179,7 +471,7 @@ func (db *DB) GetIMSSConcepts() ([]IMSSConcept, error) {
 	var concepts []IMSSConcept
 	for rows.Next() {
 		var c IMSSConcept
-		err := rows.Scan(&c.ConceptName, &c.WorkerPercent, &c.EmployerPercent, &c.BaseCapInUMAs, &c.IsFixedRate)
+		err := rows.Scan(&c.ConceptName, &c.WorkerPercent, &c.EmployerPercent, &c.BaseCapInUMAs, &c.IsFixedRate, &c.BaseType, &c.ExcessOverUMAs)
 		if err != nil {
 			return nil, err
 		}
@@ -189,35 +481,38 @@ func (db *DB) GetIMSSConcepts() ([]IMSSConcept, error) {
 	return concepts, rows.Err()
 }
 
-// GetCesantiaBracket retrieves the Cesantía bracket for a given salary
-func (db *DB) GetCesantiaBracket(fiscalYearID int, salaryInUMAs float64) (CesantiaBracket, bool, error) {
+// GetCesantiaBrackets retrieves the employer Cesantía progressive brackets for a fiscal year
+func (db *DB) GetCesantiaBrackets(fiscalYearID int) ([]CesantiaBracket, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 	defer cancel()
 
 	query := `
-		SELECT lower_bound_uma, upper_bound_uma, employer_percent
+		SELECT lower_bound_uma, upper_bound_uma, employer_percent, social_quota_daily
 		FROM imss_employer_cesantia_brackets
-		WHERE fiscal_year_id = $1 
-		  AND $2 >= lower_bound_uma 
-		  AND $2 <= upper_bound_uma
-		LIMIT 1`
+		WHERE fiscal_year_id = $1
+		ORDER BY lower_bound_uma ASC`
 
-	var cb CesantiaBracket
-	err := db.QueryRowContext(ctx, query, fiscalYearID, salaryInUMAs).Scan(
-		&cb.LowerBoundUMA, &cb.UpperBoundUMA, &cb.EmployerPercent,
-	)
+	rows, err := db.QueryContext(ctx, query, fiscalYearID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return CesantiaBracket{}, false, nil
+		return nil, err
+	}
+	defer rows.Close()
+
+	var brackets []CesantiaBracket
+	for rows.Next() {
+		var cb CesantiaBracket
+		err := rows.Scan(&cb.LowerBoundUMA, &cb.UpperBoundUMA, &cb.EmployerPercent, &cb.SocialQuotaDaily)
+		if err != nil {
+			return nil, err
 		}
-		return CesantiaBracket{}, false, err
+		brackets = append(brackets, cb)
 	}
 
-	return cb, true, nil
+	return brackets, rows.Err()
 }
 
-// GetRESICOBracket retrieves the RESICO bracket for a given monthly income
-func (db *DB) GetRESICOBracket(fiscalYearID int, monthlyIncome float64) (RESICOBracket, bool, error) {
+// GetRESICOBrackets retrieves the monthly RESICO brackets for a fiscal year
+func (db *DB) GetRESICOBrackets(fiscalYearID int) ([]RESICOBracket, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 	defer cancel()
 
@@ -226,22 +521,146 @@ func (db *DB) GetRESICOBracket(fiscalYearID int, monthlyIncome float64) (RESICOB
 		FROM resico_brackets
 		WHERE fiscal_year_id = $1 
 		  AND periodicity = 'MONTHLY'
-		  AND $2 <= upper_limit
-		ORDER BY upper_limit ASC
-		LIMIT 1`
+		ORDER BY upper_limit ASC`
 
-	var rb RESICOBracket
-	err := db.QueryRowContext(ctx, query, fiscalYearID, monthlyIncome).Scan(
-		&rb.UpperLimit, &rb.ApplicableRate,
-	)
+	rows, err := db.QueryContext(ctx, query, fiscalYearID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return RESICOBracket{}, false, nil
+		return nil, err
+	}
+	defer rows.Close()
+
+	var brackets []RESICOBracket
+	for rows.Next() {
+		var rb RESICOBracket
+		err := rows.Scan(&rb.UpperLimit, &rb.ApplicableRate)
+		if err != nil {
+			return nil, err
+		}
+		brackets = append(brackets, rb)
+	}
+
+	return brackets, rows.Err()
+}
+
+// GetRetentionRules retrieves the ISR retention rules for a fiscal year
+func (db *DB) GetRetentionRules(fiscalYearID int) ([]RetentionRule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	query := `
+		SELECT regime_name, client_type, retention_rate
+		FROM fiscal_retention_rules
+		WHERE fiscal_year_id = $1
+		ORDER BY regime_name, client_type`
+
+	rows, err := db.QueryContext(ctx, query, fiscalYearID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []RetentionRule
+	for rows.Next() {
+		var rr RetentionRule
+		err := rows.Scan(&rr.RegimeName, &rr.ClientType, &rr.RetentionRate)
+		if err != nil {
+			return nil, err
 		}
-		return RESICOBracket{}, false, err
+		rules = append(rules, rr)
 	}
 
-	return rb, true, nil
+	return rules, rows.Err()
+}
+
+// GetSeniorityBenefits retrieves the statutory benefits per year of service for a fiscal year
+func (db *DB) GetSeniorityBenefits(fiscalYearID int) ([]SeniorityBenefit, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	query := `
+		SELECT years_of_service, vacation_days,
+		       COALESCE(prima_vacacional_percent, 0.25), COALESCE(aguinaldo_days, 15)
+		FROM seniority_benefits
+		WHERE fiscal_year_id = $1
+		ORDER BY years_of_service ASC`
+
+	rows, err := db.QueryContext(ctx, query, fiscalYearID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var benefits []SeniorityBenefit
+	for rows.Next() {
+		var sb SeniorityBenefit
+		err := rows.Scan(&sb.YearsOfService, &sb.VacationDays, &sb.PrimaVacacionalPercent, &sb.AguinaldoDays)
+		if err != nil {
+			return nil, err
+		}
+		benefits = append(benefits, sb)
+	}
+
+	return benefits, rows.Err()
+}
+
+// GetStatePayrollTaxes retrieves the Impuesto Sobre Nóminas rate of every state for a fiscal year
+func (db *DB) GetStatePayrollTaxes(fiscalYearID int) ([]StatePayrollTax, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	query := `
+		SELECT state_code, state_name, rate
+		FROM state_payroll_taxes
+		WHERE fiscal_year_id = $1
+		ORDER BY state_name ASC`
+
+	rows, err := db.QueryContext(ctx, query, fiscalYearID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var taxes []StatePayrollTax
+	for rows.Next() {
+		var st StatePayrollTax
+		err := rows.Scan(&st.StateCode, &st.StateName, &st.Rate)
+		if err != nil {
+			return nil, err
+		}
+		taxes = append(taxes, st)
+	}
+
+	return taxes, rows.Err()
+}
+
+// GetRiskClasses retrieves the prima media of every IMSS risk class for a fiscal year
+func (db *DB) GetRiskClasses(fiscalYearID int) ([]RiskClass, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	query := `
+		SELECT risk_class, description, premium
+		FROM imss_risk_classes
+		WHERE fiscal_year_id = $1
+		ORDER BY premium ASC`
+
+	rows, err := db.QueryContext(ctx, query, fiscalYearID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var classes []RiskClass
+	for rows.Next() {
+		var rc RiskClass
+		err := rows.Scan(&rc.Class, &rc.Description, &rc.Premium)
+		if err != nil {
+			return nil, err
+		}
+		classes = append(classes, rc)
+	}
+
+	return classes, rows.Err()
 }
 
 // UpdateExchangeRate updates the USD/MXN exchange rate for the active fiscal year
@@ -299,4 +718,3 @@ func (db *DB) UpdateUMA(annual, monthly, daily float64) error {
 
 	return nil
 }
-
@@ -1,6 +1,66 @@
+// Package equity projects the vesting and value of RSU grants. It is excluded
+// from the fixed-point money package on purpose: grants, exchange rates and
+// share prices are estimates kept in float64 USD and MXN, and a vest becomes a
+// money.Money amount only where the payroll taxes it.
 package equity
 
-import "math"
+import (
+	"fmt"
+	"math"
+)
+
+// MaxVestingYears is the longest vesting schedule accepted
+const MaxVestingYears = 10
+
+// defaultVestingYears is the vesting length when neither years nor percentages are given
+const defaultVestingYears = 4
+
+// VestFrequency is how often a grant vests within each vesting year
+type VestFrequency string
+
+const (
+	VestAnnual    VestFrequency = "ANNUAL"
+	VestQuarterly VestFrequency = "QUARTERLY"
+	VestMonthly   VestFrequency = "MONTHLY"
+)
+
+// Valid reports whether the frequency is one of the supported ones
+func (f VestFrequency) Valid() bool {
+	switch f {
+	case VestAnnual, VestQuarterly, VestMonthly:
+		return true
+	}
+	return false
+}
+
+// months is the number of months between two vest dates; annual when empty
+func (f VestFrequency) months() int {
+	switch f {
+	case VestQuarterly:
+		return 3
+	case VestMonthly:
+		return 1
+	default:
+		return 12
+	}
+}
+
+// Withholding is how the ISR on vested shares is paid
+type Withholding string
+
+const (
+	WithholdPayroll     Withholding = "PAYROLL"       // The employer withholds the ISR in cash through payroll
+	WithholdSellToCover Withholding = "SELL_TO_COVER" // Shares are sold at every vest to cover the withholding
+)
+
+// Valid reports whether the withholding model is one of the supported ones
+func (w Withholding) Valid() bool {
+	switch w {
+	case WithholdPayroll, WithholdSellToCover:
+		return true
+	}
+	return false
+}
 
 // YearlyEquity represents equity vesting for a single year
 type YearlyEquity struct {
@@ -11,16 +71,115 @@ type YearlyEquity struct {
 	TotalVested         float64         // Total vested this year (USD)
 	NewRefresherGranted float64         // Refresher granted THIS year (USD, starts vesting next year)
 	TotalVestedMXN      float64         // Total vested in MXN using exchange rate
+	ISRMXN              float64         // ISR attributable to this year's vest on top of the salary (MXN)
+	NetVestedMXN        float64         // Total vested after its ISR (MXN)
+	SoldToCoverMXN      float64         // Shares sold at vest to cover the withholding (MXN, sell-to-cover only)
+	SettlementMXN       float64         // ISR left to pay (positive) or refunded (negative) in the annual return
+}
+
+// IsRefund reports whether the sell-to-cover settlement ends with saldo a favor
+func (y YearlyEquity) IsRefund() bool {
+	return y.SettlementMXN < 0
+}
+
+// SettlementAmount returns the sell-to-cover settlement as a positive amount
+func (y YearlyEquity) SettlementAmount() float64 {
+	return math.Abs(y.SettlementMXN)
 }
 
 // EquityConfig holds the configuration for equity calculations
 type EquityConfig struct {
-	InitialGrantUSD float64
-	HasRefreshers   bool
-	RefresherMinUSD float64
-	RefresherMaxUSD float64
-	VestingYears    int     // Typically 4
-	ExchangeRate    float64 // From FiscalYear.USDMXNRate
+	InitialGrantUSD    float64
+	HasRefreshers      bool
+	RefresherMinUSD    float64
+	RefresherMaxUSD    float64
+	VestingYears       int           // Equal vesting over this many years when VestingPercents is empty; 4 when 0
+	VestingPercents    []float64     // Percent of each grant vesting in each year, e.g. 5, 15, 40, 40 (Amazon)
+	CliffMonths        int           // Nothing from the initial grant vests before the cliff; what accrued by then vests at it
+	VestFrequency      VestFrequency // ANNUAL when empty
+	Withholding        Withholding   // PAYROLL when empty
+	SellToCoverPercent float64       // Percent of every vest sold under sell-to-cover; the marginal ISR rate when 0
+	ExchangeRate       float64       // From FiscalYear.USDMXNRate
+}
+
+// Validate checks that the vesting schedule is complete and within range
+func (c EquityConfig) Validate() error {
+	if c.InitialGrantUSD < 0 || c.RefresherMinUSD < 0 || c.RefresherMaxUSD < 0 {
+		return fmt.Errorf("equity grants cannot be negative")
+	}
+	if c.VestingYears < 0 || c.VestingYears > MaxVestingYears || len(c.VestingPercents) > MaxVestingYears {
+		return fmt.Errorf("vesting must last between 1 and %d years", MaxVestingYears)
+	}
+	if len(c.VestingPercents) > 0 {
+		total := 0.0
+		for _, percent := range c.VestingPercents {
+			if percent < 0 {
+				return fmt.Errorf("vesting percentages cannot be negative")
+			}
+			total += percent
+		}
+		if math.Abs(total-100) > 0.01 {
+			return fmt.Errorf("vesting percentages must add up to 100, not %.2f", total)
+		}
+	}
+	if c.CliffMonths < 0 || c.CliffMonths > c.Years()*12 {
+		return fmt.Errorf("cliff must be between 0 and %d months", c.Years()*12)
+	}
+	if c.VestFrequency != "" && !c.VestFrequency.Valid() {
+		return fmt.Errorf("vest frequency must be one of ANNUAL, QUARTERLY, MONTHLY")
+	}
+	if c.Withholding != "" && !c.Withholding.Valid() {
+		return fmt.Errorf("withholding must be one of PAYROLL, SELL_TO_COVER")
+	}
+	if c.SellToCoverPercent < 0 || c.SellToCoverPercent > 100 {
+		return fmt.Errorf("sell-to-cover percent must be between 0 and 100")
+	}
+	return nil
+}
+
+// Years is the length of the vesting schedule of each grant
+func (c EquityConfig) Years() int {
+	if len(c.VestingPercents) > 0 {
+		return len(c.VestingPercents)
+	}
+	if c.VestingYears > 0 {
+		return c.VestingYears
+	}
+	return defaultVestingYears
+}
+
+// yearShares returns the fraction of a grant that vests in each year after it
+// is granted, index 0 being the first year. Within a year the percentage is
+// split evenly between the vest dates; a cliff holds back every vest date
+// before it and releases them together at the cliff.
+func (c EquityConfig) yearShares(cliffMonths int) []float64 {
+	years := c.Years()
+	period := c.VestFrequency.months()
+	vestsPerYear := 12 / period
+
+	// monthly[m] is the fraction vesting at the end of month m
+	monthly := make([]float64, years*12+1)
+	for year := 0; year < years; year++ {
+		fraction := 1.0 / float64(years)
+		if len(c.VestingPercents) > 0 {
+			fraction = c.VestingPercents[year] / 100
+		}
+		for vest := 1; vest <= vestsPerYear; vest++ {
+			monthly[year*12+vest*period] += fraction / float64(vestsPerYear)
+		}
+	}
+
+	cliff := min(cliffMonths, years*12)
+	for month := 1; month < cliff; month++ {
+		monthly[cliff] += monthly[month]
+		monthly[month] = 0
+	}
+
+	shares := make([]float64, years)
+	for month := 1; month <= years*12; month++ {
+		shares[(month-1)/12] += monthly[month]
+	}
+	return shares
 }
 
 // CalculateEquitySchedule calculates year-by-year equity vesting with refresher stacking
@@ -34,8 +193,10 @@ func CalculateEquitySchedule(config EquityConfig, years int) []YearlyEquity {
 		avgRefresher = (config.RefresherMinUSD + config.RefresherMaxUSD) / 2.0
 	}
 	
-	// Annual vesting percentage (typically 25% per year for 4 years)
-	annualVestPercent := 1.0 / float64(config.VestingYears)
+	// Share of each grant vesting in every year after it is granted. Refreshers
+	// follow the same schedule without the cliff of the initial grant.
+	initialShares := config.yearShares(config.CliffMonths)
+	refresherShares := config.yearShares(0)
 	
 	// Year 0: Join date - grant awarded but nothing vests yet
 	schedule[0] = YearlyEquity{
@@ -58,20 +219,20 @@ func CalculateEquitySchedule(config EquityConfig, years int) []YearlyEquity {
 			RefresherVested: make(map[int]float64),
 		}
 		
-		// 1. Vest from initial grant (only for first VestingYears years)
-		if year <= config.VestingYears {
-			yearEquity.InitialGrantVested = config.InitialGrantUSD * annualVestPercent
+		// 1. Vest from initial grant (only for the years of its schedule)
+		if year <= len(initialShares) {
+			yearEquity.InitialGrantVested = config.InitialGrantUSD * initialShares[year-1]
 		}
 		
 		// 2. Vest from refreshers (they vest starting 1 year after granted)
 		if config.HasRefreshers {
 			for grantYear, grantAmount := range refresherGrants {
 				vestingStartYear := grantYear + 1
-				vestingEndYear := grantYear + config.VestingYears
+				vestingEndYear := grantYear + len(refresherShares)
 				
 				// Check if this grant is currently vesting
 				if year >= vestingStartYear && year <= vestingEndYear {
-					vestedAmount := grantAmount * annualVestPercent
+					vestedAmount := grantAmount * refresherShares[year-vestingStartYear]
 					yearEquity.RefresherVested[grantYear] = vestedAmount
 				}
 			}
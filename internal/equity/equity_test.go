@@ -0,0 +1,88 @@
+package equity
+
+import (
+	"testing"
+
+	"github.com/jcroyoaun/totalcompmx/internal/assert"
+)
+
+func TestCalculateEquitySchedule(t *testing.T) {
+	vested := func(schedule []YearlyEquity) []float64 {
+		var amounts []float64
+		for _, year := range schedule[1:] {
+			amounts = append(amounts, year.InitialGrantVested)
+		}
+		return amounts
+	}
+
+	tests := []struct {
+		name     string
+		config   EquityConfig
+		years    int
+		expected []float64
+	}{
+		{"Vests equally over 4 years by default", EquityConfig{InitialGrantUSD: 100000}, 5, []float64{25000, 25000, 25000, 25000, 0}},
+		{"Vests equally over the given years", EquityConfig{InitialGrantUSD: 90000, VestingYears: 3}, 4, []float64{30000, 30000, 30000, 0}},
+		{"Back-loads an Amazon grant", EquityConfig{InitialGrantUSD: 100000, VestingPercents: []float64{5, 15, 40, 40}}, 4, []float64{5000, 15000, 40000, 40000}},
+		{"Front-loads a Google grant", EquityConfig{InitialGrantUSD: 100000, VestingPercents: []float64{33, 33, 22, 12}, VestFrequency: VestMonthly}, 4, []float64{33000, 33000, 22000, 12000}},
+		{"Releases a 1-year cliff then vests monthly", EquityConfig{InitialGrantUSD: 48000, CliffMonths: 12, VestFrequency: VestMonthly}, 4, []float64{12000, 12000, 12000, 12000}},
+		{"Moves what accrued into the year of a long cliff", EquityConfig{InitialGrantUSD: 48000, CliffMonths: 18, VestFrequency: VestMonthly}, 4, []float64{0, 24000, 12000, 12000}},
+		{"Moves an annual vest past a long cliff", EquityConfig{InitialGrantUSD: 40000, CliffMonths: 15, VestFrequency: VestQuarterly}, 4, []float64{0, 20000, 10000, 10000}},
+		{"Ignores a cliff that falls in the first vesting year", EquityConfig{InitialGrantUSD: 40000, CliffMonths: 6}, 4, []float64{10000, 10000, 10000, 10000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, vested(CalculateEquitySchedule(tt.config, tt.years)), tt.expected)
+		})
+	}
+
+	t.Run("Vests refreshers on the schedule without the cliff", func(t *testing.T) {
+		config := EquityConfig{
+			InitialGrantUSD: 100000,
+			HasRefreshers:   true,
+			RefresherMinUSD: 8000,
+			RefresherMaxUSD: 12000,
+			VestingPercents: []float64{5, 15, 40, 40},
+			CliffMonths:     24,
+			ExchangeRate:    20,
+		}
+		schedule := CalculateEquitySchedule(config, 3)
+		assert.Equal(t, schedule[1].TotalVested, 0.0)
+		assert.Equal(t, schedule[2].InitialGrantVested, 20000.0)
+		assert.Equal(t, schedule[2].RefresherVested, map[int]float64{1: 500})
+		assert.Equal(t, schedule[3].RefresherVested, map[int]float64{1: 1500, 2: 500})
+		assert.Equal(t, schedule[3].TotalVested, 42000.0)
+		assert.Equal(t, schedule[3].TotalVestedMXN, 840000.0)
+	})
+}
+
+func TestEquityConfigValidate(t *testing.T) {
+	t.Run("Accepts complete schedules", func(t *testing.T) {
+		configs := []EquityConfig{
+			{InitialGrantUSD: 10000},
+			{InitialGrantUSD: 10000, VestingPercents: []float64{33.33, 33.33, 33.34}, CliffMonths: 36, VestFrequency: VestQuarterly},
+			{InitialGrantUSD: 10000, Withholding: WithholdSellToCover, SellToCoverPercent: 35},
+		}
+		for _, config := range configs {
+			assert.Nil(t, config.Validate())
+		}
+	})
+
+	t.Run("Rejects invalid schedules", func(t *testing.T) {
+		configs := []EquityConfig{
+			{InitialGrantUSD: -1},
+			{VestingYears: MaxVestingYears + 1},
+			{VestingPercents: []float64{25, 25, 25}},
+			{VestingPercents: []float64{-10, 60, 50}},
+			{CliffMonths: 49},
+			{CliffMonths: -1},
+			{VestFrequency: "WEEKLY"},
+			{Withholding: "CASH"},
+			{Withholding: WithholdSellToCover, SellToCoverPercent: 101},
+		}
+		for _, config := range configs {
+			assert.NotNil(t, config.Validate())
+		}
+	})
+}
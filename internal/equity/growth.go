@@ -0,0 +1,159 @@
+package equity
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+)
+
+// OutlookYears is the horizon of the share price scenarios
+const OutlookYears = 4
+
+// Simulations is the number of Monte Carlo price paths
+const Simulations = 10000
+
+// MaxVolatility is the highest yearly volatility accepted, in percent
+const MaxVolatility = 200
+
+// Yearly share price growth accepted in each case, in percent. A price cannot
+// fall to zero, and compounding more than tripling it every year over the
+// outlook stops being a forecast.
+const (
+	MinPriceGrowth = -99
+	MaxPriceGrowth = 200
+)
+
+// PriceGrowth holds the expected yearly share price growth of each case, in percent
+type PriceGrowth struct {
+	Bear       float64
+	Base       float64
+	Bull       float64
+	Volatility float64 // Yearly volatility around the base case; 0 skips the Monte Carlo simulation
+}
+
+// Validate checks that the growth rates and the volatility are within range
+func (g PriceGrowth) Validate() error {
+	for _, growth := range []float64{g.Bear, g.Base, g.Bull} {
+		if !(growth >= MinPriceGrowth && growth <= MaxPriceGrowth) {
+			return fmt.Errorf("share price growth must be between %d and %d", MinPriceGrowth, MaxPriceGrowth)
+		}
+	}
+	if !(g.Volatility >= 0 && g.Volatility <= MaxVolatility) {
+		return fmt.Errorf("volatility must be between 0 and %d", MaxVolatility)
+	}
+	return nil
+}
+
+// Scenario is the value of the vests when the share price grows at a fixed rate
+type Scenario struct {
+	Growth    float64   // Yearly share price growth in percent
+	VestedMXN []float64 // Value at vest of each year of the outlook (MXN)
+	TotalMXN  float64   // Equity vested over the outlook (MXN)
+}
+
+// ValueOutlook is the 4-year equity under the bear, base and bull cases and,
+// with a volatility, the percentiles of a Monte Carlo simulation
+type ValueOutlook struct {
+	Bear        Scenario
+	Base        Scenario
+	Bull        Scenario
+	FlatMXN     float64 // Equity vested over the outlook at the grant price (MXN)
+	Volatility  float64 // Yearly volatility in percent
+	Simulations int     // Monte Carlo paths; 0 without volatility
+	P10         float64 // Percentiles of the equity vested over the outlook (MXN)
+	P50         float64
+	P90         float64
+}
+
+// Scenarios returns the bear, base and bull cases in that order
+func (o ValueOutlook) Scenarios() []Scenario {
+	return []Scenario{o.Bear, o.Base, o.Bull}
+}
+
+// ProjectValue values the vesting schedule at share prices that grow over
+// time. Grants are converted to shares at the price of the day they are
+// awarded, so a vest is worth its USD amount times the growth of the price
+// since its grant. The simulation draws yearly log-normal returns whose mean
+// is the base case; the seed is fixed so the same offer always gets the same
+// percentiles.
+func ProjectValue(config EquityConfig, growth PriceGrowth) (ValueOutlook, error) {
+	if err := growth.Validate(); err != nil {
+		return ValueOutlook{}, err
+	}
+
+	schedule := CalculateEquitySchedule(config, OutlookYears)
+	outlook := ValueOutlook{
+		Bear:       scenario(config, schedule, growth.Bear),
+		Base:       scenario(config, schedule, growth.Base),
+		Bull:       scenario(config, schedule, growth.Bull),
+		FlatMXN:    scenario(config, schedule, 0).TotalMXN,
+		Volatility: growth.Volatility,
+	}
+	if growth.Volatility == 0 {
+		return outlook, nil
+	}
+
+	sigma := growth.Volatility / 100
+	drift := math.Log(1+growth.Base/100) - sigma*sigma/2
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	totals := make([]float64, Simulations)
+	prices := make([]float64, OutlookYears+1)
+	for i := range totals {
+		prices[0] = 1
+		for year := 1; year <= OutlookYears; year++ {
+			prices[year] = prices[year-1] * math.Exp(drift+sigma*rng.NormFloat64())
+		}
+		for _, year := range schedule[1:] {
+			totals[i] += vestValue(year, prices)
+		}
+		totals[i] *= config.ExchangeRate
+	}
+	slices.Sort(totals)
+
+	outlook.Simulations = Simulations
+	outlook.P10 = round(percentile(totals, 10))
+	outlook.P50 = round(percentile(totals, 50))
+	outlook.P90 = round(percentile(totals, 90))
+
+	return outlook, nil
+}
+
+// scenario values every year of the schedule with the price growing at a fixed rate
+func scenario(config EquityConfig, schedule []YearlyEquity, growth float64) Scenario {
+	prices := make([]float64, len(schedule))
+	for year := range prices {
+		prices[year] = math.Pow(1+growth/100, float64(year))
+	}
+
+	s := Scenario{Growth: growth}
+	for _, year := range schedule[1:] {
+		vested := round(vestValue(year, prices) * config.ExchangeRate)
+		s.VestedMXN = append(s.VestedMXN, vested)
+		s.TotalMXN += vested
+	}
+	s.TotalMXN = round(s.TotalMXN)
+	return s
+}
+
+// vestValue is the USD value of a year's vests where prices[y] is the share
+// price at the end of year y relative to the price at joining
+func vestValue(year YearlyEquity, prices []float64) float64 {
+	value := year.InitialGrantVested * prices[year.Year]
+	for grantYear := 1; grantYear < year.Year; grantYear++ {
+		value += year.RefresherVested[grantYear] * prices[year.Year] / prices[grantYear]
+	}
+	return value
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank-1, 0)]
+}
+
+// round rounds to 2 decimal places
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
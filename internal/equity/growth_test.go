@@ -0,0 +1,71 @@
+package equity
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jcroyoaun/totalcompmx/internal/assert"
+)
+
+func TestProjectValue(t *testing.T) {
+	config := EquityConfig{InitialGrantUSD: 40000, ExchangeRate: 20}
+
+	t.Run("Grows every vest with the share price", func(t *testing.T) {
+		outlook, err := ProjectValue(config, PriceGrowth{Bear: -10, Base: 10, Bull: 30})
+		assert.Nil(t, err)
+		assert.Equal(t, outlook.FlatMXN, 800000.0)
+		// 10,000 USD a year at 1.1, 1.21, 1.331 and 1.4641 times the grant price
+		assert.Equal(t, outlook.Base.VestedMXN, []float64{220000, 242000, 266200, 292820})
+		assert.Equal(t, outlook.Base.TotalMXN, 1021020.0)
+		assert.Equal(t, outlook.Bear.TotalMXN, 619020.0)
+		assert.True(t, outlook.Bull.TotalMXN > outlook.Base.TotalMXN)
+		assert.Equal(t, outlook.Simulations, 0)
+		assert.Equal(t, outlook.P50, 0.0)
+	})
+
+	t.Run("Prices refreshers from their own grant", func(t *testing.T) {
+		withRefreshers := config
+		withRefreshers.HasRefreshers = true
+		withRefreshers.RefresherMinUSD = 4000
+		withRefreshers.RefresherMaxUSD = 4000
+		outlook, err := ProjectValue(withRefreshers, PriceGrowth{Base: 10})
+		assert.Nil(t, err)
+		// 10,000 x 1.21 of the initial grant and 1,000 x 1.1 of the first refresher
+		assert.Equal(t, outlook.Base.VestedMXN[1], 264000.0)
+	})
+
+	t.Run("Simulates percentiles around the base case", func(t *testing.T) {
+		growth := PriceGrowth{Bear: -20, Base: 8, Bull: 30, Volatility: 35}
+		outlook, err := ProjectValue(config, growth)
+		assert.Nil(t, err)
+		assert.Equal(t, outlook.Simulations, Simulations)
+		assert.True(t, outlook.P10 < outlook.P50)
+		assert.True(t, outlook.P50 < outlook.P90)
+		assert.True(t, outlook.P10 < outlook.Base.TotalMXN && outlook.Base.TotalMXN < outlook.P90)
+
+		// The seed is fixed so an offer always gets the same percentiles
+		again, err := ProjectValue(config, growth)
+		assert.Nil(t, err)
+		assert.Equal(t, again.P50, outlook.P50)
+	})
+
+	t.Run("Compounds the highest growth over the outlook", func(t *testing.T) {
+		growth := PriceGrowth{Bear: MinPriceGrowth, Base: MaxPriceGrowth, Bull: MaxPriceGrowth, Volatility: MaxVolatility}
+		outlook, err := ProjectValue(config, growth)
+		assert.Nil(t, err)
+		assert.True(t, outlook.Bear.TotalMXN > 0)
+		assert.True(t, outlook.Bull.TotalMXN > outlook.FlatMXN)
+		assert.False(t, math.IsInf(outlook.P90, 0) || math.IsNaN(outlook.P90))
+	})
+
+	t.Run("Rejects invalid growth", func(t *testing.T) {
+		invalid := []PriceGrowth{
+			{Bear: -100}, {Bull: -150}, {Bull: MaxPriceGrowth + 1}, {Base: math.Inf(1)}, {Bear: math.NaN()},
+			{Volatility: -1}, {Volatility: MaxVolatility + 1}, {Volatility: math.NaN()},
+		}
+		for _, growth := range invalid {
+			_, err := ProjectValue(config, growth)
+			assert.NotNil(t, err)
+		}
+	})
+}
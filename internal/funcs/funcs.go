@@ -39,6 +39,8 @@ var TemplateFuncs = template.FuncMap{
 	"formatInt":   formatInt,
 	"formatFloat": formatFloat,
 
+	"formatPercent": formatPercent,
+
 	"yesNo": yesNo,
 
 	"urlSetParam": urlSetParam,
@@ -157,9 +159,24 @@ func formatInt(i any) (string, error) {
 	return printer.Sprintf("%d", n), nil
 }
 
-func formatFloat(f float64, dp int) string {
+func formatFloat(f any, dp int) (string, error) {
+	n, err := toFloat64(f)
+	if err != nil {
+		return "", err
+	}
+
 	format := "%." + strconv.Itoa(dp) + "f"
-	return printer.Sprintf(format, f)
+	return printer.Sprintf(format, n), nil
+}
+
+func formatPercent(f any, dp int) (string, error) {
+	n, err := toFloat64(f)
+	if err != nil {
+		return "", err
+	}
+
+	s, err := formatFloat(n*100, dp)
+	return s + "%", err
 }
 
 func yesNo(b bool) string {
@@ -235,3 +252,21 @@ func toInt64(i any) (int64, error) {
 
 	return 0, fmt.Errorf("unable to convert type %T to int", i)
 }
+
+func toFloat64(f any) (float64, error) {
+	switch v := f.(type) {
+	case interface{ Float64() float64 }:
+		return v.Float64(), nil
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	}
+
+	n, err := toInt64(f)
+	if err != nil {
+		return 0, fmt.Errorf("unable to convert type %T to float", f)
+	}
+
+	return float64(n), nil
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/jcroyoaun/totalcompmx/internal/assert"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
 )
 
 func TestFormatTime(t *testing.T) {
@@ -216,7 +217,7 @@ func TestFormatInt(t *testing.T) {
 func TestFormatFloat(t *testing.T) {
 	tests := []struct {
 		name     string
-		input    float64
+		input    any
 		dp       int
 		expected string
 	}{
@@ -226,11 +227,36 @@ func TestFormatFloat(t *testing.T) {
 		{"Negative number", -123.456, 1, "-123.5"},
 		{"Zero value", 0.0, 2, "0.00"},
 		{"Many decimal places", 3.14159, 4, "3.1416"},
+		{"Money amount", money.MustParse("1234.56"), 2, "1,234.56"},
+		{"Integer", 42, 1, "42.0"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := formatFloat(tt.input, tt.dp)
+			result, err := formatFloat(tt.input, tt.dp)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestFormatPercent(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    any
+		dp       int
+		expected string
+	}{
+		{"Whole percent", 0.04, 0, "4%"},
+		{"Fractional percent", 0.0425, 2, "4.25%"},
+		{"Zero value", 0.0, 1, "0.0%"},
+		{"Rate", money.MustParseRate("0.0192"), 2, "1.92%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := formatPercent(tt.input, tt.dp)
+			assert.Nil(t, err)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
@@ -0,0 +1,385 @@
+// Package money holds exact peso amounts and rates for the payroll engine.
+// Amounts are whole centavos and rates are fixed-point decimals, so sums are
+// exact and every product is rounded to the centavo once, with the SAT rule.
+// Equity projections are estimates and stay in float64 in package equity.
+package money
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount of Mexican pesos in centavos
+type Money int64
+
+// Rate is a decimal fraction or factor with ten decimal places, e.g. a tax
+// rate (0.0054355), a factor (1.0452) or days per month (30.4)
+type Rate int64
+
+const (
+	centavosPerPeso = 100
+	rateDecimals    = 10
+	rateScale       = 10_000_000_000
+)
+
+// Centavo is the smallest amount, the step of every rounding
+const Centavo Money = 1
+
+// One is the rate of a whole, e.g. the base of 1 + rate
+const One Rate = rateScale
+
+// MaxAmount is the largest amount accepted from user input, a hundred million
+// pesos. It leaves room for yearly totals, projections and products with rates
+// without overflowing the centavos.
+const MaxAmount Money = 100_000_000 * centavosPerPeso
+
+// Pesos returns an amount of whole pesos
+func Pesos(pesos int64) Money {
+	return Money(pesos * centavosPerPeso)
+}
+
+// FromFloat converts a float amount, e.g. from a form, rounding to the centavo.
+// Check user input with ValidAmounts first.
+func FromFloat(amount float64) Money {
+	return Money(math.Round(amount * centavosPerPeso))
+}
+
+// ValidAmounts reports whether every float amount is finite and within
+// MaxAmount either way, so FromFloat converts it and the payroll can use it
+func ValidAmounts(amounts ...float64) bool {
+	for _, amount := range amounts {
+		if !(math.Abs(amount) <= MaxAmount.Float64()) {
+			return false
+		}
+	}
+	return true
+}
+
+// Parse reads a decimal amount such as "1234.56" or a Postgres NUMERIC.
+// Digits past the centavo are rounded with the SAT rule.
+func Parse(s string) (Money, error) {
+	units, err := parseDecimal(s, 2)
+	if err != nil {
+		return 0, fmt.Errorf("money: invalid amount %q", s)
+	}
+	return Money(units), nil
+}
+
+// MustParse is Parse for constants; it panics on an invalid amount
+func MustParse(s string) Money {
+	m, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Float64 returns the amount in pesos as a float, for display and statistics only
+func (m Money) Float64() float64 {
+	return float64(m) / centavosPerPeso
+}
+
+// String formats the amount with two decimals and no separators, e.g. "-1234.56"
+func (m Money) String() string {
+	sign := ""
+	units := int64(m)
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, units/centavosPerPeso, units%centavosPerPeso)
+}
+
+// Mul multiplies the amount by a rate, rounding to the centavo
+func (m Money) Mul(r Rate) Money {
+	return Money(mulDiv(int64(m), int64(r), rateScale))
+}
+
+// Div divides the amount into n equal parts, rounding to the centavo
+func (m Money) Div(n int) Money {
+	return Money(mulDiv(int64(m), 1, int64(n)))
+}
+
+// DivRate divides the amount by a rate, e.g. a monthly amount by 30.4 days,
+// rounding to the centavo
+func (m Money) DivRate(r Rate) Money {
+	return Money(mulDiv(int64(m), rateScale, int64(r)))
+}
+
+// Ratio returns the amount as a fraction of whole, e.g. a salary in UMAs. It is
+// zero when whole is zero.
+func (m Money) Ratio(whole Money) Rate {
+	if whole == 0 {
+		return 0
+	}
+	return Rate(mulDiv(int64(m), rateScale, int64(whole)))
+}
+
+// RoundPesos adjusts an amount to pay to the SAT to whole pesos (CFF Art. 20):
+// 1 to 50 centavos go down and 51 to 99 centavos go up
+func (m Money) RoundPesos() Money {
+	sign := Money(1)
+	if m < 0 {
+		sign, m = -1, -m
+	}
+	pesos, centavos := m/centavosPerPeso, m%centavosPerPeso
+	if centavos > 50 {
+		pesos++
+	}
+	return sign * pesos * centavosPerPeso
+}
+
+// MarshalJSON writes the amount as a JSON number with two decimals
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON reads a JSON number or numeric string; null leaves the amount
+// unchanged
+func (m *Money) UnmarshalJSON(data []byte) error {
+	units, ok, err := unmarshalDecimal(data, 2)
+	if err != nil {
+		return fmt.Errorf("money: invalid amount %s", data)
+	}
+	if ok {
+		*m = Money(units)
+	}
+	return nil
+}
+
+// Scan reads a NUMERIC column without going through a float
+func (m *Money) Scan(src any) error {
+	units, err := scanDecimal(src, 2)
+	if err != nil {
+		return err
+	}
+	*m = Money(units)
+	return nil
+}
+
+// Value writes the amount as a decimal string
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// RateFromFloat converts a float rate, e.g. a percentage from a form divided
+// by 100, to the nearest representable rate
+func RateFromFloat(rate float64) Rate {
+	return Rate(math.Round(rate * rateScale))
+}
+
+// Percent converts a percentage such as 25 (for 25%) to a rate
+func Percent(percent float64) Rate {
+	return RateFromFloat(percent / 100)
+}
+
+// Int returns a whole number as a rate, e.g. a count of days or UMAs
+func Int(n int) Rate {
+	return Rate(int64(n) * rateScale)
+}
+
+// ParseRate reads a decimal rate such as "0.0054355" or a Postgres NUMERIC
+func ParseRate(s string) (Rate, error) {
+	units, err := parseDecimal(s, rateDecimals)
+	if err != nil {
+		return 0, fmt.Errorf("money: invalid rate %q", s)
+	}
+	return Rate(units), nil
+}
+
+// MustParseRate is ParseRate for constants; it panics on an invalid rate
+func MustParseRate(s string) Rate {
+	r, err := ParseRate(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Float64 returns the rate as a float, for display and statistics only
+func (r Rate) Float64() float64 {
+	return float64(r) / rateScale
+}
+
+// String formats the rate without trailing zeros, e.g. "0.0054355"
+func (r Rate) String() string {
+	sign := ""
+	units := int64(r)
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+	fraction := strings.TrimRight(fmt.Sprintf("%010d", units%rateScale), "0")
+	if fraction == "" {
+		return fmt.Sprintf("%s%d", sign, units/rateScale)
+	}
+	return fmt.Sprintf("%s%d.%s", sign, units/rateScale, fraction)
+}
+
+// Mul multiplies two rates, rounding to the tenth decimal
+func (r Rate) Mul(o Rate) Rate {
+	return Rate(mulDiv(int64(r), int64(o), rateScale))
+}
+
+// Div divides a rate by another, rounding to the tenth decimal. It is zero
+// when o is zero.
+func (r Rate) Div(o Rate) Rate {
+	if o == 0 {
+		return 0
+	}
+	return Rate(mulDiv(int64(r), rateScale, int64(o)))
+}
+
+// Round rounds the rate to the given decimals with the SAT rule, e.g. the four
+// decimals of the IMSS integration factor
+func (r Rate) Round(decimals int) Rate {
+	step := int64(math.Pow10(rateDecimals - decimals))
+	return Rate(mulDiv(int64(r), 1, step) * step)
+}
+
+// MarshalJSON writes the rate as a JSON number
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalJSON reads a JSON number or numeric string; null leaves the rate
+// unchanged
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	units, ok, err := unmarshalDecimal(data, rateDecimals)
+	if err != nil {
+		return fmt.Errorf("money: invalid rate %s", data)
+	}
+	if ok {
+		*r = Rate(units)
+	}
+	return nil
+}
+
+// Scan reads a NUMERIC column without going through a float
+func (r *Rate) Scan(src any) error {
+	units, err := scanDecimal(src, rateDecimals)
+	if err != nil {
+		return err
+	}
+	*r = Rate(units)
+	return nil
+}
+
+// Value writes the rate as a decimal string
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+// scanDecimal reads a database value into units of 10^-decimals
+func scanDecimal(src any, decimals int) (int64, error) {
+	switch v := src.(type) {
+	case []byte:
+		return parseDecimal(string(v), decimals)
+	case string:
+		return parseDecimal(v, decimals)
+	case int64:
+		return parseDecimal(strconv.FormatInt(v, 10), decimals)
+	case float64:
+		return parseDecimal(strconv.FormatFloat(v, 'f', -1, 64), decimals)
+	case nil:
+		return 0, nil
+	}
+	return 0, fmt.Errorf("money: cannot scan %T", src)
+}
+
+// unmarshalDecimal reads a JSON number or numeric string into units of
+// 10^-decimals. Exponents such as 1e5 are expanded to their shortest decimal
+// first. ok is false for null.
+func unmarshalDecimal(data []byte, decimals int) (units int64, ok bool, err error) {
+	if string(data) == "null" {
+		return 0, false, nil
+	}
+
+	s := strings.Trim(string(data), `"`)
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+			return 0, false, fmt.Errorf("invalid number")
+		}
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	units, err = parseDecimal(s, decimals)
+	return units, err == nil, err
+}
+
+// parseDecimal reads a plain decimal number into units of 10^-decimals,
+// rounding any extra digits half away from zero (the SAT rounding rule)
+func parseDecimal(s string, decimals int) (int64, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" && fraction == "" {
+		return 0, fmt.Errorf("empty number")
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	roundUp := false
+	if len(fraction) > decimals {
+		for _, c := range fraction[decimals:] {
+			if c < '0' || c > '9' {
+				return 0, fmt.Errorf("invalid digit %q", c)
+			}
+		}
+		roundUp = fraction[decimals] >= '5'
+		fraction = fraction[:decimals]
+	}
+	fraction += strings.Repeat("0", decimals-len(fraction))
+
+	units, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil || strings.ContainsAny(whole+fraction, "+-") {
+		return 0, fmt.Errorf("invalid number")
+	}
+	if roundUp {
+		units++
+	}
+	if negative {
+		units = -units
+	}
+	return units, nil
+}
+
+// mulDiv returns a*b/c rounded half away from zero, the SAT rounding rule,
+// without overflowing the intermediate product
+func mulDiv(a, b, c int64) int64 {
+	if c == 0 {
+		panic("money: division by zero")
+	}
+	negative := (a < 0) != (b < 0) != (c < 0)
+
+	hi, lo := bits.Mul64(abs(a), abs(b))
+	divisor := abs(c)
+	if hi >= divisor {
+		panic("money: overflow")
+	}
+	quotient, remainder := bits.Div64(hi, lo, divisor)
+	if remainder >= divisor-remainder {
+		quotient++
+	}
+
+	if negative {
+		return -int64(quotient)
+	}
+	return int64(quotient)
+}
+
+// abs returns the magnitude of n as an unsigned integer
+func abs(n int64) uint64 {
+	if n < 0 {
+		return uint64(-n)
+	}
+	return uint64(n)
+}
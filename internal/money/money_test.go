@@ -0,0 +1,239 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/jcroyoaun/totalcompmx/internal/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Money
+	}{
+		{"Pesos and centavos", "1234.56", 123456},
+		{"Whole pesos", "30000", 3000000},
+		{"One decimal", "0.5", 50},
+		{"Negative", "-1.05", -105},
+		{"Rounds half a centavo up", "0.005", 1},
+		{"Rounds away from zero", "-0.005", -1},
+		{"Truncates below half a centavo", "113.1449", 11314},
+		{"Postgres NUMERIC", "999999999.99", 99999999999},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Parse(tt.input)
+			assert.Nil(t, err)
+			assert.Equal(t, m, tt.expected)
+		})
+	}
+
+	t.Run("Rejects invalid amounts", func(t *testing.T) {
+		for _, input := range []string{"", ".", "abc", "1.2.3", "1,000", "--1", "1.0x"} {
+			_, err := Parse(input)
+			assert.NotNil(t, err)
+		}
+	})
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		amount   Money
+		expected string
+	}{
+		{123456, "1234.56"},
+		{5, "0.05"},
+		{-105, "-1.05"},
+		{0, "0.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.amount.String(), tt.expected)
+		})
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	t.Run("Mul rounds half a centavo away from zero", func(t *testing.T) {
+		assert.Equal(t, MustParse("0.10").Mul(MustParseRate("0.05")), Money(1))
+		assert.Equal(t, MustParse("-0.10").Mul(MustParseRate("0.05")), Money(-1))
+		assert.Equal(t, MustParse("0.10").Mul(MustParseRate("0.04")), Money(0))
+	})
+
+	t.Run("Does not drift like float64", func(t *testing.T) {
+		// 0.1 + 0.2 is 0.30000000000000004 in float64
+		assert.Equal(t, MustParse("0.1")+MustParse("0.2"), MustParse("0.3"))
+		var sum Money
+		for range 10 {
+			sum += MustParse("0.1")
+		}
+		assert.Equal(t, sum, Pesos(1))
+	})
+
+	t.Run("Divides a monthly amount into days", func(t *testing.T) {
+		assert.Equal(t, Pesos(20000).DivRate(MustParseRate("30.4")), MustParse("657.89"))
+		assert.Equal(t, Pesos(100).Div(3), MustParse("33.33"))
+		assert.Equal(t, Pesos(200).Div(3), MustParse("66.67"))
+	})
+
+	t.Run("Ratio is zero for a zero whole", func(t *testing.T) {
+		assert.Equal(t, Pesos(1500).Ratio(Pesos(30000)), MustParseRate("0.05"))
+		assert.Equal(t, Pesos(1500).Ratio(0), Rate(0))
+	})
+
+	t.Run("Survives products beyond 64 bits", func(t *testing.T) {
+		// 349,999,999.9965 rounds to the next peso
+		assert.Equal(t, MustParse("999999999.99").Mul(MustParseRate("0.35")), Pesos(350000000))
+	})
+
+	t.Run("Converts from float", func(t *testing.T) {
+		assert.Equal(t, FromFloat(0.1+0.2), MustParse("0.30"))
+		assert.Equal(t, FromFloat(-12.345), MustParse("-12.35"))
+	})
+
+	t.Run("Validates float amounts from user input", func(t *testing.T) {
+		assert.True(t, ValidAmounts(30000, -30000, MaxAmount.Float64()))
+		assert.True(t, ValidAmounts())
+		assert.False(t, ValidAmounts(30000, MaxAmount.Float64()+1))
+		assert.False(t, ValidAmounts(1e300))
+		assert.False(t, ValidAmounts(math.Inf(-1)))
+		assert.False(t, ValidAmounts(math.NaN()))
+	})
+}
+
+func TestRoundPesos(t *testing.T) {
+	tests := []struct {
+		amount   string
+		expected string
+	}{
+		{"1066.50", "1066"},
+		{"1066.51", "1067"},
+		{"1066.01", "1066"},
+		{"1066.99", "1067"},
+		{"1066.00", "1066"},
+		{"-10.51", "-11"},
+		{"-10.50", "-10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.amount, func(t *testing.T) {
+			assert.Equal(t, MustParse(tt.amount).RoundPesos(), MustParse(tt.expected))
+		})
+	}
+}
+
+func TestRate(t *testing.T) {
+	t.Run("Parses and formats without trailing zeros", func(t *testing.T) {
+		assert.Equal(t, MustParseRate("0.0054355").String(), "0.0054355")
+		assert.Equal(t, MustParseRate("1.0500").String(), "1.05")
+		assert.Equal(t, MustParseRate("2").String(), "2")
+		assert.Equal(t, MustParseRate("-0.5").String(), "-0.5")
+	})
+
+	t.Run("Converts percentages", func(t *testing.T) {
+		assert.Equal(t, Percent(25), MustParseRate("0.25"))
+		assert.Equal(t, Percent(1.2), MustParseRate("0.012"))
+		assert.Equal(t, Int(12), MustParseRate("12"))
+	})
+
+	t.Run("Multiplies and divides", func(t *testing.T) {
+		assert.Equal(t, MustParseRate("1.04").Mul(MustParseRate("1.04")), MustParseRate("1.0816"))
+		assert.Equal(t, MustParseRate("30.4").Div(Int(7)), MustParseRate("4.3428571429"))
+		assert.Equal(t, One.Div(0), Rate(0))
+	})
+
+	t.Run("Rounds to a number of decimals", func(t *testing.T) {
+		assert.Equal(t, MustParseRate("1.04931506").Round(4), MustParseRate("1.0493"))
+		assert.Equal(t, MustParseRate("1.00005").Round(4), MustParseRate("1.0001"))
+		assert.Equal(t, MustParseRate("1.5015").Round(3), MustParseRate("1.502"))
+	})
+}
+
+func TestJSON(t *testing.T) {
+	t.Run("Marshals as numbers", func(t *testing.T) {
+		data, err := json.Marshal(struct {
+			Amount Money `json:"amount"`
+			Rate   Rate  `json:"rate"`
+		}{MustParse("1234.5"), MustParseRate("0.16")})
+		assert.Nil(t, err)
+		assert.Equal(t, string(data), `{"amount":1234.50,"rate":0.16}`)
+	})
+
+	t.Run("Unmarshals numbers and strings", func(t *testing.T) {
+		var input struct {
+			Amount Money `json:"amount"`
+			Rate   Rate  `json:"rate"`
+		}
+		err := json.Unmarshal([]byte(`{"amount":"30000.5","rate":0.0192}`), &input)
+		assert.Nil(t, err)
+		assert.Equal(t, input.Amount, MustParse("30000.50"))
+		assert.Equal(t, input.Rate, MustParseRate("0.0192"))
+	})
+
+	t.Run("Unmarshals exponents", func(t *testing.T) {
+		var input struct {
+			Amount Money `json:"amount"`
+			Rate   Rate  `json:"rate"`
+		}
+		err := json.Unmarshal([]byte(`{"amount":1e5,"rate":1.92E-2}`), &input)
+		assert.Nil(t, err)
+		assert.Equal(t, input.Amount, Pesos(100000))
+		assert.Equal(t, input.Rate, MustParseRate("0.0192"))
+	})
+
+	t.Run("Leaves null values unchanged", func(t *testing.T) {
+		input := struct {
+			Amount Money `json:"amount"`
+			Rate   Rate  `json:"rate"`
+		}{Pesos(1), One}
+		err := json.Unmarshal([]byte(`{"amount":null,"rate":null}`), &input)
+		assert.Nil(t, err)
+		assert.Equal(t, input.Amount, Pesos(1))
+		assert.Equal(t, input.Rate, One)
+	})
+
+	t.Run("Rejects invalid numbers", func(t *testing.T) {
+		var amount Money
+		assert.NotNil(t, json.Unmarshal([]byte(`"abc"`), &amount))
+		assert.NotNil(t, json.Unmarshal([]byte(`1e400`), &amount))
+		assert.NotNil(t, json.Unmarshal([]byte(`1e30`), &amount))
+	})
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      any
+		expected Money
+	}{
+		{"NUMERIC as bytes", []byte("113.14"), 11314},
+		{"Text", "278.80", 27880},
+		{"Integer", int64(15), 1500},
+		{"Float", 419.88, 41988},
+		{"NULL", nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m Money
+			assert.Nil(t, m.Scan(tt.src))
+			assert.Equal(t, m, tt.expected)
+		})
+	}
+
+	t.Run("Scans rates with ten decimals", func(t *testing.T) {
+		var r Rate
+		assert.Nil(t, r.Scan([]byte("0.0054355")))
+		assert.Equal(t, r, MustParseRate("0.0054355"))
+	})
+
+	t.Run("Rejects unsupported types", func(t *testing.T) {
+		var m Money
+		assert.NotNil(t, m.Scan(true))
+	})
+}
@@ -0,0 +1,132 @@
+package payroll
+
+import (
+	"fmt"
+
+	"github.com/jcroyoaun/totalcompmx/internal/database"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+// MaxAforeYears is the longest projection accepted, a full working life
+const MaxAforeYears = 60
+
+// MinAforeRate and MaxAforeRate bound the yearly real return and salary growth
+// of the projection, in percent. Compounded over MaxAforeYears anything higher
+// overflows the balance.
+const (
+	MinAforeRate = -50
+	MaxAforeRate = 20
+)
+
+// aforeSBCCapUMAs is the SBC cap of Retiro and Cesantía (LSS Art. 28)
+const aforeSBCCapUMAs = 25
+
+// AforeInput configures the retirement account projection of a package
+type AforeInput struct {
+	Years            int         // Years to project; 0 skips the projection
+	RealReturn       float64     // Yearly return over inflation in percent (e.g. 4 for 4%)
+	SalaryGrowth     float64     // Yearly salary growth over inflation in percent
+	VoluntaryMonthly money.Money // Aportaciones voluntarias in pesos a month
+	InitialBalance   money.Money // Current balance of the account
+}
+
+// ProjectAfore accumulates the Retiro, the Cesantía of worker and employer, the
+// cuota social and the voluntary contributions of a Sueldos y Salarios package
+// into the AFORE balance, year by year. Amounts are in today's pesos: the UMA
+// and the rates stay constant and the return is real. Contributions are
+// deposited every bimester, so each year's contributions earn half a year of
+// return. The SBC grows with the salary but never above 25 UMAs; whatever the
+// cap leaves out is reported as the lost balance.
+func ProjectAfore(rt *RateTables, calc database.SalaryCalculation, input AforeInput) (database.AforeProjection, error) {
+	var projection database.AforeProjection
+
+	if calc.SBC <= 0 {
+		return projection, fmt.Errorf("afore projection requires a Sueldos y Salarios calculation")
+	}
+	if input.Years <= 0 || input.Years > MaxAforeYears {
+		return projection, fmt.Errorf("afore projection years must be between 1 and %d", MaxAforeYears)
+	}
+	if !(input.RealReturn >= MinAforeRate && input.RealReturn <= MaxAforeRate) ||
+		!(input.SalaryGrowth >= MinAforeRate && input.SalaryGrowth <= MaxAforeRate) {
+		return projection, fmt.Errorf("afore return and salary growth must be between %d%% and %d%%", MinAforeRate, MaxAforeRate)
+	}
+	if input.VoluntaryMonthly < 0 || input.InitialBalance < 0 {
+		return projection, fmt.Errorf("afore voluntary contributions and balance cannot be negative")
+	}
+
+	realReturn := money.Percent(input.RealReturn)
+	halfReturn := realReturn.Div(money.Int(2))
+	salaryGrowth := money.Percent(input.SalaryGrowth)
+	maxSBC := rt.fiscalYear.UMADaily * aforeSBCCapUMAs
+
+	// The salary keeps growing past the cap, so start from the SBC before it
+	uncappedSBC := calc.SBC
+	if calc.SBC >= maxSBC {
+		uncappedSBC = max(calc.SBC, calc.GrossSalary.DivRate(daysPerMonth).Mul(calc.IntegrationFactor))
+	}
+
+	projection.Years = input.Years
+	projection.RealReturn = realReturn
+	projection.SalaryGrowth = salaryGrowth
+	projection.InitialBalance = input.InitialBalance
+	projection.UncappedSBC = uncappedSBC
+
+	balance := input.InitialBalance
+	growth := money.One
+	var lost money.Money
+	for year := 1; year <= input.Years; year++ {
+		if year > 1 {
+			growth = growth.Mul(money.One + salaryGrowth)
+		}
+		dailySBC := uncappedSBC.Mul(growth)
+		row := database.AforeYear{
+			Year:      year,
+			DailySBC:  min(dailySBC, maxSBC),
+			Capped:    dailySBC > maxSBC,
+			Voluntary: input.VoluntaryMonthly * 12,
+		}
+		row.Retiro, row.Cesantia, row.SocialQuota = aforeContributions(rt, row.DailySBC)
+		row.Contributions = row.Retiro + row.Cesantia + row.SocialQuota + row.Voluntary
+		row.Returns = balance.Mul(realReturn) + row.Contributions.Mul(halfReturn)
+		balance += row.Contributions + row.Returns
+		row.Balance = balance
+
+		// Above the cap Retiro and Cesantía would keep their rate on the rest of the SBC
+		var lostContributions money.Money
+		if row.Capped {
+			lostContributions = (row.Retiro + row.Cesantia).Mul((dailySBC - row.DailySBC).Ratio(row.DailySBC))
+			projection.SBCCapped = true
+		}
+		lost = lost.Mul(money.One+realReturn) + lostContributions.Mul(money.One+halfReturn)
+
+		projection.TotalContributions += row.Contributions
+		projection.TotalReturns += row.Returns
+		projection.Schedule = append(projection.Schedule, row)
+	}
+
+	projection.Balance = balance
+	projection.LostBalance = lost
+
+	return projection, nil
+}
+
+// aforeContributions returns the yearly deposits to the retirement subaccount
+// for a daily SBC: the employer Retiro, the Cesantía of employer and worker and
+// the cuota social of the government
+func aforeContributions(rt *RateTables, dailySBC money.Money) (retiro, cesantia, socialQuota money.Money) {
+	for _, concept := range rt.imssConcepts {
+		switch concept.ConceptName {
+		case conceptRetiro:
+			retiro += employerContribution(rt, concept, dailySBC, 0)
+		case conceptCesantia:
+			cesantia += employerContribution(rt, concept, dailySBC, 0)
+			cesantia += conceptMonthlyBase(rt, concept, dailySBC).Mul(concept.WorkerPercent)
+		}
+	}
+
+	if bracket, found := rt.cesantiaBracket(dailySBC.Ratio(rt.fiscalYear.UMADaily)); found {
+		socialQuota = bracket.SocialQuotaDaily.Mul(daysPerMonth)
+	}
+
+	return retiro * 12, cesantia * 12, socialQuota * 12
+}
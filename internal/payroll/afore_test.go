@@ -0,0 +1,90 @@
+package payroll
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jcroyoaun/totalcompmx/internal/assert"
+	"github.com/jcroyoaun/totalcompmx/internal/database"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+func TestProjectAfore(t *testing.T) {
+	rt := newTestRateTables()
+
+	t.Run("Accumulates Retiro, Cesantía and the cuota social", func(t *testing.T) {
+		calc, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("10000"), YearsOfService: 1, Afore: AforeInput{Years: 2}})
+		assert.Nil(t, err)
+		projection := calc.Afore
+		assert.NotNil(t, projection)
+
+		// A daily SBC of ~3.05 UMA: 2% Retiro, 3.863% + 1.125% Cesantía and the cuota social
+		year := projection.Schedule[0]
+		monthlyBase := calc.SBC.Mul(daysPerMonth)
+		assert.Equal(t, year.Retiro, monthlyBase.Mul(rate("0.02"))*12)
+		assert.Equal(t, year.Cesantia, (monthlyBase.Mul(rate("0.03863"))+monthlyBase.Mul(rate("0.01125")))*12)
+		assert.Equal(t, year.SocialQuota, mxn("10.87").Mul(daysPerMonth)*12)
+		assert.Equal(t, year.Returns, money.Money(0))
+		assert.Equal(t, projection.Balance, year.Contributions*2)
+		assert.False(t, projection.SBCCapped)
+	})
+
+	t.Run("Adds voluntary contributions and half a year of return on them", func(t *testing.T) {
+		calc, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("20000"), YearsOfService: 1})
+		assert.Nil(t, err)
+		base, err := ProjectAfore(rt, calc, AforeInput{Years: 1})
+		assert.Nil(t, err)
+
+		projection, err := ProjectAfore(rt, calc, AforeInput{Years: 1, RealReturn: 4, VoluntaryMonthly: mxn("500"), InitialBalance: mxn("100000")})
+		assert.Nil(t, err)
+		contributions := base.Schedule[0].Contributions + mxn("6000")
+		assert.Equal(t, projection.Schedule[0].Contributions, contributions)
+		assert.Equal(t, projection.Schedule[0].Returns, mxn("4000")+contributions.Mul(rate("0.02")))
+		assert.Equal(t, projection.Balance, mxn("100000")+contributions+projection.Schedule[0].Returns)
+	})
+
+	t.Run("Grows the SBC with the salary", func(t *testing.T) {
+		calc, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("20000"), YearsOfService: 1})
+		assert.Nil(t, err)
+		projection, err := ProjectAfore(rt, calc, AforeInput{Years: 2, SalaryGrowth: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, projection.Schedule[1].DailySBC, calc.SBC.Mul(rate("1.1")))
+	})
+
+	t.Run("Flags the value lost to the 25 UMA cap", func(t *testing.T) {
+		calc, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("150000"), YearsOfService: 1})
+		assert.Nil(t, err)
+		projection, err := ProjectAfore(rt, calc, AforeInput{Years: 10, RealReturn: 4})
+		assert.Nil(t, err)
+		assert.True(t, projection.SBCCapped)
+		assert.True(t, projection.Schedule[0].Capped)
+		assert.Equal(t, projection.Schedule[0].DailySBC, mxn("2828.50"))
+		assert.True(t, projection.UncappedSBC > projection.Schedule[0].DailySBC)
+		assert.True(t, projection.LostBalance > 0)
+		assert.Equal(t, projection.Schedule[0].SocialQuota, money.Money(0))
+	})
+
+	t.Run("Compounds the highest rates over a working life", func(t *testing.T) {
+		calc, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("1000000"), YearsOfService: 1})
+		assert.Nil(t, err)
+		projection, err := ProjectAfore(rt, calc, AforeInput{Years: MaxAforeYears, RealReturn: MaxAforeRate, SalaryGrowth: MaxAforeRate, InitialBalance: money.MaxAmount})
+		assert.Nil(t, err)
+		assert.True(t, projection.Balance > money.MaxAmount)
+		assert.True(t, projection.LostBalance > 0)
+	})
+
+	t.Run("Rejects invalid inputs", func(t *testing.T) {
+		calc, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("20000"), YearsOfService: 1})
+		assert.Nil(t, err)
+		_, err = ProjectAfore(rt, calc, AforeInput{Years: 61})
+		assert.NotNil(t, err)
+		_, err = ProjectAfore(rt, calc, AforeInput{Years: 5, VoluntaryMonthly: mxn("-1")})
+		assert.NotNil(t, err)
+		_, err = ProjectAfore(rt, calc, AforeInput{Years: MaxAforeYears, SalaryGrowth: 45})
+		assert.NotNil(t, err)
+		_, err = ProjectAfore(rt, calc, AforeInput{Years: 5, RealReturn: math.NaN()})
+		assert.NotNil(t, err)
+		_, err = ProjectAfore(rt, database.SalaryCalculation{GrossSalary: mxn("20000")}, AforeInput{Years: 5})
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,156 @@
+package payroll
+
+import (
+	"github.com/jcroyoaun/totalcompmx/internal/database"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+// SchoolLevel is an education level eligible for the colegiaturas deduction
+type SchoolLevel string
+
+const (
+	SchoolPreescolar         SchoolLevel = "PREESCOLAR"
+	SchoolPrimaria           SchoolLevel = "PRIMARIA"
+	SchoolSecundaria         SchoolLevel = "SECUNDARIA"
+	SchoolProfesionalTecnico SchoolLevel = "PROFESIONAL_TECNICO"
+	SchoolBachillerato       SchoolLevel = "BACHILLERATO"
+)
+
+// tuitionLimits are the annual per-student limits of the colegiaturas decree
+var tuitionLimits = map[SchoolLevel]money.Money{
+	SchoolPreescolar:         money.Pesos(14200),
+	SchoolPrimaria:           money.Pesos(12900),
+	SchoolSecundaria:         money.Pesos(19900),
+	SchoolProfesionalTecnico: money.Pesos(17100),
+	SchoolBachillerato:       money.Pesos(24500),
+}
+
+const (
+	deductionCapUMAs      = 5  // Art. 151: global cap of 5 annual UMAs...
+	retirementCapUMAs     = 5  // Art. 151 fr. V: up to 5 annual UMAs...
+	aguinaldoExemptUMAs   = 30 // Art. 93 fr. XIV
+	primaVacacionalExempt = 15 // Art. 93 fr. XIV, in daily UMAs
+)
+
+var (
+	deductionCapPercent  = money.MustParseRate("0.15") // ...or 15% of total income, whichever is lower
+	retirementCapPercent = money.MustParseRate("0.10") // ...or 10% of taxable income, whichever is lower
+)
+
+// Valid reports whether the level is covered by the colegiaturas decree
+func (l SchoolLevel) Valid() bool {
+	_, ok := tuitionLimits[l]
+	return ok
+}
+
+// TuitionLimit returns the annual deductible limit per student for the level
+func (l SchoolLevel) TuitionLimit() money.Money {
+	return tuitionLimits[l]
+}
+
+// Tuition is the colegiatura paid for one student during the year
+type Tuition struct {
+	Level  SchoolLevel
+	Amount money.Money
+}
+
+// PersonalDeductions are the annual personal deductions of the taxpayer
+type PersonalDeductions struct {
+	MedicalExpenses         money.Money // Art. 151 fr. I: medical, dental and hospital fees
+	MortgageInterest        money.Money // Art. 151 fr. IV: real interest on a home mortgage
+	RetirementContributions money.Money // Art. 151 fr. V: voluntary contributions to a PPR or AFORE
+	Tuition                 []Tuition   // Colegiaturas decree, one entry per student
+}
+
+// AnnualReturn is the annual reconciliation (declaración anual) of a salaried
+// employee. A positive Balance is ISR a cargo, a negative one is saldo a favor;
+// either way it is adjusted to whole pesos (CFF Art. 20).
+type AnnualReturn struct {
+	TotalIncome   money.Money // Every income of the year, exempt included (base of the 15% cap)
+	ExemptIncome  money.Money
+	TaxableIncome money.Money
+
+	DeductionCap        money.Money // Lower of 5 annual UMAs and 15% of TotalIncome
+	CappedDeductions    money.Money // Medical and mortgage interest after the global cap
+	RetirementDeduction money.Money // PPR, outside the global cap with its own limit
+	TuitionDeduction    money.Money // Colegiaturas, outside the global cap with per-level limits
+	TotalDeductions     money.Money
+
+	TaxBase        money.Money // TaxableIncome - TotalDeductions
+	AnnualISR      money.Money // From the Art. 152 annual tariff
+	SubsidioEmpleo money.Money // Subsidio al empleo paid during the year
+	TaxDue         money.Money // ISR of the year after subsidio
+	TaxWithheld    money.Money // Withheld by the employer during the year
+	Balance        money.Money // TaxDue - TaxWithheld
+}
+
+// IsRefund reports whether the return ends with saldo a favor
+func (a AnnualReturn) IsRefund() bool {
+	return a.Balance < 0
+}
+
+// BalanceAmount returns the saldo a favor or a cargo as a positive amount
+func (a AnnualReturn) BalanceAmount() money.Money {
+	return max(a.Balance, -a.Balance)
+}
+
+// CalculateAnnualReturn reconciles a year of salary withholding with the annual
+// ISR tariff. Salary, overtime, aguinaldo, prima vacacional, PTU and other benefits are taken
+// from the calculation; the personal deductions then lower the tax base.
+func CalculateAnnualReturn(rt *RateTables, calc database.SalaryCalculation, deductions PersonalDeductions) AnnualReturn {
+	fiscalYear := rt.fiscalYear
+	var result AnnualReturn
+
+	// Income of the year: exempt portions count for the 15% cap but not the base
+	aguinaldoExempt := min(calc.AguinaldoGross, fiscalYear.UMADaily*aguinaldoExemptUMAs)
+	primaExempt := min(calc.PrimaVacacionalGross, fiscalYear.UMADaily*primaVacacionalExempt)
+	ptuExempt := min(calc.PTUGross, fiscalYear.UMADaily*ptuExemptUMAs)
+
+	result.TaxableIncome = calc.GrossSalary*12 + (calc.AguinaldoGross - aguinaldoExempt) + (calc.PrimaVacacionalGross - primaExempt) + (calc.PTUGross - ptuExempt)
+	result.ExemptIncome = aguinaldoExempt + primaExempt + ptuExempt + calc.ValesDespensaMonthly*12 + calc.FondoAhorroYearly.Div(2)
+	result.TaxWithheld = (calc.ISRTax-calc.SubsidioEmpleo)*12 + calc.AguinaldoISR + calc.PrimaVacacionalISR + calc.PTUISR
+
+	// Overtime and prima dominical are paid every month
+	overtimeGross := calc.OvertimeDouble + calc.OvertimeTriple + calc.PrimaDominicalGross
+	overtimeExempt := calc.OvertimeExempt + calc.PrimaDominicalExempt
+	result.TaxableIncome += (overtimeGross - overtimeExempt) * 12
+	result.ExemptIncome += overtimeExempt * 12
+	result.TaxWithheld += (calc.OvertimeISR + calc.PrimaDominicalISR) * 12
+
+	for _, benefit := range calc.OtherBenefits {
+		amount, isr := benefit.Amount, benefit.ISR
+		if benefit.Cadence != "annual" {
+			amount, isr = amount*12, isr*12
+		}
+		if benefit.TaxFree {
+			result.ExemptIncome += amount
+		} else {
+			result.TaxableIncome += amount
+			result.TaxWithheld += isr
+		}
+	}
+	result.TotalIncome = result.TaxableIncome + result.ExemptIncome
+
+	// Personal deductions
+	result.DeductionCap = min(fiscalYear.UMAAnnual*deductionCapUMAs, result.TotalIncome.Mul(deductionCapPercent))
+	result.CappedDeductions = min(deductions.MedicalExpenses+deductions.MortgageInterest, result.DeductionCap)
+	result.RetirementDeduction = min(deductions.RetirementContributions,
+		result.TaxableIncome.Mul(retirementCapPercent), fiscalYear.UMAAnnual*retirementCapUMAs)
+	for _, tuition := range deductions.Tuition {
+		result.TuitionDeduction += min(tuition.Amount, tuition.Level.TuitionLimit())
+	}
+	result.TotalDeductions = result.CappedDeductions + result.RetirementDeduction + result.TuitionDeduction
+
+	// Annual tax
+	result.TaxBase = max(0, result.TaxableIncome-result.TotalDeductions)
+	result.AnnualISR = CalculateISR(result.TaxBase, rt.ISRTable(PeriodAnnual))
+	result.SubsidioEmpleo = calc.SubsidioEmpleo * 12
+
+	result.TaxDue = max(0, result.AnnualISR-result.SubsidioEmpleo)
+
+	// Subsidio paid above the ISR is not refundable in the annual return
+	result.TaxWithheld = max(0, result.TaxWithheld)
+	result.Balance = (result.TaxDue - result.TaxWithheld).RoundPesos()
+
+	return result
+}
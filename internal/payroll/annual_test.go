@@ -0,0 +1,61 @@
+package payroll
+
+import (
+	"testing"
+
+	"github.com/jcroyoaun/totalcompmx/internal/assert"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+func TestCalculateAnnualReturn(t *testing.T) {
+	rt := newTestRateTables()
+
+	calc, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("30000"), YearsOfService: 1, HasAguinaldo: true, HasPrimaVacacional: true})
+	assert.Nil(t, err)
+
+	t.Run("Withholding matches the annual tax without deductions", func(t *testing.T) {
+		annual := CalculateAnnualReturn(rt, calc, PersonalDeductions{})
+		assert.Equal(t, annual.TotalDeductions, money.Money(0))
+		assert.Equal(t, annual.TaxWithheld, mxn("59586.75"))
+		assert.Equal(t, annual.Balance, money.Money(0))
+		assert.False(t, annual.IsRefund())
+	})
+
+	t.Run("Caps medical and mortgage deductions at 15% of income", func(t *testing.T) {
+		annual := CalculateAnnualReturn(rt, calc, PersonalDeductions{MedicalExpenses: mxn("40000"), MortgageInterest: mxn("60000")})
+		// 15% of income is below 5 annual UMAs (206,367.60)
+		assert.Equal(t, annual.DeductionCap, annual.TotalIncome.Mul(rate("0.15")))
+		assert.Equal(t, annual.CappedDeductions, annual.DeductionCap)
+		assert.True(t, annual.IsRefund())
+	})
+
+	t.Run("Caps at 5 annual UMAs for high incomes", func(t *testing.T) {
+		high, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("200000"), YearsOfService: 1})
+		assert.Nil(t, err)
+		annual := CalculateAnnualReturn(rt, high, PersonalDeductions{MedicalExpenses: mxn("500000")})
+		assert.Equal(t, annual.CappedDeductions, mxn("206367.60"))
+	})
+
+	t.Run("Keeps PPR and colegiaturas outside the global cap", func(t *testing.T) {
+		annual := CalculateAnnualReturn(rt, calc, PersonalDeductions{
+			RetirementContributions: mxn("100000"),
+			Tuition: []Tuition{
+				{Level: SchoolPrimaria, Amount: mxn("30000")},
+				{Level: SchoolBachillerato, Amount: mxn("10000")},
+			},
+		})
+		assert.Equal(t, annual.CappedDeductions, money.Money(0))
+		// 10% of taxable income
+		assert.Equal(t, annual.RetirementDeduction, annual.TaxableIncome.Mul(rate("0.10")))
+		// 12,900 primaria limit + 10,000 bachillerato
+		assert.Equal(t, annual.TuitionDeduction, mxn("22900"))
+	})
+
+	t.Run("Does not refund subsidio paid above the ISR", func(t *testing.T) {
+		low, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("9000"), YearsOfService: 1})
+		assert.Nil(t, err)
+		annual := CalculateAnnualReturn(rt, low, PersonalDeductions{MedicalExpenses: mxn("10000")})
+		assert.Equal(t, annual.TaxDue, money.Money(0))
+		assert.Equal(t, annual.Balance, money.Money(0))
+	})
+}
@@ -0,0 +1,78 @@
+package payroll
+
+import (
+	"github.com/jcroyoaun/totalcompmx/internal/database"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+// infonavitEmployerRate is the employer's housing contribution on the SBC (Ley Infonavit Art. 29)
+var infonavitEmployerRate = money.MustParseRate("0.05")
+
+// AsimiladosInput holds everything needed to calculate an Asimilados a Salarios package
+type AsimiladosInput struct {
+	GrossMonthlySalary money.Money
+	Zone               Zone        // Work location; empty means ZoneGeneral
+	PayPeriod          Periodicity // Payslip periodicity; empty means monthly. GrossMonthlySalary stays monthly
+	WorkRisk           WorkRisk    // Risk of the company, for the missing employer IMSS
+	OtherBenefits      []OtherBenefit
+	ExchangeRate       money.Rate // Used to convert USD other benefits to MXN
+	Explain            bool       // Record a step-by-step trace in the result (explain mode)
+}
+
+// CalculateAsimilados withholds ISR with the salary tariff (LISR Art. 94 fr. IV)
+// but, with no employment relationship, there is no IMSS, Infonavit, subsidio
+// al empleo or statutory benefits. The employer IMSS and Infonavit an employee
+// with the same salary would receive are reported as missing social security,
+// so the package is not overvalued against Sueldos y Salarios.
+func CalculateAsimilados(rt *RateTables, input AsimiladosInput) (database.SalaryCalculation, error) {
+	grossMonthlySalary := input.GrossMonthlySalary
+	tr := newTracer(input.Explain)
+
+	result := database.SalaryCalculation{
+		GrossSalary: grossMonthlySalary,
+		Zone:        string(input.Zone),
+	}
+
+	if input.PayPeriod == "" || input.PayPeriod == PeriodMonthly {
+		result.ISRTax = calculateISR(tr, "Sueldo", grossMonthlySalary, rt.isrBrackets)
+	} else {
+		// Withhold each payslip with its own tariff, without subsidio or IMSS
+		slip := calculatePayslip(rt, SalaryInput{
+			GrossMonthlySalary: grossMonthlySalary,
+			Zone:               input.Zone,
+			PayPeriod:          input.PayPeriod,
+		}, 0, 0, tr)
+		perMonth := input.PayPeriod.PeriodsPerMonth()
+
+		result.Payslip = &slip
+		result.ISRTax = slip.ISRTax.Mul(perMonth)
+	}
+
+	result.NetSalary = grossMonthlySalary - result.ISRTax
+
+	// Taxable benefits are more income of the month, taxed at the marginal rate
+	var otherBenefitsAnnualNet money.Money
+	result.OtherBenefits, result.OtherBenefitsMonthlyNet, otherBenefitsAnnualNet = independentBenefits(input.OtherBenefits, grossMonthlySalary, input.ExchangeRate, func(amount money.Money) money.Money {
+		return CalculateISR(grossMonthlySalary+amount, rt.isrBrackets) - CalculateISR(grossMonthlySalary, rt.isrBrackets)
+	})
+	result.NetSalary += result.OtherBenefitsMonthlyNet
+
+	independentTotals(&result, grossMonthlySalary, otherBenefitsAnnualNet, 0)
+
+	// Social security an employee with the same salary would have, on the
+	// statutory SBC of the first year. It is not part of the package.
+	sbc := CalculateSBC(rt, SalaryInput{GrossMonthlySalary: grossMonthlySalary, YearsOfService: 1, Zone: input.Zone})
+	riskPremium, err := rt.RiskPremium(input.WorkRisk)
+	if err != nil {
+		return result, err
+	}
+	result.RiskClass = string(input.WorkRisk.Class)
+	result.RiskPremium = riskPremium
+	result.MissingIMSSEmployerMonthly = calculateIMSSEmployer(rt, sbc, riskPremium, tr)
+	result.MissingInfonavitEmployerMonthly = sbc.Mul(daysPerMonth).Mul(infonavitEmployerRate)
+	result.MissingSocialSecurityAnnual = (result.MissingIMSSEmployerMonthly + result.MissingInfonavitEmployerMonthly) * 12
+
+	result.Trace = tr.result()
+
+	return result, nil
+}
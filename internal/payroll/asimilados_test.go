@@ -0,0 +1,50 @@
+package payroll
+
+import (
+	"testing"
+
+	"github.com/jcroyoaun/totalcompmx/internal/assert"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+func TestCalculateAsimilados(t *testing.T) {
+	rt := newTestRateTables()
+
+	t.Run("Withholds salary ISR without IMSS or subsidio", func(t *testing.T) {
+		result, err := CalculateAsimilados(rt, AsimiladosInput{GrossMonthlySalary: mxn("8000")})
+		assert.Nil(t, err)
+		assert.Equal(t, result.ISRTax, CalculateISR(mxn("8000"), rt.ISRBrackets()))
+		assert.Equal(t, result.SubsidioEmpleo, money.Money(0))
+		assert.Equal(t, result.IMSSWorker, money.Money(0))
+		assert.Equal(t, result.NetSalary, mxn("8000")-result.ISRTax)
+		assert.Equal(t, result.YearlyNet, result.NetSalary*12)
+	})
+
+	t.Run("Reports the employer social security it does not include", func(t *testing.T) {
+		result, err := CalculateAsimilados(rt, AsimiladosInput{GrossMonthlySalary: mxn("30000")})
+		assert.Nil(t, err)
+
+		employee, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("30000"), YearsOfService: 1})
+		assert.Nil(t, err)
+		assert.Equal(t, result.MissingIMSSEmployerMonthly, employee.IMSSEmployerMonthly)
+		assert.Equal(t, result.MissingInfonavitEmployerMonthly, employee.InfonavitEmployerMonthly)
+		assert.Equal(t, result.IMSSEmployerAnnual, money.Money(0))
+		assert.Equal(t, result.YearlyGross, mxn("360000"))
+		assert.True(t, result.MissingSocialSecurityAnnual > 0)
+	})
+
+	t.Run("Does not apply the border stimulus", func(t *testing.T) {
+		result, err := CalculateAsimilados(rt, AsimiladosInput{GrossMonthlySalary: mxn("30000"), Zone: ZoneNorthBorder})
+		assert.Nil(t, err)
+		assert.Equal(t, result.BorderISRCredit, money.Money(0))
+		assert.Equal(t, result.NetSalary, mxn("30000")-result.ISRTax)
+	})
+
+	t.Run("Withholds each payslip with its own tariff", func(t *testing.T) {
+		result, err := CalculateAsimilados(rt, AsimiladosInput{GrossMonthlySalary: mxn("30000"), PayPeriod: PeriodSemimonthly})
+		assert.Nil(t, err)
+		assert.NotNil(t, result.Payslip)
+		assert.Equal(t, result.Payslip.IMSSWorker, money.Money(0))
+		assert.Equal(t, result.ISRTax, result.Payslip.ISRTax.Mul(PeriodSemimonthly.PeriodsPerMonth()))
+	})
+}
@@ -0,0 +1,92 @@
+package payroll
+
+import (
+	"fmt"
+
+	"github.com/jcroyoaun/totalcompmx/internal/database"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+// MaxCurvePoints is the most salaries a tax curve samples
+const MaxCurvePoints = 200
+
+// curveStepRounding keeps the points of a curve on round salaries
+var curveStepRounding = money.Pesos(100)
+
+// CurveInput is the range of gross monthly salaries a tax curve sweeps
+type CurveInput struct {
+	MinGross money.Money // Gross monthly salary of the first point
+	MaxGross money.Money // Upper bound; the last point may fall short of it
+	Step     money.Money // Gross between two points
+}
+
+// Points is the number of salaries the range samples
+func (input CurveInput) Points() int {
+	if input.Step <= 0 || input.MaxGross < input.MinGross {
+		return 0
+	}
+	return int((input.MaxGross-input.MinGross)/input.Step) + 1
+}
+
+// CurveRange spreads about points salaries between from and to, with a step
+// rounded up to whole hundreds of pesos
+func CurveRange(from, to money.Money, points int) CurveInput {
+	if points < 2 || to <= from {
+		return CurveInput{MinGross: from, MaxGross: from, Step: curveStepRounding}
+	}
+	step := (to - from).Div(points - 1)
+	if remainder := step % curveStepRounding; remainder != 0 || step == 0 {
+		step += curveStepRounding - remainder
+	}
+	return CurveInput{MinGross: from, MaxGross: to, Step: step}
+}
+
+// TaxCurve re-runs the package calculation for every salary of the range. The
+// marginal rates of a point come from the calculation one step above it, so
+// they tell what the next Step of gross is really worth.
+func TaxCurve(input CurveInput, yearsOfService int, calculate Calculator) (database.TaxCurve, error) {
+	var curve database.TaxCurve
+
+	if input.MinGross <= 0 || input.Step <= 0 || input.MaxGross < input.MinGross {
+		return curve, fmt.Errorf("curve range must be positive with a maximum above the minimum")
+	}
+	points := input.Points()
+	if points > MaxCurvePoints {
+		return curve, fmt.Errorf("curve cannot have more than %d points", MaxCurvePoints)
+	}
+
+	curve.Step = input.Step
+
+	next, err := calculate(input.MinGross, yearsOfService)
+	if err != nil {
+		return curve, err
+	}
+	for i := 0; i < points; i++ {
+		gross := input.MinGross + input.Step*money.Money(i)
+		calc := next
+		next, err = calculate(gross+input.Step, yearsOfService)
+		if err != nil {
+			return curve, err
+		}
+
+		isr := curveISR(calc)
+		curve.Points = append(curve.Points, database.CurvePoint{
+			GrossMonthlySalary: gross,
+			NetSalary:          calc.NetSalary,
+			YearlyNet:          calc.YearlyNet,
+			ISRTax:             isr,
+			IMSSWorker:         calc.IMSSWorker,
+			EffectiveRate:      (isr + calc.IMSSWorker).Ratio(gross),
+			MarginalISRRate:    (curveISR(next) - isr).Ratio(input.Step),
+			MarginalIMSSRate:   (next.IMSSWorker - calc.IMSSWorker).Ratio(input.Step),
+			NetDrops:           next.NetSalary < calc.NetSalary,
+		})
+	}
+
+	return curve, nil
+}
+
+// curveISR is the monthly salary ISR actually withheld
+func curveISR(calc database.SalaryCalculation) money.Money {
+	return calc.ISRTax - calc.SubsidioEmpleo
+}
@@ -0,0 +1,83 @@
+package payroll
+
+import (
+	"testing"
+
+	"github.com/jcroyoaun/totalcompmx/internal/assert"
+	"github.com/jcroyoaun/totalcompmx/internal/database"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+func TestTaxCurve(t *testing.T) {
+	// A flat 20% tax and 2% IMSS keep the arithmetic visible
+	flat := func(gross money.Money, _ int) (database.SalaryCalculation, error) {
+		isr, imss := gross.Mul(rate("0.2")), gross.Mul(rate("0.02"))
+		return database.SalaryCalculation{ISRTax: isr, IMSSWorker: imss, NetSalary: gross - isr - imss}, nil
+	}
+
+	t.Run("Samples the range step by step", func(t *testing.T) {
+		curve, err := TaxCurve(CurveInput{MinGross: mxn("10000"), MaxGross: mxn("12500"), Step: mxn("1000")}, 1, flat)
+		assert.Nil(t, err)
+		assert.Equal(t, curve.Step, mxn("1000"))
+		assert.Equal(t, len(curve.Points), 3)
+		last := curve.Points[2]
+		assert.Equal(t, last.GrossMonthlySalary, mxn("12000"))
+		assert.Equal(t, last.NetSalary, mxn("9360"))
+		assert.Equal(t, last.EffectiveRate, rate("0.22"))
+		assert.Equal(t, last.MarginalISRRate, rate("0.2"))
+		assert.Equal(t, last.MarginalIMSSRate, rate("0.02"))
+	})
+
+	t.Run("Shows the Subsidio al Empleo cliff", func(t *testing.T) {
+		rt := newTestRateTables()
+		curve, err := TaxCurve(CurveInput{MinGross: mxn("10000"), MaxGross: mxn("10300"), Step: mxn("100")}, 1, func(gross money.Money, yearsOfService int) (database.SalaryCalculation, error) {
+			return CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: gross, YearsOfService: yearsOfService})
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, len(curve.Points), 4)
+
+		// Going past 10,171 loses the whole subsidio
+		assert.True(t, curve.Points[1].MarginalISRRate > money.One)
+		assert.True(t, curve.Points[2].NetSalary < curve.Points[1].NetSalary)
+		assert.True(t, curve.Points[1].NetDrops)
+		assert.False(t, curve.Points[2].NetDrops)
+
+		// Above it only the 10.88% bracket applies
+		assert.Equal(t, curve.Points[2].MarginalISRRate, rate("0.1088"))
+		assert.True(t, curve.Points[2].MarginalIMSSRate > 0)
+		assert.True(t, curve.Points[2].EffectiveRate > curve.Points[0].EffectiveRate)
+	})
+
+	t.Run("Rejects invalid ranges", func(t *testing.T) {
+		inputs := []CurveInput{
+			{MinGross: 0, MaxGross: mxn("10000"), Step: mxn("100")},
+			{MinGross: mxn("10000"), MaxGross: mxn("20000"), Step: 0},
+			{MinGross: mxn("20000"), MaxGross: mxn("10000"), Step: mxn("100")},
+			{MinGross: mxn("10000"), MaxGross: mxn("100000"), Step: mxn("100")},
+		}
+		for _, input := range inputs {
+			_, err := TaxCurve(input, 1, flat)
+			assert.NotNil(t, err)
+		}
+	})
+}
+
+func TestCurveRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to money.Money
+		points   int
+		expected CurveInput
+	}{
+		{"Rounds the step up to hundreds", mxn("8000"), mxn("60000"), 40, CurveInput{MinGross: mxn("8000"), MaxGross: mxn("60000"), Step: mxn("1400")}},
+		{"Keeps an exact step", mxn("10000"), mxn("20000"), 11, CurveInput{MinGross: mxn("10000"), MaxGross: mxn("20000"), Step: mxn("1000")}},
+		{"Never steps less than 100 pesos", mxn("10000"), mxn("10001"), 40, CurveInput{MinGross: mxn("10000"), MaxGross: mxn("10001"), Step: mxn("100")}},
+		{"Collapses an empty range", mxn("10000"), mxn("9000"), 40, CurveInput{MinGross: mxn("10000"), MaxGross: mxn("10000"), Step: mxn("100")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, CurveRange(tt.from, tt.to, tt.points), tt.expected)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package payroll
+
+import (
+	"fmt"
+
+	"github.com/jcroyoaun/totalcompmx/internal/database"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+// IMSS concepts reported on their own in the employer cost, by imss_concepts name
+const (
+	conceptRetiro        = "Retiro"
+	conceptCesantia      = "Cesantía en Edad Avanzada y Vejez"
+	conceptRiesgoTrabajo = "Riesgo de Trabajo"
+)
+
+// CalculateEmployerCost returns the monthly cost to the company (costo patronal)
+// of a Sueldos y Salarios calculation in the given state: the pay and benefits,
+// the employer IMSS by branch, Infonavit and the state's Impuesto Sobre Nóminas.
+// The ISN base is every remuneración except PTU and previsión social (vales and
+// fondo de ahorro), which most state laws exclude.
+func CalculateEmployerCost(rt *RateTables, calc database.SalaryCalculation, stateCode string) (database.EmployerCost, error) {
+	var cost database.EmployerCost
+
+	if calc.SBC <= 0 {
+		return cost, fmt.Errorf("employer cost requires a Sueldos y Salarios calculation")
+	}
+	state, found := rt.StatePayrollTax(stateCode)
+	if !found {
+		return cost, fmt.Errorf("unknown state %q", stateCode)
+	}
+	cost.StateCode = state.StateCode
+	cost.StateName = state.StateName
+	cost.ISNRate = state.Rate
+
+	cost.Salary = calc.GrossSalary + calc.OvertimeDouble + calc.OvertimeTriple + calc.PrimaDominicalGross
+
+	// Benefits paid once a year are spread over the months
+	var otherBenefits money.Money
+	for _, benefit := range calc.OtherBenefits {
+		if benefit.Cadence == "annual" {
+			otherBenefits += benefit.Amount.Div(12)
+		} else {
+			otherBenefits += benefit.Amount
+		}
+	}
+	yearly := (calc.AguinaldoGross + calc.PrimaVacacionalGross + calc.PTUGross).Div(12)
+	// The company matches the fondo de ahorro the employee saves
+	cost.Benefits = yearly + calc.ValesDespensaMonthly + calc.FondoAhorroEmployee + otherBenefits
+
+	for _, concept := range rt.imssConcepts {
+		contribution := employerContribution(rt, concept, calc.SBC, calc.RiskPremium)
+		switch concept.ConceptName {
+		case conceptRetiro:
+			cost.Retiro += contribution
+		case conceptCesantia:
+			cost.Cesantia += contribution
+		case conceptRiesgoTrabajo:
+			cost.RiesgoTrabajo += contribution
+		default:
+			cost.IMSS += contribution
+		}
+	}
+
+	cost.Infonavit = calc.InfonavitEmployerMonthly
+
+	cost.ISNBase = cost.Salary + (calc.AguinaldoGross + calc.PrimaVacacionalGross).Div(12) + otherBenefits
+	cost.ISN = cost.ISNBase.Mul(cost.ISNRate)
+
+	cost.Total = cost.Salary + cost.Benefits + cost.IMSS + cost.Retiro + cost.Cesantia + cost.RiesgoTrabajo + cost.Infonavit + cost.ISN
+	cost.TotalAnnual = cost.Total * 12
+	cost.Factor = cost.Total.Ratio(calc.GrossSalary)
+
+	return cost, nil
+}
@@ -0,0 +1,60 @@
+package payroll
+
+import (
+	"testing"
+
+	"github.com/jcroyoaun/totalcompmx/internal/assert"
+	"github.com/jcroyoaun/totalcompmx/internal/database"
+)
+
+func TestCalculateEmployerCost(t *testing.T) {
+	rt := newTestRateTables()
+
+	t.Run("Adds employer IMSS by branch, Infonavit and ISN", func(t *testing.T) {
+		calc, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("30000"), YearsOfService: 1, HasAguinaldo: true, HasPrimaVacacional: true, State: "CDMX"})
+		assert.Nil(t, err)
+		cost := calc.EmployerCost
+		assert.NotNil(t, cost)
+		assert.Equal(t, cost.StateName, "Ciudad de México")
+		assert.Equal(t, cost.Salary, mxn("30000"))
+		assert.Equal(t, cost.Benefits, (calc.AguinaldoGross + calc.PrimaVacacionalGross).Div(12))
+		assert.Equal(t, cost.IMSS+cost.Retiro+cost.Cesantia+cost.RiesgoTrabajo, calc.IMSSEmployerMonthly)
+		assert.Equal(t, cost.Retiro, calc.SBC.Mul(daysPerMonth).Mul(rate("0.02")))
+		assert.Equal(t, cost.ISNBase, cost.Salary+cost.Benefits)
+		assert.Equal(t, cost.ISN, cost.ISNBase.Mul(rate("0.04")))
+		assert.Equal(t, cost.Total, cost.Salary+cost.Benefits+calc.IMSSEmployerMonthly+cost.Infonavit+cost.ISN)
+		assert.True(t, cost.Factor > rate("1.2"))
+	})
+
+	t.Run("Leaves PTU and previsión social out of the ISN base", func(t *testing.T) {
+		calc, err := CalculateSalaryWithBenefits(rt, SalaryInput{
+			GrossMonthlySalary:  mxn("20000"),
+			YearsOfService:      1,
+			HasValesDespensa:    true,
+			ValesDespensaAmount: mxn("1000"),
+			HasPTU:              true,
+			PTU:                 PTU{Mode: PTUEmployeeShare, Value: 12000},
+			State:               "JAL",
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, calc.EmployerCost.Benefits, mxn("2000"))
+		assert.Equal(t, calc.EmployerCost.ISNBase, mxn("20000"))
+		assert.Equal(t, calc.EmployerCost.ISN, mxn("400"))
+	})
+
+	t.Run("Skips the employer cost without a state", func(t *testing.T) {
+		calc, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("20000"), YearsOfService: 1})
+		assert.Nil(t, err)
+		assert.Nil(t, calc.EmployerCost)
+	})
+
+	t.Run("Rejects an unknown state", func(t *testing.T) {
+		_, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("20000"), YearsOfService: 1, State: "XX"})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Requires a salaried calculation", func(t *testing.T) {
+		_, err := CalculateEmployerCost(rt, database.SalaryCalculation{GrossSalary: mxn("20000")}, "CDMX")
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,168 @@
+package payroll
+
+import (
+	"fmt"
+
+	"github.com/jcroyoaun/totalcompmx/internal/database"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+// ExpenseCategory groups the deductible expenses of Actividad Empresarial
+type ExpenseCategory string
+
+const (
+	ExpenseRent         ExpenseCategory = "RENT"              // Office or coworking rent
+	ExpenseServices     ExpenseCategory = "SERVICES"          // Internet, phone and electricity
+	ExpenseSupplies     ExpenseCategory = "SUPPLIES"          // Stationery, software and consumables
+	ExpenseProfessional ExpenseCategory = "PROFESSIONAL_FEES" // Accountant and other advisors
+	ExpenseTravel       ExpenseCategory = "TRAVEL"            // Viáticos and transport
+	ExpenseEquipment    ExpenseCategory = "EQUIPMENT"         // Computers, deducted over time
+)
+
+// equipmentDepreciationRate is the yearly deduction of computer equipment (LISR Art. 34 fr. VII)
+var equipmentDepreciationRate = money.MustParseRate("0.30")
+
+// ExpenseCategories returns every expense category in display order
+func ExpenseCategories() []ExpenseCategory {
+	return []ExpenseCategory{ExpenseRent, ExpenseServices, ExpenseSupplies, ExpenseProfessional, ExpenseTravel, ExpenseEquipment}
+}
+
+// Valid reports whether the category is one of the supported values
+func (c ExpenseCategory) Valid() bool {
+	switch c {
+	case ExpenseRent, ExpenseServices, ExpenseSupplies, ExpenseProfessional, ExpenseTravel, ExpenseEquipment:
+		return true
+	}
+	return false
+}
+
+// Expense is a deductible expense before IVA
+type Expense struct {
+	Category ExpenseCategory
+	Amount   money.Money // Monthly spend; for equipment, the purchase price
+}
+
+// Deduction returns the part of the expense deducted each month. Equipment is
+// an investment, so only its yearly depreciation is deducted.
+func (e Expense) Deduction() money.Money {
+	if e.Category == ExpenseEquipment {
+		return e.Amount.Mul(equipmentDepreciationRate).Div(12)
+	}
+	return e.Amount
+}
+
+// IVABase returns the part of the expense whose IVA is credited each month.
+// Equipment is a one-time purchase, so its IVA is spread over the year instead
+// of being credited again on every monthly invoice.
+func (e Expense) IVABase() money.Money {
+	if e.Category == ExpenseEquipment {
+		return e.Amount.Div(12)
+	}
+	return e.Amount
+}
+
+// ActividadEmpresarialInput holds everything needed to calculate a package of
+// a persona física con actividad empresarial y profesional (honorarios)
+type ActividadEmpresarialInput struct {
+	MonthlyIncome      money.Money
+	Expenses           []Expense
+	UnpaidVacationDays int
+	ClientType         ClientType // Who pays the invoices; empty means ClientPersonaFisica
+	ChargesIVA         bool       // Invoices add 16% IVA
+	Zone               Zone       // Where the activity is carried out; empty means ZoneGeneral
+	BorderStimulus     bool       // Registered in the padrón of the border region ISR stimulus
+	OtherBenefits      []OtherBenefit
+	ExchangeRate       money.Rate // Used to convert USD other benefits to MXN
+	Explain            bool       // Record a step-by-step trace in the result (explain mode)
+}
+
+// CalculateActividadEmpresarial applies the progressive ISR tariff to the
+// income minus deductible expenses, as the monthly provisional payment of
+// LISR Art. 106. With a steady income the accumulated tariff of each month
+// equals the monthly one, so a single month represents the year. There is no
+// IMSS or subsidio, and the expenses are a cost, so they reduce the net.
+func CalculateActividadEmpresarial(rt *RateTables, input ActividadEmpresarialInput) (database.SalaryCalculation, error) {
+	monthlyIncome := input.MonthlyIncome
+	tr := newTracer(input.Explain)
+
+	clientType := input.ClientType
+	if clientType == "" {
+		clientType = ClientPersonaFisica
+	}
+	if !clientType.Valid() {
+		return database.SalaryCalculation{}, fmt.Errorf("unknown client type %q", clientType)
+	}
+
+	result := database.SalaryCalculation{
+		GrossSalary:        monthlyIncome,
+		UnpaidVacationDays: input.UnpaidVacationDays,
+		ClientType:         string(clientType),
+		Zone:               string(input.Zone),
+	}
+
+	var ivaBase money.Money
+	for _, expense := range input.Expenses {
+		if !expense.Category.Valid() {
+			return result, fmt.Errorf("unknown expense category %q", expense.Category)
+		}
+		if expense.Amount < 0 {
+			return result, fmt.Errorf("expenses cannot be negative")
+		}
+		if expense.Amount == 0 {
+			continue
+		}
+
+		deduction := expense.Deduction()
+		result.Expenses = append(result.Expenses, database.ExpenseResult{
+			Category:  string(expense.Category),
+			Amount:    expense.Amount,
+			Deduction: deduction,
+		})
+		result.DeductibleExpenses += deduction
+		ivaBase += expense.IVABase()
+	}
+
+	// Losses are carried forward, not refunded, so the ISR never goes below 0
+	result.TaxableProfit = max(0, monthlyIncome-result.DeductibleExpenses)
+	result.ISRTax = calculateISR(tr, "Utilidad gravable", result.TaxableProfit, rt.isrBrackets)
+
+	// Taxpayers in the padrón of the border region stimulus credit one third
+	// of the ISR of the income earned in the region
+	if input.BorderStimulus {
+		result.BorderISRCredit = BorderISRCredit(input.Zone, result.ISRTax)
+	}
+
+	// Cash flow: a Persona Moral retains 10% of the honorarios; the taxpayer
+	// pays the rest as the monthly provisional payment, in whole pesos
+	result.ISRRetained = monthlyIncome.Mul(rt.retentionRate("ACTIVIDAD_EMPRESARIAL", clientType))
+	result.ISRProvisionalPayment = max(0, result.ISRTax-result.BorderISRCredit-result.ISRRetained).RoundPesos()
+	result.CashReceived = monthlyIncome - result.ISRRetained
+
+	// The IVA of every expense is acreditable; equipment is credited on its
+	// price, not on the depreciation deducted for ISR
+	if input.ChargesIVA {
+		invoice := CalculateInvoice(rt, "ACTIVIDAD_EMPRESARIAL", clientType, monthlyIncome, ivaBase)
+		invoice.TaxesOwed = result.ISRProvisionalPayment + invoice.IVAPayable
+		result.CashReceived = invoice.Total - invoice.IVARetenido - invoice.ISRRetenido
+		result.Invoice = &invoice
+	}
+
+	result.NetSalary = monthlyIncome - result.DeductibleExpenses - result.ISRTax + result.BorderISRCredit
+
+	// Taxable benefits are more income of the month, taxed at the marginal rate
+	var otherBenefitsAnnualNet money.Money
+	result.OtherBenefits, result.OtherBenefitsMonthlyNet, otherBenefitsAnnualNet = independentBenefits(input.OtherBenefits, monthlyIncome, input.ExchangeRate, func(amount money.Money) money.Money {
+		isr := CalculateISR(result.TaxableProfit+amount, rt.isrBrackets) - result.ISRTax
+		if input.BorderStimulus {
+			isr -= BorderISRCredit(input.Zone, isr)
+		}
+		return isr
+	})
+	result.NetSalary += result.OtherBenefitsMonthlyNet
+
+	independentTotals(&result, monthlyIncome, otherBenefitsAnnualNet, input.UnpaidVacationDays)
+
+	result.Trace = tr.result()
+
+	return result, nil
+}
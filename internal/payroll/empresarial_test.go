@@ -0,0 +1,115 @@
+package payroll
+
+import (
+	"testing"
+
+	"github.com/jcroyoaun/totalcompmx/internal/assert"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+func TestExpenseDeduction(t *testing.T) {
+	tests := []struct {
+		name     string
+		expense  Expense
+		expected money.Money
+	}{
+		{"Rent is deducted in full", Expense{Category: ExpenseRent, Amount: mxn("8000")}, mxn("8000")},
+		{"Equipment is depreciated at 30% a year", Expense{Category: ExpenseEquipment, Amount: mxn("40000")}, mxn("1000")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expense.Deduction(), tt.expected)
+		})
+	}
+}
+
+func TestCalculateActividadEmpresarial(t *testing.T) {
+	rt := newTestRateTables()
+
+	t.Run("Taxes the income minus deductions with the progressive tariff", func(t *testing.T) {
+		result, err := CalculateActividadEmpresarial(rt, ActividadEmpresarialInput{
+			MonthlyIncome: mxn("50000"),
+			Expenses: []Expense{
+				{Category: ExpenseRent, Amount: mxn("8000")},
+				{Category: ExpenseServices, Amount: mxn("1000")},
+				{Category: ExpenseEquipment, Amount: mxn("40000")},
+			},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, result.DeductibleExpenses, mxn("10000"))
+		assert.Equal(t, result.TaxableProfit, mxn("40000"))
+		assert.Equal(t, result.ISRTax, CalculateISR(mxn("40000"), rt.ISRBrackets()))
+		assert.Equal(t, result.NetSalary, mxn("40000")-result.ISRTax)
+		assert.Equal(t, len(result.Expenses), 3)
+	})
+
+	t.Run("Companies retain 10% of the honorarios", func(t *testing.T) {
+		result, err := CalculateActividadEmpresarial(rt, ActividadEmpresarialInput{MonthlyIncome: mxn("50000"), ClientType: ClientPersonaMoral})
+		assert.Nil(t, err)
+		assert.Equal(t, result.ISRRetained, mxn("5000"))
+		assert.Equal(t, result.ISRProvisionalPayment, (result.ISRTax - mxn("5000")).RoundPesos())
+		assert.Equal(t, result.CashReceived, mxn("45000"))
+	})
+
+	t.Run("Credits the IVA of the expenses", func(t *testing.T) {
+		result, err := CalculateActividadEmpresarial(rt, ActividadEmpresarialInput{
+			MonthlyIncome: mxn("50000"),
+			Expenses:      []Expense{{Category: ExpenseRent, Amount: mxn("10000")}},
+			ClientType:    ClientPersonaMoral,
+			ChargesIVA:    true,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, result.Invoice.IVAAcreditable, mxn("1600"))
+		// 8,000 IVA - 5,333.33 retained - 1,600 acreditable, in whole pesos
+		assert.Equal(t, result.Invoice.IVAPayable, mxn("1067"))
+		assert.Equal(t, result.CashReceived, mxn("58000")-mxn("5333.33")-mxn("5000"))
+
+		// Equipment is depreciated at 30% for ISR, but its whole IVA is
+		// credited over the year: 6,400 / 12 a month
+		result, err = CalculateActividadEmpresarial(rt, ActividadEmpresarialInput{
+			MonthlyIncome: mxn("50000"),
+			Expenses:      []Expense{{Category: ExpenseEquipment, Amount: mxn("40000")}},
+			ChargesIVA:    true,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, result.DeductibleExpenses, mxn("1000"))
+		assert.Equal(t, result.Invoice.IVAAcreditable, mxn("533.33"))
+	})
+
+	t.Run("Credits one third of the ISR in the border stimulus padrón", func(t *testing.T) {
+		general, err := CalculateActividadEmpresarial(rt, ActividadEmpresarialInput{MonthlyIncome: mxn("50000"), Zone: ZoneNorthBorder})
+		assert.Nil(t, err)
+		assert.Equal(t, general.BorderISRCredit, money.Money(0))
+
+		result, err := CalculateActividadEmpresarial(rt, ActividadEmpresarialInput{MonthlyIncome: mxn("50000"), Zone: ZoneNorthBorder, BorderStimulus: true})
+		assert.Nil(t, err)
+		assert.Equal(t, result.BorderISRCredit, result.ISRTax.Div(3))
+		assert.Equal(t, result.ISRProvisionalPayment, (result.ISRTax - result.BorderISRCredit).RoundPesos())
+		assert.Equal(t, result.NetSalary, general.NetSalary+result.BorderISRCredit)
+
+		// Outside the border regions the padrón does not apply
+		result, err = CalculateActividadEmpresarial(rt, ActividadEmpresarialInput{MonthlyIncome: mxn("50000"), BorderStimulus: true})
+		assert.Nil(t, err)
+		assert.Equal(t, result.BorderISRCredit, money.Money(0))
+	})
+
+	t.Run("Does not tax a loss", func(t *testing.T) {
+		result, err := CalculateActividadEmpresarial(rt, ActividadEmpresarialInput{
+			MonthlyIncome: mxn("10000"),
+			Expenses:      []Expense{{Category: ExpenseTravel, Amount: mxn("12000")}},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, result.TaxableProfit, money.Money(0))
+		assert.Equal(t, result.ISRTax, money.Money(0))
+		assert.Equal(t, result.NetSalary, mxn("-2000"))
+	})
+
+	t.Run("Rejects invalid expenses", func(t *testing.T) {
+		_, err := CalculateActividadEmpresarial(rt, ActividadEmpresarialInput{MonthlyIncome: mxn("50000"), Expenses: []Expense{{Category: "YACHT", Amount: mxn("1000")}}})
+		assert.NotNil(t, err)
+
+		_, err = CalculateActividadEmpresarial(rt, ActividadEmpresarialInput{MonthlyIncome: mxn("50000"), Expenses: []Expense{{Category: ExpenseRent, Amount: mxn("-1")}}})
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,61 @@
+package payroll
+
+import (
+	"fmt"
+
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+// InfonavitCreditType mirrors the infonavit_credit_type enum
+type InfonavitCreditType string
+
+const (
+	InfonavitPercentage InfonavitCreditType = "PERCENTAGE"  // Percentage of the SBC
+	InfonavitFixedPesos InfonavitCreditType = "FIXED_PESOS" // Fixed monthly amount in pesos
+	InfonavitVSM        InfonavitCreditType = "VSM"         // Factor of the UMI (formerly Veces Salario Mínimo)
+)
+
+// InfonavitCredit describes the employee's mortgage discount as stated in the
+// Aviso de Retención issued by Infonavit
+type InfonavitCredit struct {
+	Type InfonavitCreditType
+
+	// Value depends on Type: percent of the SBC (e.g. 20 for 20%), monthly pesos,
+	// or the monthly VSM/UMI factor
+	Value float64
+}
+
+// Valid reports whether the credit type is one of the supported enum values
+func (t InfonavitCreditType) Valid() bool {
+	switch t {
+	case InfonavitPercentage, InfonavitFixedPesos, InfonavitVSM:
+		return true
+	}
+	return false
+}
+
+// CalculateInfonavitDiscount returns the monthly amortization withheld for an
+// Infonavit credit and the seguro de daños fee charged on top of it
+func CalculateInfonavitDiscount(rt *RateTables, credit InfonavitCredit, dailySBC money.Money) (discount, insurance money.Money, err error) {
+	if credit.Value < 0 {
+		return 0, 0, fmt.Errorf("infonavit credit value cannot be negative")
+	}
+
+	switch credit.Type {
+	case InfonavitPercentage:
+		// Percentage applies to the SBC of the days in the period
+		discount = dailySBC.Mul(daysPerMonth).Mul(money.Percent(credit.Value))
+	case InfonavitFixedPesos:
+		discount = money.FromFloat(credit.Value)
+	case InfonavitVSM:
+		// The factor is expressed in monthly UMIs
+		discount = rt.fiscalYear.UMIValue.Mul(daysPerMonth).Mul(money.RateFromFloat(credit.Value))
+	default:
+		return 0, 0, fmt.Errorf("unknown infonavit credit type %q", credit.Type)
+	}
+
+	// Seguro de daños is charged per bimester
+	insurance = rt.fiscalYear.InfonavitInsuranceBimonthly.Div(2)
+
+	return discount, insurance, nil
+}
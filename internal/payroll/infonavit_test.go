@@ -0,0 +1,69 @@
+package payroll
+
+import (
+	"testing"
+
+	"github.com/jcroyoaun/totalcompmx/internal/assert"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+func TestCalculateInfonavitDiscount(t *testing.T) {
+	rt := newTestRateTables()
+
+	tests := []struct {
+		name             string
+		credit           InfonavitCredit
+		dailySBC         money.Money
+		expectedDiscount money.Money
+	}{
+		{"Percentage of the SBC", InfonavitCredit{Type: InfonavitPercentage, Value: 20}, mxn("1000"), mxn("6080.00")},
+		{"Fixed pesos", InfonavitCredit{Type: InfonavitFixedPesos, Value: 2500}, mxn("1000"), mxn("2500.00")},
+		{"VSM factor times the UMI", InfonavitCredit{Type: InfonavitVSM, Value: 1.5}, mxn("1000"), mxn("5159.19")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			discount, insurance, err := CalculateInfonavitDiscount(rt, tt.credit, tt.dailySBC)
+			assert.Nil(t, err)
+			assert.Equal(t, discount, tt.expectedDiscount)
+			assert.Equal(t, insurance, mxn("7.50"))
+		})
+	}
+
+	t.Run("Rejects unknown credit types", func(t *testing.T) {
+		_, _, err := CalculateInfonavitDiscount(rt, InfonavitCredit{Type: "OTHER", Value: 1}, mxn("1000"))
+		assert.NotNil(t, err)
+	})
+}
+
+func TestCalculateSalaryWithInfonavitCredit(t *testing.T) {
+	rt := newTestRateTables()
+
+	input := SalaryInput{GrossMonthlySalary: mxn("30000"), YearsOfService: 1}
+	withoutCredit, err := CalculateSalaryWithBenefits(rt, input)
+	assert.Nil(t, err)
+
+	t.Run("Subtracts the discount and seguro de daños from net", func(t *testing.T) {
+		input := input
+		input.HasInfonavitCredit = true
+		input.InfonavitCredit = InfonavitCredit{Type: InfonavitFixedPesos, Value: 3000}
+
+		result, err := CalculateSalaryWithBenefits(rt, input)
+		assert.Nil(t, err)
+		assert.Equal(t, result.InfonavitDiscount, mxn("3007.50"))
+		assert.Equal(t, result.InfonavitInsurance, mxn("7.50"))
+		assert.Equal(t, result.NetSalary, withoutCredit.NetSalary-mxn("3007.50"))
+		assert.True(t, result.YearlyNet < withoutCredit.YearlyNet)
+	})
+
+	t.Run("Only flags the credit when no discount is given", func(t *testing.T) {
+		input := input
+		input.HasInfonavitCredit = true
+
+		result, err := CalculateSalaryWithBenefits(rt, input)
+		assert.Nil(t, err)
+		assert.True(t, result.HasInfonavitCredit)
+		assert.Equal(t, result.InfonavitDiscount, money.Money(0))
+		assert.Equal(t, result.NetSalary, withoutCredit.NetSalary)
+	})
+}
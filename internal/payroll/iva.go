@@ -0,0 +1,37 @@
+package payroll
+
+import (
+	"github.com/jcroyoaun/totalcompmx/internal/database"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+// ivaRate is the general IVA rate (LIVA Art. 1)
+var ivaRate = money.MustParseRate("0.16")
+
+// ivaRetainedThirds is the IVA on services a Persona Moral retains, in thirds (RLIVA Art. 3)
+const ivaRetainedThirds = 2
+
+// CalculateInvoice builds the monthly invoice of an independent worker for a
+// subtotal. A Persona Moral client retains two thirds of the IVA and the ISR
+// rate of the regime's retention rule; the IVA paid on expenses is credited
+// against the IVA that remains to pay (LIVA Art. 4-5), in whole pesos.
+func CalculateInvoice(rt *RateTables, regime string, clientType ClientType, subtotal, ivaExpenses money.Money) database.Invoice {
+	invoice := database.Invoice{
+		Subtotal:      subtotal,
+		IVATrasladado: subtotal.Mul(ivaRate),
+		ISRRetenido:   subtotal.Mul(rt.retentionRate(regime, clientType)),
+	}
+
+	if clientType == ClientPersonaMoral {
+		invoice.IVARetenido = (invoice.IVATrasladado * ivaRetainedThirds).Div(3)
+	}
+
+	invoice.Total = invoice.Subtotal + invoice.IVATrasladado
+	invoice.IVAAcreditable = max(0, ivaExpenses).Mul(ivaRate)
+
+	balance := invoice.IVATrasladado - invoice.IVARetenido - invoice.IVAAcreditable
+	invoice.IVAPayable = max(0, balance).RoundPesos()
+	invoice.IVAInFavor = max(0, -balance)
+
+	return invoice
+}
@@ -0,0 +1,81 @@
+package payroll
+
+import (
+	"testing"
+
+	"github.com/jcroyoaun/totalcompmx/internal/assert"
+	"github.com/jcroyoaun/totalcompmx/internal/database"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+func TestCalculateInvoice(t *testing.T) {
+	rt := newTestRateTables()
+
+	tests := []struct {
+		name       string
+		clientType ClientType
+		expenses   money.Money
+		expected   database.Invoice
+	}{
+		{
+			name:       "Individuals pay the whole IVA",
+			clientType: ClientPersonaFisica,
+			expected:   database.Invoice{Subtotal: mxn("30000"), IVATrasladado: mxn("4800"), Total: mxn("34800"), IVAPayable: mxn("4800")},
+		},
+		{
+			name:       "Companies retain two thirds of the IVA",
+			clientType: ClientPersonaMoral,
+			expected:   database.Invoice{Subtotal: mxn("30000"), IVATrasladado: mxn("4800"), IVARetenido: mxn("3200"), ISRRetenido: mxn("375"), Total: mxn("34800"), IVAPayable: mxn("1600")},
+		},
+		{
+			name:       "Credits the IVA paid on expenses",
+			clientType: ClientPersonaFisica,
+			expenses:   mxn("10000"),
+			expected:   database.Invoice{Subtotal: mxn("30000"), IVATrasladado: mxn("4800"), Total: mxn("34800"), IVAAcreditable: mxn("1600"), IVAPayable: mxn("3200")},
+		},
+		{
+			name:       "Leaves a balance in favor when expenses exceed the IVA owed",
+			clientType: ClientPersonaMoral,
+			expenses:   mxn("20000"),
+			expected:   database.Invoice{Subtotal: mxn("30000"), IVATrasladado: mxn("4800"), IVARetenido: mxn("3200"), ISRRetenido: mxn("375"), Total: mxn("34800"), IVAAcreditable: mxn("3200"), IVAInFavor: mxn("1600")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, CalculateInvoice(rt, "RESICO", tt.clientType, mxn("30000"), tt.expenses), tt.expected)
+		})
+	}
+}
+
+func TestCalculateRESICOWithIVA(t *testing.T) {
+	rt := newTestRateTables()
+
+	base, err := CalculateRESICO(rt, RESICOInput{MonthlyIncome: mxn("30000"), ClientType: ClientPersonaMoral})
+	assert.Nil(t, err)
+	result, err := CalculateRESICO(rt, RESICOInput{MonthlyIncome: mxn("30000"), ClientType: ClientPersonaMoral, ChargesIVA: true})
+	assert.Nil(t, err)
+
+	t.Run("Does not change the net", func(t *testing.T) {
+		assert.Equal(t, result.NetSalary, base.NetSalary)
+		assert.Equal(t, result.YearlyNet, base.YearlyNet)
+	})
+
+	t.Run("Deposits the invoice total minus retentions", func(t *testing.T) {
+		assert.Equal(t, result.CashReceived, mxn("31225"))
+	})
+
+	t.Run("Owes the ISR and IVA not retained", func(t *testing.T) {
+		assert.Equal(t, result.Invoice.TaxesOwed, result.ISRProvisionalPayment+result.Invoice.IVAPayable)
+		assert.Equal(t, result.Invoice.TaxesOwed, mxn("1600"))
+	})
+
+	t.Run("Has no invoice without IVA", func(t *testing.T) {
+		assert.True(t, base.Invoice == nil)
+	})
+
+	t.Run("Rejects negative expenses", func(t *testing.T) {
+		_, err := CalculateRESICO(rt, RESICOInput{MonthlyIncome: mxn("30000"), ChargesIVA: true, IVAExpenses: mxn("-1")})
+		assert.NotNil(t, err)
+	})
+}
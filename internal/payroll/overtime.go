@@ -0,0 +1,123 @@
+package payroll
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/jcroyoaun/totalcompmx/internal/database"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+const (
+	jornadaHours             = 8   // LFT Art. 61: hours of the daytime shift, base of the hourly rate
+	overtimeDoubleHoursWeek  = 9.0 // LFT Art. 66-67: first 9 hours a week are paid double
+	overtimeExemptUMAsWeek   = 5   // LISR Art. 93 fr. I: cap per week, in daily UMAs
+	primaDominicalExemptUMAs = 1   // LISR Art. 93 fr. XIV: per Sunday worked, in daily UMAs
+)
+
+var (
+	weeksPerMonth         = daysPerMonth.Div(money.Int(7)) // Weeks in the 30.4-day month used across the engine
+	primaDominicalPercent = money.MustParseRate("0.25")    // LFT Art. 71
+	overtimeExemptPercent = money.MustParseRate("0.50")    // LISR Art. 93 fr. I: half of the double hours
+)
+
+// Overtime describes the horas extra and Sundays an employee works regularly
+type Overtime struct {
+	HoursPerWeek    float64 // Overtime hours per week beyond the jornada
+	SundaysPerMonth float64 // Sundays worked per month, paid with prima dominical
+}
+
+// OvertimePay is the monthly pay for overtime and Sundays worked with the
+// portions exempt from ISR and integrable to the SBC
+type OvertimePay struct {
+	Double               money.Money // First 9 hours of the week at twice the hourly rate
+	Triple               money.Money // Hours beyond 9 a week at three times the hourly rate
+	Exempt               money.Money // Exempt portion of the double hours
+	PrimaDominical       money.Money
+	PrimaDominicalExempt money.Money
+}
+
+// Gross returns the overtime pay of the month, prima dominical excluded
+func (p OvertimePay) Gross() money.Money {
+	return p.Double + p.Triple
+}
+
+// CalculateOvertime returns the monthly overtime and prima dominical pay for a
+// salary. Triple hours exceed the LFT limits, so they are neither exempt from
+// ISR nor excluded from the SBC. Workers earning the minimum wage keep the
+// double hours fully exempt (LISR Art. 93 fr. I). Each week is paid in centavos
+// and then converted to the month.
+func CalculateOvertime(rt *RateTables, overtime Overtime, grossMonthlySalary money.Money, zone Zone) (OvertimePay, error) {
+	return calculateOvertime(rt, overtime, grossMonthlySalary, zone, nil)
+}
+
+// calculateOvertime is CalculateOvertime recording the exemptions of the double
+// hours and the prima dominical
+func calculateOvertime(rt *RateTables, overtime Overtime, grossMonthlySalary money.Money, zone Zone, tr *tracer) (OvertimePay, error) {
+	fiscalYear := rt.fiscalYear
+	var pay OvertimePay
+
+	if overtime.HoursPerWeek < 0 || overtime.SundaysPerMonth < 0 {
+		return pay, fmt.Errorf("overtime hours and sundays cannot be negative")
+	}
+
+	dailySalary := grossMonthlySalary.DivRate(daysPerMonth)
+	hourlyRate := dailySalary.Div(jornadaHours)
+
+	doubleHours := math.Min(overtime.HoursPerWeek, overtimeDoubleHoursWeek)
+	tripleHours := math.Max(0, overtime.HoursPerWeek-overtimeDoubleHoursWeek)
+	pay.Double = hourlyRate.Mul(money.RateFromFloat(2 * doubleHours)).Mul(weeksPerMonth)
+	pay.Triple = hourlyRate.Mul(money.RateFromFloat(3 * tripleHours)).Mul(weeksPerMonth)
+
+	rule, limit := "Horas dobles con salario mínimo", pay.Double
+	if grossMonthlySalary <= MinimumMonthlyWage(rt, zone) {
+		pay.Exempt = pay.Double
+	} else {
+		weeklyCap := (fiscalYear.UMADaily * overtimeExemptUMAsWeek).Mul(weeksPerMonth)
+		pay.Exempt = min(pay.Double.Mul(overtimeExemptPercent), weeklyCap)
+		rule, limit = "50% de las horas dobles, hasta 5 UMA por semana", weeklyCap
+	}
+	if pay.Gross() > 0 {
+		tr.exemption(database.ExemptionStep{
+			Concept: "Horas extra",
+			Rule:    rule,
+			Limit:   limit,
+			Gross:   pay.Gross(),
+			Exempt:  pay.Exempt,
+			Taxable: pay.Gross() - pay.Exempt,
+		})
+	}
+
+	sundays := money.RateFromFloat(overtime.SundaysPerMonth)
+	dominicalCap := (fiscalYear.UMADaily * primaDominicalExemptUMAs).Mul(sundays)
+	pay.PrimaDominical = dailySalary.Mul(primaDominicalPercent).Mul(sundays)
+	pay.PrimaDominicalExempt = min(pay.PrimaDominical, dominicalCap)
+	if pay.PrimaDominical > 0 {
+		tr.exemption(database.ExemptionStep{
+			Concept: "Prima dominical",
+			Rule:    "1 UMA por domingo",
+			Limit:   dominicalCap,
+			Gross:   pay.PrimaDominical,
+			Exempt:  pay.PrimaDominicalExempt,
+			Taxable: pay.PrimaDominical - pay.PrimaDominicalExempt,
+		})
+	}
+
+	return pay, nil
+}
+
+// integrableOvertime returns the daily pay for triple hours and prima dominical,
+// which integrate to the SBC (LSS Art. 27 fr. IX only excludes overtime within
+// the LFT limits)
+func integrableOvertime(rt *RateTables, input SalaryInput) money.Money {
+	if !input.HasOvertime {
+		return 0
+	}
+
+	pay, err := CalculateOvertime(rt, input.Overtime, input.GrossMonthlySalary, input.Zone)
+	if err != nil {
+		return 0
+	}
+
+	return (pay.Triple + pay.PrimaDominical).DivRate(daysPerMonth)
+}
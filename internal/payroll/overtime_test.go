@@ -0,0 +1,95 @@
+package payroll
+
+import (
+	"testing"
+
+	"github.com/jcroyoaun/totalcompmx/internal/assert"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+func TestCalculateOvertime(t *testing.T) {
+	rt := newTestRateTables()
+	daily := mxn("30000").DivRate(daysPerMonth)
+	hourly := daily.Div(8)
+
+	t.Run("Pays double up to 9 hours and triple after", func(t *testing.T) {
+		pay, err := CalculateOvertime(rt, Overtime{HoursPerWeek: 12}, mxn("30000"), ZoneGeneral)
+		assert.Nil(t, err)
+		assert.Equal(t, pay.Double, hourly.Mul(money.Int(2*9)).Mul(weeksPerMonth))
+		assert.Equal(t, pay.Triple, hourly.Mul(money.Int(3*3)).Mul(weeksPerMonth))
+	})
+
+	t.Run("Exempts half of the double hours up to 5 UMAs a week", func(t *testing.T) {
+		pay, err := CalculateOvertime(rt, Overtime{HoursPerWeek: 4}, mxn("30000"), ZoneGeneral)
+		assert.Nil(t, err)
+		assert.Equal(t, pay.Exempt, pay.Double.Div(2))
+
+		pay, err = CalculateOvertime(rt, Overtime{HoursPerWeek: 9}, mxn("100000"), ZoneGeneral)
+		assert.Nil(t, err)
+		assert.Equal(t, pay.Exempt, mxn("565.70").Mul(weeksPerMonth))
+	})
+
+	t.Run("Fully exempts the double hours at the minimum wage", func(t *testing.T) {
+		minimum := MinimumMonthlyWage(rt, ZoneGeneral)
+		pay, err := CalculateOvertime(rt, Overtime{HoursPerWeek: 9}, minimum, ZoneGeneral)
+		assert.Nil(t, err)
+		assert.Equal(t, pay.Exempt, pay.Double)
+	})
+
+	t.Run("Pays 25% prima dominical with 1 UMA exempt per Sunday", func(t *testing.T) {
+		pay, err := CalculateOvertime(rt, Overtime{SundaysPerMonth: 2}, mxn("30000"), ZoneGeneral)
+		assert.Nil(t, err)
+		assert.Equal(t, pay.PrimaDominical, daily.Mul(rate("0.25"))*2)
+		assert.Equal(t, pay.PrimaDominicalExempt, mxn("226.28"))
+	})
+
+	t.Run("Rejects negative values", func(t *testing.T) {
+		_, err := CalculateOvertime(rt, Overtime{HoursPerWeek: -1}, mxn("30000"), ZoneGeneral)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestCalculateSalaryWithOvertime(t *testing.T) {
+	rt := newTestRateTables()
+
+	base, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("30000"), YearsOfService: 1})
+	assert.Nil(t, err)
+
+	t.Run("Double hours do not integrate to the SBC", func(t *testing.T) {
+		result, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("30000"), YearsOfService: 1, HasOvertime: true, Overtime: Overtime{HoursPerWeek: 9}})
+		assert.Nil(t, err)
+		assert.Equal(t, result.SBC, base.SBC)
+		assert.Equal(t, result.OvertimeTriple, money.Money(0))
+	})
+
+	t.Run("Triple hours and prima dominical integrate to the SBC", func(t *testing.T) {
+		result, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("30000"), YearsOfService: 1, HasOvertime: true, Overtime: Overtime{HoursPerWeek: 12, SundaysPerMonth: 4}})
+		assert.Nil(t, err)
+		assert.Equal(t, result.SBC, base.SBC+(result.OvertimeTriple+result.PrimaDominicalGross).DivRate(daysPerMonth))
+	})
+
+	t.Run("Adds the net overtime to the monthly net", func(t *testing.T) {
+		result, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("30000"), YearsOfService: 1, HasOvertime: true, Overtime: Overtime{HoursPerWeek: 6, SundaysPerMonth: 2}})
+		assert.Nil(t, err)
+		assert.True(t, result.OvertimeISR > 0)
+		assert.True(t, result.PrimaDominicalISR > 0)
+		taxable := result.OvertimeDouble - result.OvertimeExempt + result.PrimaDominicalGross - result.PrimaDominicalExempt
+		extraISR := CalculateISR(mxn("30000")+taxable, rt.ISRBrackets()) - CalculateISR(mxn("30000"), rt.ISRBrackets())
+		assert.Equal(t, result.OvertimeISR+result.PrimaDominicalISR, extraISR)
+		// Prima dominical raises the SBC and therefore the IMSS quota
+		imssIncrease := result.IMSSWorker - base.IMSSWorker
+		assert.True(t, imssIncrease > 0)
+		assert.Equal(t, result.NetSalary, base.NetSalary+result.OvertimeNet+result.PrimaDominicalNet-imssIncrease)
+	})
+
+	t.Run("Withholds the overtime with the tariff of the pay period", func(t *testing.T) {
+		result, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("30000"), YearsOfService: 1, PayPeriod: PeriodWeekly, HasOvertime: true, Overtime: Overtime{HoursPerWeek: 6, SundaysPerMonth: 2}})
+		assert.Nil(t, err)
+		perMonth := PeriodWeekly.PeriodsPerMonth()
+		weekly := rt.ISRTable(PeriodWeekly)
+		slipGross := mxn("30000").DivRate(perMonth)
+		taxable := result.OvertimeDouble - result.OvertimeExempt + result.PrimaDominicalGross - result.PrimaDominicalExempt
+		extraISR := CalculateISR(slipGross+taxable.DivRate(perMonth), weekly) - CalculateISR(slipGross, weekly)
+		assert.Equal(t, result.OvertimeISR+result.PrimaDominicalISR, extraISR.Mul(perMonth))
+	})
+}
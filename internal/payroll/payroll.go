@@ -0,0 +1,819 @@
+// Package payroll implements the Mexican payroll engine (ISR, Subsidio al Empleo,
+// IMSS, RESICO and statutory benefits). All functions are pure: they operate on a
+// pre-loaded RateTables snapshot and never perform I/O.
+package payroll
+
+import (
+	"fmt"
+
+	"github.com/jcroyoaun/totalcompmx/internal/database"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+// OtherBenefit represents an "Otras prestaciones" line item of a package
+type OtherBenefit struct {
+	Name         string
+	Amount       float64 // Pesos, dollars or a percent, see Currency and IsPercentage
+	TaxFree      bool
+	Currency     string
+	Cadence      string // monthly, annual, etc.
+	IsPercentage bool   // true if Amount is a percentage of gross annual salary
+}
+
+// SalaryInput holds everything needed to calculate a Sueldos y Salarios package
+type SalaryInput struct {
+	GrossMonthlySalary     money.Money
+	YearsOfService         int
+	Zone                   Zone        // Work location; empty means ZoneGeneral
+	PayPeriod              Periodicity // Payslip periodicity; empty means monthly. GrossMonthlySalary stays monthly
+	HasAguinaldo           bool
+	AguinaldoDays          int
+	HasValesDespensa       bool
+	ValesDespensaAmount    money.Money
+	HasPrimaVacacional     bool
+	VacationDays           int
+	PrimaVacacionalPercent float64
+	HasFondoAhorro         bool
+	FondoAhorroPercent     float64
+	HasInfonavitCredit     bool
+	InfonavitCredit        InfonavitCredit // Discount applied when HasInfonavitCredit and Value > 0
+	HasPTU                 bool
+	PTU                    PTU // Profit sharing paid once a year when HasPTU
+	HasOvertime            bool
+	Overtime               Overtime   // Horas extra and Sundays worked every month when HasOvertime
+	WorkRisk               WorkRisk   // Company risk class or exact prima de riesgo; empty uses the imss_concepts rate
+	State                  string     // State code for the employer cost and its ISN; empty skips the employer cost
+	Afore                  AforeInput // Retirement account projection; zero Years skips it
+	OtherBenefits          []OtherBenefit
+	ExchangeRate           money.Rate // Used to convert USD other benefits to MXN
+	Explain                bool       // Record a step-by-step trace in the result (explain mode)
+}
+
+// Regime is the tax regime a package is paid under
+type Regime string
+
+const (
+	RegimeSueldos              Regime = "sueldos"               // Sueldos y Salarios
+	RegimeAsimilados           Regime = "asimilados"            // Asimilados a Salarios
+	RegimeRESICO               Regime = "resico"                // Régimen Simplificado de Confianza
+	RegimeActividadEmpresarial Regime = "actividad_empresarial" // Actividad Empresarial y Profesional
+)
+
+// ClientType is the kind of client a RESICO taxpayer invoices
+type ClientType string
+
+const (
+	ClientPersonaFisica ClientType = "PERSONA_FISICA" // Individuals do not retain ISR
+	ClientPersonaMoral  ClientType = "PERSONA_MORAL"  // Companies retain ISR from every payment (LISR Art. 113-J)
+)
+
+// resicoAnnualCap is the most a person can earn in a year and stay in RESICO (LISR Art. 113-E)
+var resicoAnnualCap = money.Pesos(3_500_000)
+
+// fondoAhorroCapUMAs is the yearly employee fondo de ahorro cap, in annual UMAs
+var fondoAhorroCapUMAs = money.MustParseRate("1.3")
+
+// Valid reports whether the client type is one of the supported values
+func (c ClientType) Valid() bool {
+	switch c {
+	case ClientPersonaFisica, ClientPersonaMoral:
+		return true
+	}
+	return false
+}
+
+// RESICOInput holds everything needed to calculate a RESICO package
+type RESICOInput struct {
+	MonthlyIncome      money.Money
+	UnpaidVacationDays int
+	ClientType         ClientType  // Who pays the invoices; empty means ClientPersonaFisica
+	ChargesIVA         bool        // Invoices add 16% IVA
+	IVAExpenses        money.Money // Monthly expenses before IVA whose IVA is acreditable
+	OtherBenefits      []OtherBenefit
+	ExchangeRate       money.Rate // Used to convert USD other benefits to MXN
+}
+
+// CalculateRESICO performs RESICO regime calculation (flat rate, no IMSS, no subsidio)
+func CalculateRESICO(rt *RateTables, input RESICOInput) (database.SalaryCalculation, error) {
+	monthlyIncome := input.MonthlyIncome
+
+	clientType := input.ClientType
+	if clientType == "" {
+		clientType = ClientPersonaFisica
+	}
+	if !clientType.Valid() {
+		return database.SalaryCalculation{}, fmt.Errorf("unknown client type %q", clientType)
+	}
+	if input.IVAExpenses < 0 {
+		return database.SalaryCalculation{}, fmt.Errorf("iva expenses cannot be negative")
+	}
+
+	result := database.SalaryCalculation{
+		GrossSalary:        monthlyIncome,
+		UnpaidVacationDays: input.UnpaidVacationDays,
+		ClientType:         string(clientType),
+	}
+
+	// Get RESICO bracket
+	resicoBracket, found := rt.resicoBracket(monthlyIncome)
+	if !found {
+		return result, fmt.Errorf("no RESICO bracket found for income %s", monthlyIncome)
+	}
+
+	// RESICO: Apply flat rate to TOTAL income
+	result.ISRTax = monthlyIncome.Mul(resicoBracket.ApplicableRate)
+
+	// Cash flow: a Persona Moral retains part of the ISR when it pays; the
+	// taxpayer pays the rest as the monthly provisional payment, in whole pesos
+	result.ISRRetained = monthlyIncome.Mul(rt.retentionRate("RESICO", clientType))
+	result.ISRProvisionalPayment = max(0, result.ISRTax-result.ISRRetained).RoundPesos()
+	result.CashReceived = monthlyIncome - result.ISRRetained
+
+	// IVA passes through to the SAT, so it changes the cash flow but not the net
+	if input.ChargesIVA {
+		invoice := CalculateInvoice(rt, "RESICO", clientType, monthlyIncome, input.IVAExpenses)
+		invoice.TaxesOwed = result.ISRProvisionalPayment + invoice.IVAPayable
+		result.CashReceived = invoice.Total - invoice.IVARetenido - invoice.ISRRetenido
+		result.Invoice = &invoice
+	}
+
+	// RESICO has NO:
+	// - IMSS (result.IMSSWorker = 0)
+	// - Subsidio al Empleo (result.SubsidioEmpleo = 0)
+	// - SBC (result.SBC = 0)
+
+	// Calculate Net Salary
+	result.NetSalary = monthlyIncome - result.ISRTax
+
+	// Taxable benefits pay the RESICO rate of the income's bracket
+	var otherBenefitsAnnualNet money.Money
+	result.OtherBenefits, result.OtherBenefitsMonthlyNet, otherBenefitsAnnualNet = independentBenefits(input.OtherBenefits, monthlyIncome, input.ExchangeRate, func(amount money.Money) money.Money {
+		return amount.Mul(resicoBracket.ApplicableRate)
+	})
+	result.NetSalary += result.OtherBenefitsMonthlyNet
+
+	independentTotals(&result, monthlyIncome, otherBenefitsAnnualNet, input.UnpaidVacationDays)
+
+	// Above the annual ceiling the taxpayer must leave RESICO for Actividad Empresarial
+	result.ExceedsRESICOCap = result.YearlyGrossBase > resicoAnnualCap
+
+	return result, nil
+}
+
+// independentBenefits processes the other benefits (Otras prestaciones) of a
+// package without statutory benefits (independent or asimilados), taxing them
+// with the regime's isr function, and splits their net between monthly and annual
+func independentBenefits(benefits []OtherBenefit, monthlyIncome money.Money, exchangeRate money.Rate, isr func(amount money.Money) money.Money) (results []database.OtherBenefitResult, monthlyNet, annualNet money.Money) {
+	for _, benefit := range benefits {
+		benefitAmount := otherBenefitAmount(benefit, monthlyIncome*12, exchangeRate)
+
+		benefitResult := database.OtherBenefitResult{
+			Name:    benefit.Name,
+			Amount:  benefitAmount,
+			TaxFree: benefit.TaxFree,
+			Cadence: benefit.Cadence,
+		}
+
+		if benefit.TaxFree {
+			// Tax-free benefits
+			benefitResult.ISR = 0
+			benefitResult.Net = benefitAmount
+		} else {
+			// Taxable benefits - apply the regime's ISR
+			benefitResult.ISR = isr(benefitAmount)
+			benefitResult.Net = benefitAmount - benefitResult.ISR
+		}
+
+		results = append(results, benefitResult)
+
+		// Add to monthly or annual based on cadence
+		if benefit.Cadence == "annual" {
+			annualNet += benefitResult.Net
+		} else {
+			// Default to monthly
+			monthlyNet += benefitResult.Net
+		}
+	}
+
+	return results, monthlyNet, annualNet
+}
+
+// independentTotals fills the yearly totals of a package without statutory
+// benefits from the monthly net, which already includes the monthly benefits
+func independentTotals(result *database.SalaryCalculation, monthlyIncome, annualBenefitsNet money.Money, unpaidVacationDays int) {
+	// Calculate yearly totals (include annual benefits)
+	result.YearlyGrossBase = monthlyIncome * 12
+	result.YearlyGross = result.YearlyGrossBase
+
+	// Unpaid Vacation Adjustment
+	// Freelancers don't get paid when they don't work - this is "opportunity cost"
+	if unpaidVacationDays > 0 {
+		dailyRate := monthlyIncome.DivRate(daysPerMonth) // Average days per month
+		result.UnpaidVacationLoss = dailyRate.Mul(money.Int(unpaidVacationDays))
+
+		// Reduce yearly gross and net by the lost income
+		result.YearlyGrossBase -= result.UnpaidVacationLoss
+		result.YearlyGross -= result.UnpaidVacationLoss
+	}
+
+	result.YearlyNet = (result.NetSalary * 12) + annualBenefitsNet - result.UnpaidVacationLoss
+	result.MonthlyAdjusted = result.YearlyNet.Div(12)
+}
+
+// CalculateSalaryWithBenefits performs the full Mexican payroll calculation with benefits
+// Zero AguinaldoDays, VacationDays or PrimaVacacionalPercent default to the
+// statutory values for the employee's years of service.
+func CalculateSalaryWithBenefits(rt *RateTables, input SalaryInput) (database.SalaryCalculation, error) {
+	fiscalYear := rt.fiscalYear
+	input = withStatutoryDefaults(rt, input)
+	grossMonthlySalary := input.GrossMonthlySalary
+	tr := newTracer(input.Explain)
+
+	// Calculate monthly first
+	result := calculateMonthly(rt, input, tr)
+
+	// Add overtime and prima dominical, taxed as ordinary income of the month
+	if input.HasOvertime {
+		pay, err := calculateOvertime(rt, input.Overtime, grossMonthlySalary, input.Zone, tr)
+		if err != nil {
+			return result, err
+		}
+		result.OvertimeDouble = pay.Double
+		result.OvertimeTriple = pay.Triple
+		result.OvertimeExempt = pay.Exempt
+		result.PrimaDominicalGross = pay.PrimaDominical
+		result.PrimaDominicalExempt = pay.PrimaDominicalExempt
+
+		// ISR on top of the salary's, split in proportion to each taxable amount.
+		// It is withheld on every payslip with the same tariff as the salary.
+		overtimeTaxable := pay.Gross() - pay.Exempt
+		dominicalTaxable := pay.PrimaDominical - pay.PrimaDominicalExempt
+		if taxable := overtimeTaxable + dominicalTaxable; taxable > 0 {
+			perMonth := input.PayPeriod.PeriodsPerMonth()
+			table := rt.ISRTable(input.PayPeriod)
+			slipGross := grossMonthlySalary.DivRate(perMonth)
+			slipISR := calculateISR(tr, "Sueldo y horas extra", slipGross+taxable.DivRate(perMonth), table) - CalculateISR(slipGross, table)
+			extraISR := slipISR.Mul(perMonth)
+			result.OvertimeISR = extraISR.Mul(overtimeTaxable.Ratio(taxable))
+			result.PrimaDominicalISR = extraISR - result.OvertimeISR
+		}
+
+		result.OvertimeNet = pay.Gross() - result.OvertimeISR
+		result.PrimaDominicalNet = pay.PrimaDominical - result.PrimaDominicalISR
+		result.NetSalary += result.OvertimeNet + result.PrimaDominicalNet
+	}
+
+	// Apply Fondo de Ahorro monthly deduction
+	if input.HasFondoAhorro {
+		monthlyDeduction := grossMonthlySalary.Mul(money.Percent(input.FondoAhorroPercent))
+		// Cap at 1.3 UMA annually / 12
+		maxMonthlyDeduction := fiscalYear.UMAAnnual.Mul(fondoAhorroCapUMAs).Div(12)
+		if monthlyDeduction > maxMonthlyDeduction {
+			monthlyDeduction = maxMonthlyDeduction
+		}
+		result.FondoAhorroEmployee = monthlyDeduction
+		result.NetSalary -= monthlyDeduction
+	}
+
+	// Apply Infonavit credit discount (mortgage amortization + seguro de daños)
+	if input.HasInfonavitCredit && input.InfonavitCredit.Value > 0 {
+		discount, insurance, err := CalculateInfonavitDiscount(rt, input.InfonavitCredit, result.SBC)
+		if err != nil {
+			return result, err
+		}
+		result.InfonavitInsurance = insurance
+		result.InfonavitDiscount = discount + insurance
+		result.NetSalary -= result.InfonavitDiscount
+	}
+
+	// Add Vales de Despensa to monthly net (tax-free, max 1 UMA monthly)
+	if input.HasValesDespensa {
+		monthlyVales := input.ValesDespensaAmount
+		if monthlyVales > fiscalYear.UMAMonthly {
+			monthlyVales = fiscalYear.UMAMonthly
+		}
+		result.ValesDespensaMonthly = monthlyVales
+		result.NetSalary += monthlyVales
+	}
+
+	// Process Other Benefits (Otras prestaciones) - separate monthly and annual
+	var otherBenefitsMonthlyNet money.Money
+	var otherBenefitsAnnualNet money.Money
+
+	for _, benefit := range input.OtherBenefits {
+		benefitAmount := otherBenefitAmount(benefit, grossMonthlySalary*12, input.ExchangeRate)
+
+		benefitResult := database.OtherBenefitResult{
+			Name:    benefit.Name,
+			Amount:  benefitAmount,
+			TaxFree: benefit.TaxFree,
+			Cadence: benefit.Cadence,
+		}
+
+		if benefit.TaxFree {
+			// Tax-free benefits
+			benefitResult.ISR = 0
+			benefitResult.Net = benefitAmount
+		} else {
+			// Use Article 174 method for annual bonuses (considers base salary)
+			// Use standard ISR for monthly benefits (isolated calculation)
+			if benefit.Cadence == "annual" {
+				benefitResult.ISR = calculateTaxArt174(tr, benefit.Name, grossMonthlySalary, benefitAmount, rt.isrBrackets)
+			} else {
+				benefitResult.ISR = calculateISR(tr, benefit.Name, benefitAmount, rt.isrBrackets)
+			}
+			benefitResult.Net = benefitAmount - benefitResult.ISR
+		}
+
+		result.OtherBenefits = append(result.OtherBenefits, benefitResult)
+
+		// Add to monthly or annual based on cadence
+		if benefit.Cadence == "annual" {
+			otherBenefitsAnnualNet += benefitResult.Net
+		} else {
+			// Default to monthly
+			otherBenefitsMonthlyNet += benefitResult.Net
+		}
+	}
+
+	// Add monthly other benefits to monthly net
+	result.OtherBenefitsMonthlyNet = otherBenefitsMonthlyNet
+	result.NetSalary += otherBenefitsMonthlyNet
+
+	// Calculate yearly components (paid once per year)
+	dailySalary := grossMonthlySalary.DivRate(daysPerMonth)
+
+	// 1. Aguinaldo (subject to ISR with 30 UMA exemption per LISR Article 93, not subject to IMSS)
+	if input.HasAguinaldo {
+		result.AguinaldoGross = dailySalary.Mul(money.Int(input.AguinaldoDays))
+
+		// LISR Article 93: Aguinaldo is exempt from ISR up to 30 UMAs
+		exemptAmount := fiscalYear.UMADaily * aguinaldoExemptUMAs
+
+		// Only the amount exceeding the exemption is taxable
+		taxableBase := taxableOverExemption(tr, "Aguinaldo", "30 UMA", result.AguinaldoGross, exemptAmount)
+
+		// Calculate ISR on taxable base using Article 174 (progressive method)
+		result.AguinaldoISR = calculateTaxArt174(tr, "Aguinaldo", grossMonthlySalary, taxableBase, rt.isrBrackets)
+		result.AguinaldoNet = result.AguinaldoGross - result.AguinaldoISR
+	}
+
+	// 2. Prima Vacacional (subject to ISR with 15 UMA exemption per LISR Article 93, not subject to IMSS)
+	if input.HasPrimaVacacional {
+		vacationSalary := dailySalary.Mul(money.Int(input.VacationDays))
+		result.PrimaVacacionalGross = vacationSalary.Mul(money.Percent(input.PrimaVacacionalPercent))
+
+		// LISR Article 93: Prima Vacacional is exempt from ISR up to 15 UMAs
+		exemptAmount := fiscalYear.UMADaily * primaVacacionalExempt
+
+		// Only the amount exceeding the exemption is taxable
+		taxableBase := taxableOverExemption(tr, "Prima vacacional", "15 UMA", result.PrimaVacacionalGross, exemptAmount)
+
+		// Calculate ISR on taxable base using Article 174 (progressive method)
+		result.PrimaVacacionalISR = calculateTaxArt174(tr, "Prima vacacional", grossMonthlySalary, taxableBase, rt.isrBrackets)
+		result.PrimaVacacionalNet = result.PrimaVacacionalGross - result.PrimaVacacionalISR
+	}
+
+	// 3. PTU (capped per LFT Article 127, 15 UMA exemption per LISR Article 93, not subject to IMSS)
+	if input.HasPTU {
+		ptuGross, capped, err := CalculatePTU(input.PTU, grossMonthlySalary)
+		if err != nil {
+			return result, err
+		}
+		result.PTUGross = ptuGross
+		result.PTUCapped = capped
+
+		// LISR Article 93: PTU is exempt from ISR up to 15 UMAs
+		exemptAmount := fiscalYear.UMADaily * ptuExemptUMAs
+
+		// Only the amount exceeding the exemption is taxable
+		taxableBase := taxableOverExemption(tr, "PTU", "15 UMA", result.PTUGross, exemptAmount)
+
+		// Calculate ISR on taxable base using Article 174 (progressive method)
+		result.PTUISR = calculateTaxArt174(tr, "PTU", grossMonthlySalary, taxableBase, rt.isrBrackets)
+		result.PTUNet = result.PTUGross - result.PTUISR
+	}
+
+	// 4. Fondo de Ahorro yearly return (company returns 2x employee contribution)
+	if input.HasFondoAhorro {
+		yearlyEmployeeContribution := result.FondoAhorroEmployee * 12
+		// Company matches 100% (returns 2x what was deducted)
+		result.FondoAhorroYearly = yearlyEmployeeContribution * 2
+	}
+
+	// 5. Infonavit Employer Contribution (Art 29, Ley Infonavit)
+	// Employers pay 5% of SBC (already capped at 25 UMAs)
+	// Paid bimonthly but shown as monthly equivalent
+	// This is NON-LIQUID (goes to housing fund, not employee's pocket)
+	monthlySBC := result.SBC.Mul(daysPerMonth) // Daily SBC to Monthly
+	result.InfonavitEmployerMonthly = monthlySBC.Mul(infonavitEmployerRate)
+	result.InfonavitEmployerAnnual = result.InfonavitEmployerMonthly * 12
+	result.HasInfonavitCredit = input.HasInfonavitCredit // Flag to determine if it's mortgage payment or savings
+
+	// 6. IMSS Employer Contributions (Non-liquid, part of total comp)
+	riskPremium, err := rt.RiskPremium(input.WorkRisk)
+	if err != nil {
+		return result, err
+	}
+	result.RiskClass = string(input.WorkRisk.Class)
+	result.RiskPremium = riskPremium
+	imssEmployer := calculateIMSSEmployer(rt, result.SBC, riskPremium, tr)
+	result.IMSSEmployerMonthly = imssEmployer
+	result.IMSSEmployerAnnual = imssEmployer * 12
+
+	// Calculate yearly totals
+	result.YearlyGrossBase = grossMonthlySalary * 12 // Compensation bruta anual (solo salario)
+
+	// YearlyGross includes:
+	// - Base salary (12 months)
+	// - Overtime and prima dominical (12 months)
+	// - Aguinaldo
+	// - Prima Vacacional
+	// - PTU
+	// - Infonavit Employer (12 months) - Non-liquid but part of total comp
+	// - IMSS Employer (12 months) - Non-liquid but part of total comp
+	result.YearlyGross = result.YearlyGrossBase + (result.OvertimeDouble+result.OvertimeTriple+result.PrimaDominicalGross)*12 + result.AguinaldoGross + result.PrimaVacacionalGross + result.PTUGross +
+		(result.InfonavitEmployerMonthly * 12) + (result.IMSSEmployerMonthly * 12)
+	result.YearlyNet = (result.NetSalary * 12) + result.AguinaldoNet + result.PrimaVacacionalNet + result.PTUNet + result.FondoAhorroYearly + otherBenefitsAnnualNet
+	result.MonthlyAdjusted = result.YearlyNet.Div(12)
+
+	// Cost to the company, including the state payroll tax
+	if input.State != "" {
+		cost, err := CalculateEmployerCost(rt, result, input.State)
+		if err != nil {
+			return result, err
+		}
+		result.EmployerCost = &cost
+	}
+
+	// Long-term value of the retirement contributions
+	if input.Afore.Years > 0 {
+		projection, err := ProjectAfore(rt, result, input.Afore)
+		if err != nil {
+			return result, err
+		}
+		result.Afore = &projection
+	}
+
+	result.Trace = tr.result()
+
+	return result, nil
+}
+
+// CalculateSalary performs the base monthly payroll calculation (ISR, subsidio, IMSS, SBC)
+// with the statutory benefits for the given years of service and zone
+func CalculateSalary(rt *RateTables, grossMonthlySalary money.Money, yearsOfService int, zone Zone) database.SalaryCalculation {
+	return calculateMonthly(rt, withStatutoryDefaults(rt, SalaryInput{
+		GrossMonthlySalary: grossMonthlySalary,
+		YearsOfService:     yearsOfService,
+		Zone:               zone,
+	}), nil)
+}
+
+// calculateMonthly computes ISR, subsidio, SBC and IMSS for one month of salary
+func calculateMonthly(rt *RateTables, input SalaryInput, tr *tracer) database.SalaryCalculation {
+	fiscalYear := rt.fiscalYear
+	grossMonthlySalary := input.GrossMonthlySalary
+
+	result := database.SalaryCalculation{
+		GrossSalary:  grossMonthlySalary,
+		VacationDays: input.VacationDays,
+		Zone:         string(input.Zone),
+	}
+
+	// Calculate Subsidio al Empleo (if applicable)
+	var subsidio money.Money
+	if grossMonthlySalary <= fiscalYear.SubsidyThresholdMonthly {
+		subsidio = grossMonthlySalary.Mul(fiscalYear.SubsidyFactor)
+	}
+
+	// Calculate SBC (Salario Base de Cotización)
+	result.IntegrationFactor = IntegrationFactor(rt, input)
+	result.SBC = CalculateSBC(rt, input)
+
+	// Calculate IMSS Worker contributions (on the SBC)
+	result.IMSSWorker = CalculateIMSSWorker(rt, result.SBC)
+
+	if input.PayPeriod == "" || input.PayPeriod == PeriodMonthly {
+		// Calculate ISR Tax
+		result.ISRTax = calculateISR(tr, "Sueldo", grossMonthlySalary, rt.isrBrackets)
+		result.SubsidioEmpleo = subsidio
+	} else {
+		// Withhold each payslip with its own tariff; the month is the sum of its payslips
+		slip := calculatePayslip(rt, input, subsidio, result.IMSSWorker, tr)
+		perMonth := input.PayPeriod.PeriodsPerMonth()
+
+		result.Payslip = &slip
+		result.ISRTax = slip.ISRTax.Mul(perMonth)
+		result.SubsidioEmpleo = slip.SubsidioEmpleo.Mul(perMonth)
+		result.IMSSWorker = slip.IMSSWorker.Mul(perMonth)
+	}
+
+	// Calculate Net Salary
+	// Net = Gross - ISR + Subsidio - IMSS - Other Deductions
+	result.NetSalary = grossMonthlySalary - result.ISRTax + result.SubsidioEmpleo - result.IMSSWorker
+
+	return result
+}
+
+// CalculateISR calculates the ISR tax based on progressive brackets. The tax on
+// the surplus is rounded to the centavo before adding the fixed fee.
+func CalculateISR(grossSalary money.Money, brackets []database.ISRBracket) money.Money {
+	return calculateISR(nil, "", grossSalary, brackets)
+}
+
+// calculateISR is CalculateISR recording the bracket applied to the label's income
+func calculateISR(tr *tracer, label string, grossSalary money.Money, brackets []database.ISRBracket) money.Money {
+	for _, bracket := range brackets {
+		if grossSalary >= bracket.LowerLimit && grossSalary <= bracket.UpperLimit {
+			surplus := grossSalary - bracket.LowerLimit
+			tax := bracket.FixedFee + surplus.Mul(bracket.SurplusPercent)
+			tr.isr(database.ISRStep{
+				Label:          label,
+				Periodicity:    bracket.Periodicity,
+				Base:           grossSalary,
+				LowerLimit:     bracket.LowerLimit,
+				UpperLimit:     bracket.UpperLimit,
+				FixedFee:       bracket.FixedFee,
+				SurplusPercent: bracket.SurplusPercent,
+				Surplus:        surplus,
+				Tax:            tax,
+			})
+			return tax
+		}
+	}
+	return 0
+}
+
+// CalculateTaxArt174 calculates ISR on annual bonuses using Article 174 methodology
+// This prevents under-taxation by considering that the base salary has already consumed lower brackets
+func CalculateTaxArt174(grossMonthlySalary, annualBonusAmount money.Money, brackets []database.ISRBracket) money.Money {
+	return calculateTaxArt174(nil, "", grossMonthlySalary, annualBonusAmount, brackets)
+}
+
+// calculateTaxArt174 is CalculateTaxArt174 recording its intermediate values
+// for the label's payment
+func calculateTaxArt174(tr *tracer, label string, grossMonthlySalary, annualBonusAmount money.Money, brackets []database.ISRBracket) money.Money {
+	if annualBonusAmount <= 0 {
+		return 0
+	}
+
+	// Step A: Convert bonus to daily rate, then to monthly equivalent
+	// This represents what the bonus would be if spread over the year
+	remuneracionMensual := annualBonusAmount.Mul(daysPerMonth.Div(money.Int(365)))
+
+	// Step B: Calculate partial tax
+	// Tax on (Salary + Monthly Share of Bonus)
+	taxOnTotal := CalculateISR(grossMonthlySalary+remuneracionMensual, brackets)
+
+	// Tax on Salary alone
+	taxOnSalary := CalculateISR(grossMonthlySalary, brackets)
+
+	// Tax attributable to the monthly share of the bonus
+	taxOnShare := taxOnTotal - taxOnSalary
+
+	// Step C: Calculate effective rate
+	// This is the marginal rate at which this bonus should be taxed
+	effectiveRate := taxOnShare.Ratio(remuneracionMensual)
+
+	// Step D: Apply rate to full bonus
+	tax := annualBonusAmount.Mul(effectiveRate)
+
+	tr.art174(database.Art174Step{
+		Label:         label,
+		Amount:        annualBonusAmount,
+		MonthlyShare:  remuneracionMensual,
+		TaxOnTotal:    taxOnTotal,
+		TaxOnSalary:   taxOnSalary,
+		TaxOnShare:    taxOnShare,
+		EffectiveRate: effectiveRate,
+		Tax:           tax,
+	})
+
+	return tax
+}
+
+// taxableOverExemption returns the part of a yearly payment above its UMA
+// exemption, recording the exemption under the concept and its legal rule
+func taxableOverExemption(tr *tracer, concept, rule string, gross, exemptAmount money.Money) money.Money {
+	taxable := max(0, gross-exemptAmount)
+
+	tr.exemption(database.ExemptionStep{
+		Concept: concept,
+		Rule:    rule,
+		Limit:   exemptAmount,
+		Gross:   gross,
+		Exempt:  gross - taxable,
+		Taxable: taxable,
+	})
+
+	return taxable
+}
+
+// IMSSBase mirrors the imss_base_type enum: what a concept's percentages apply to
+type IMSSBase string
+
+const (
+	IMSSBaseSBC       IMSSBase = "SBC"        // SBC capped at the concept's UMA limit; empty means SBC
+	IMSSBaseUMAFixed  IMSSBase = "UMA_FIXED"  // One UMA per day, whatever the salary (cuota fija)
+	IMSSBaseExcessUMA IMSSBase = "EXCESS_UMA" // Capped SBC above the concept's ExcessOverUMAs (excedente)
+)
+
+// CalculateIMSSWorker calculates the worker's monthly IMSS contributions on the daily SBC
+// Each concept is rounded to the centavo, as the SUA does.
+func CalculateIMSSWorker(rt *RateTables, dailySBC money.Money) money.Money {
+	var total money.Money
+
+	for _, concept := range rt.imssConcepts {
+		// Worker pays fixed 1.125% for Cesantía, the progressive part is for employer
+		total += conceptMonthlyBase(rt, concept, dailySBC).Mul(concept.WorkerPercent)
+	}
+
+	return total
+}
+
+// CalculateIMSSEmployer calculates the employer's monthly IMSS contributions on the daily SBC
+// with the company's prima de riesgo de trabajo (see RateTables.RiskPremium)
+// This is NON-LIQUID compensation (doesn't go to employee's pocket)
+func CalculateIMSSEmployer(rt *RateTables, dailySBC money.Money, riskPremium money.Rate) money.Money {
+	return calculateIMSSEmployer(rt, dailySBC, riskPremium, nil)
+}
+
+// calculateIMSSEmployer is CalculateIMSSEmployer recording every concept with
+// its capped base and both the worker and employer shares
+func calculateIMSSEmployer(rt *RateTables, dailySBC money.Money, riskPremium money.Rate, tr *tracer) money.Money {
+	var total money.Money
+
+	for _, concept := range rt.imssConcepts {
+		monthlyBase := conceptMonthlyBase(rt, concept, dailySBC)
+		percent := employerPercent(rt, concept, dailySBC, riskPremium, tr)
+		contribution := monthlyBase.Mul(percent)
+		total += contribution
+
+		tr.imss(database.IMSSStep{
+			Concept:         concept.ConceptName,
+			BaseType:        concept.BaseType,
+			DailyBase:       conceptDailyBase(rt, concept, dailySBC),
+			Capped:          concept.BaseCapInUMAs > 0 && dailySBC >= rt.fiscalYear.UMADaily.Mul(money.Int(concept.BaseCapInUMAs)),
+			MonthlyBase:     monthlyBase,
+			WorkerPercent:   concept.WorkerPercent,
+			Worker:          monthlyBase.Mul(concept.WorkerPercent),
+			EmployerPercent: percent,
+			Employer:        contribution,
+		})
+	}
+
+	return total
+}
+
+// employerContribution calculates the employer's monthly contribution to one IMSS concept
+func employerContribution(rt *RateTables, concept database.IMSSConcept, dailySBC money.Money, riskPremium money.Rate) money.Money {
+	return conceptMonthlyBase(rt, concept, dailySBC).Mul(employerPercent(rt, concept, dailySBC, riskPremium, nil))
+}
+
+// employerPercent returns the employer's rate of one IMSS concept, recording
+// the Cesantía bracket the SBC falls in
+func employerPercent(rt *RateTables, concept database.IMSSConcept, dailySBC money.Money, riskPremium money.Rate, tr *tracer) money.Rate {
+	// Riesgo de Trabajo depends on the company, not on the seeded rate
+	if concept.ConceptName == conceptRiesgoTrabajo {
+		return riskPremium
+	}
+
+	// Special handling for Cesantía (progressive for employer)
+	if !concept.IsFixedRate && concept.ConceptName == conceptCesantia {
+		salaryInUMAs := dailySBC.Ratio(rt.fiscalYear.UMADaily)
+		bracket, found := rt.cesantiaBracket(salaryInUMAs)
+		if found {
+			// Employer pays progressive rate based on bracket
+			tr.cesantia(database.CesantiaStep{
+				SalaryInUMAs:    salaryInUMAs.Round(3),
+				LowerBoundUMA:   bracket.LowerBoundUMA,
+				UpperBoundUMA:   bracket.UpperBoundUMA,
+				EmployerPercent: bracket.EmployerPercent,
+			})
+			return bracket.EmployerPercent
+		}
+	}
+
+	return concept.EmployerPercent
+}
+
+// IntegrationFactor returns the Factor de Integración (LSS Art. 27): one plus the
+// daily share of aguinaldo and prima vacacional. The statutory minimum for the
+// employee's seniority applies unless the company's benefits are higher.
+func IntegrationFactor(rt *RateTables, input SalaryInput) money.Rate {
+	statutory := rt.SeniorityBenefit(input.YearsOfService)
+
+	aguinaldoDays := statutory.AguinaldoDays
+	if input.HasAguinaldo && input.AguinaldoDays > aguinaldoDays {
+		aguinaldoDays = input.AguinaldoDays
+	}
+
+	vacationDays := statutory.VacationDays
+	primaPercent := statutory.PrimaVacacionalPercent
+	if input.HasPrimaVacacional {
+		if input.VacationDays > vacationDays {
+			vacationDays = input.VacationDays
+		}
+		if percent := money.Percent(input.PrimaVacacionalPercent); percent > primaPercent {
+			primaPercent = percent
+		}
+	}
+
+	factor := money.One + (money.Int(aguinaldoDays) + money.Int(vacationDays).Mul(primaPercent)).Div(money.Int(365))
+
+	return factor.Round(4) // IMSS uses 4 decimals
+}
+
+// CalculateSBC calculates the daily Salario Base de Cotización: the daily salary
+// times the integration factor plus integrable benefits and overtime, capped at 25 UMAs. The
+// daily salary is never below the zone's minimum wage.
+func CalculateSBC(rt *RateTables, input SalaryInput) money.Money {
+	fiscalYear := rt.fiscalYear
+
+	dailySalary := max(input.GrossMonthlySalary.DivRate(daysPerMonth), MinimumDailyWage(rt, input.Zone))
+	sbc := dailySalary.Mul(IntegrationFactor(rt, input)) + integrableVales(rt, input) + integrableOvertime(rt, input)
+
+	// Cap at 25 UMAs
+	maxSBC := fiscalYear.UMADaily * 25
+	if sbc > maxSBC {
+		sbc = maxSBC
+	}
+
+	return sbc
+}
+
+// integrableVales returns the daily portion of vales de despensa that exceeds the
+// pantry_vouchers_uma_cap (40% of the daily UMA) and therefore integrates to the SBC
+func integrableVales(rt *RateTables, input SalaryInput) money.Money {
+	if !input.HasValesDespensa {
+		return 0
+	}
+
+	dailyVales := input.ValesDespensaAmount.DivRate(daysPerMonth)
+	threshold := rt.fiscalYear.UMADaily.Mul(rt.fiscalYear.PantryVouchersUMACap)
+
+	return max(0, dailyVales-threshold)
+}
+
+// withStatutoryDefaults fills unset benefit days and percentages with the
+// statutory values for the employee's years of service
+func withStatutoryDefaults(rt *RateTables, input SalaryInput) SalaryInput {
+	statutory := rt.SeniorityBenefit(input.YearsOfService)
+
+	if input.AguinaldoDays <= 0 {
+		input.AguinaldoDays = statutory.AguinaldoDays
+	}
+	if input.VacationDays <= 0 {
+		input.VacationDays = statutory.VacationDays
+	}
+	if input.PrimaVacacionalPercent <= 0 {
+		input.PrimaVacacionalPercent = statutory.PrimaVacacionalPercent.Float64() * 100
+	}
+
+	return input
+}
+
+// conceptMonthlyBase returns the monthly amount a concept's percentages apply to:
+// one UMA a day for a cuota fija, the capped SBC above the concept's UMAs for an
+// excedente, or the capped SBC
+func conceptMonthlyBase(rt *RateTables, concept database.IMSSConcept, dailySBC money.Money) money.Money {
+	return conceptDailyBase(rt, concept, dailySBC).Mul(daysPerMonth)
+}
+
+// conceptDailyBase returns the daily amount behind conceptMonthlyBase
+func conceptDailyBase(rt *RateTables, concept database.IMSSConcept, dailySBC money.Money) money.Money {
+	switch IMSSBase(concept.BaseType) {
+	case IMSSBaseUMAFixed:
+		return rt.fiscalYear.UMADaily
+	case IMSSBaseExcessUMA:
+		excess := cappedDailyBase(dailySBC, concept, rt.fiscalYear) - rt.fiscalYear.UMADaily.Mul(money.Int(concept.ExcessOverUMAs))
+		return max(0, excess)
+	default:
+		return cappedDailyBase(dailySBC, concept, rt.fiscalYear)
+	}
+}
+
+// cappedDailyBase caps the daily salary at the concept's UMA limit (usually 25 UMAs)
+func cappedDailyBase(dailySalary money.Money, concept database.IMSSConcept, fiscalYear database.FiscalYear) money.Money {
+	if concept.BaseCapInUMAs > 0 {
+		maxBase := fiscalYear.UMADaily.Mul(money.Int(concept.BaseCapInUMAs))
+		if dailySalary > maxBase {
+			return maxBase
+		}
+	}
+	return dailySalary
+}
+
+// otherBenefitAmount resolves a benefit to MXN (percentage of gross annual salary or fixed amount)
+func otherBenefitAmount(benefit OtherBenefit, grossAnnualSalary money.Money, exchangeRate money.Rate) money.Money {
+	if benefit.IsPercentage {
+		// Percentage of gross annual salary
+		return grossAnnualSalary.Mul(money.Percent(benefit.Amount))
+	}
+	// Fixed amount - convert to MXN if needed
+	if benefit.Currency == "USD" {
+		return money.FromFloat(benefit.Amount).Mul(exchangeRate)
+	}
+	return money.FromFloat(benefit.Amount)
+}
@@ -0,0 +1,404 @@
+package payroll
+
+import (
+	"testing"
+
+	"github.com/jcroyoaun/totalcompmx/internal/assert"
+	"github.com/jcroyoaun/totalcompmx/internal/database"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+// mxn parses a peso amount, e.g. mxn("113.14")
+func mxn(amount string) money.Money {
+	return money.MustParse(amount)
+}
+
+// rate parses a decimal rate, e.g. rate("0.0192")
+func rate(r string) money.Rate {
+	return money.MustParseRate(r)
+}
+
+// newTestRateTables returns the 2025 tables as seeded by the migrations
+func newTestRateTables() *RateTables {
+	fiscalYear := database.FiscalYear{
+		ID:                      1,
+		Year:                    2025,
+		UMADaily:                mxn("113.14"),
+		UMAMonthly:              mxn("3439.46"),
+		UMAAnnual:               mxn("41273.52"),
+		UMIValue:                mxn("113.14"),
+		SMGGeneral:              mxn("278.80"),
+		SMGBorder:               mxn("419.88"),
+		SubsidyFactor:           rate("0.1380"),
+		SubsidyThresholdMonthly: mxn("10171.00"),
+		FALegalCapUMAFactor:     rate("1.30"),
+		FALegalMaxPercentage:    rate("0.13"),
+		PantryVouchersUMACap:    rate("0.40"),
+		USDMXNRate:              rate("20.00"),
+
+		InfonavitInsuranceBimonthly: mxn("15.00"),
+	}
+
+	isrBrackets := []database.ISRBracket{
+		{LowerLimit: mxn("0.01"), UpperLimit: mxn("746.04"), FixedFee: mxn("0.00"), SurplusPercent: rate("0.0192")},
+		{LowerLimit: mxn("746.05"), UpperLimit: mxn("6332.05"), FixedFee: mxn("14.32"), SurplusPercent: rate("0.0640")},
+		{LowerLimit: mxn("6332.06"), UpperLimit: mxn("11128.01"), FixedFee: mxn("371.83"), SurplusPercent: rate("0.1088")},
+		{LowerLimit: mxn("11128.02"), UpperLimit: mxn("12935.82"), FixedFee: mxn("893.63"), SurplusPercent: rate("0.1600")},
+		{LowerLimit: mxn("12935.83"), UpperLimit: mxn("15487.71"), FixedFee: mxn("1182.88"), SurplusPercent: rate("0.1792")},
+		{LowerLimit: mxn("15487.72"), UpperLimit: mxn("31236.49"), FixedFee: mxn("1640.18"), SurplusPercent: rate("0.2136")},
+		{LowerLimit: mxn("31236.50"), UpperLimit: mxn("49233.00"), FixedFee: mxn("5004.12"), SurplusPercent: rate("0.2352")},
+		{LowerLimit: mxn("49233.01"), UpperLimit: mxn("93993.90"), FixedFee: mxn("9236.89"), SurplusPercent: rate("0.3000")},
+		{LowerLimit: mxn("93993.91"), UpperLimit: mxn("125325.20"), FixedFee: mxn("22665.17"), SurplusPercent: rate("0.3200")},
+		{LowerLimit: mxn("125325.21"), UpperLimit: mxn("375975.61"), FixedFee: mxn("32691.18"), SurplusPercent: rate("0.3400")},
+		{LowerLimit: mxn("375975.62"), UpperLimit: mxn("999999999.99"), FixedFee: mxn("117912.32"), SurplusPercent: rate("0.3500")},
+	}
+
+	imssConcepts := []database.IMSSConcept{
+		{ConceptName: "Enfermedad y Maternidad (Cuota Fija)", WorkerPercent: rate("0.00000"), EmployerPercent: rate("0.20400"), BaseCapInUMAs: 0, IsFixedRate: true, BaseType: "UMA_FIXED"},
+		{ConceptName: "Enfermedad y Maternidad (Excedente)", WorkerPercent: rate("0.00400"), EmployerPercent: rate("0.01100"), BaseCapInUMAs: 25, IsFixedRate: true, BaseType: "EXCESS_UMA", ExcessOverUMAs: 3},
+		{ConceptName: "Enfermedad y Maternidad (Gastos Médicos Pensionados)", WorkerPercent: rate("0.00375"), EmployerPercent: rate("0.01050"), BaseCapInUMAs: 25, IsFixedRate: true, BaseType: "SBC"},
+		{ConceptName: "Enfermedad y Maternidad (Prestaciones en Dinero)", WorkerPercent: rate("0.00250"), EmployerPercent: rate("0.00700"), BaseCapInUMAs: 25, IsFixedRate: true, BaseType: "SBC"},
+		{ConceptName: "Invalidez y Vida", WorkerPercent: rate("0.00625"), EmployerPercent: rate("0.01750"), BaseCapInUMAs: 25, IsFixedRate: true, BaseType: "SBC"},
+		{ConceptName: "Guarderías y Prestaciones Sociales", WorkerPercent: rate("0.00000"), EmployerPercent: rate("0.01000"), BaseCapInUMAs: 25, IsFixedRate: true, BaseType: "SBC"},
+		{ConceptName: "Retiro", WorkerPercent: rate("0.00000"), EmployerPercent: rate("0.02000"), BaseCapInUMAs: 25, IsFixedRate: true, BaseType: "SBC"},
+		{ConceptName: "Cesantía en Edad Avanzada y Vejez", WorkerPercent: rate("0.01125"), EmployerPercent: rate("0.00000"), BaseCapInUMAs: 25, IsFixedRate: false, BaseType: "SBC"},
+		{ConceptName: "Riesgo de Trabajo", WorkerPercent: rate("0.00000"), EmployerPercent: rate("0.00500"), BaseCapInUMAs: 25, IsFixedRate: true, BaseType: "SBC"},
+	}
+
+	cesantiaBrackets := []database.CesantiaBracket{
+		{LowerBoundUMA: rate("1.000"), UpperBoundUMA: rate("1.500"), EmployerPercent: rate("0.03150"), SocialQuotaDaily: mxn("13.32")},
+		{LowerBoundUMA: rate("1.501"), UpperBoundUMA: rate("2.000"), EmployerPercent: rate("0.03281"), SocialQuotaDaily: mxn("12.71")},
+		{LowerBoundUMA: rate("2.001"), UpperBoundUMA: rate("2.500"), EmployerPercent: rate("0.03412"), SocialQuotaDaily: mxn("12.09")},
+		{LowerBoundUMA: rate("2.501"), UpperBoundUMA: rate("3.000"), EmployerPercent: rate("0.03544"), SocialQuotaDaily: mxn("11.48")},
+		{LowerBoundUMA: rate("3.001"), UpperBoundUMA: rate("3.500"), EmployerPercent: rate("0.03863"), SocialQuotaDaily: mxn("10.87")},
+		{LowerBoundUMA: rate("3.501"), UpperBoundUMA: rate("4.000"), EmployerPercent: rate("0.04181"), SocialQuotaDaily: mxn("10.26")},
+		{LowerBoundUMA: rate("4.001"), UpperBoundUMA: rate("4.500"), EmployerPercent: rate("0.04500")},
+		{LowerBoundUMA: rate("4.501"), UpperBoundUMA: rate("5.000"), EmployerPercent: rate("0.04819")},
+		{LowerBoundUMA: rate("5.001"), UpperBoundUMA: rate("5.500"), EmployerPercent: rate("0.05137")},
+		{LowerBoundUMA: rate("5.501"), UpperBoundUMA: rate("6.000"), EmployerPercent: rate("0.05456")},
+		{LowerBoundUMA: rate("6.001"), UpperBoundUMA: rate("6.500"), EmployerPercent: rate("0.05775")},
+		{LowerBoundUMA: rate("6.501"), UpperBoundUMA: rate("7.000"), EmployerPercent: rate("0.06093")},
+		{LowerBoundUMA: rate("7.001"), UpperBoundUMA: rate("7.500"), EmployerPercent: rate("0.06412")},
+		{LowerBoundUMA: rate("7.501"), UpperBoundUMA: rate("8.000"), EmployerPercent: rate("0.06731")},
+		{LowerBoundUMA: rate("8.001"), UpperBoundUMA: rate("8.500"), EmployerPercent: rate("0.07049")},
+		{LowerBoundUMA: rate("8.501"), UpperBoundUMA: rate("9.000"), EmployerPercent: rate("0.07368")},
+		{LowerBoundUMA: rate("9.001"), UpperBoundUMA: rate("9.500"), EmployerPercent: rate("0.07686")},
+		{LowerBoundUMA: rate("9.501"), UpperBoundUMA: rate("10.000"), EmployerPercent: rate("0.08005")},
+		{LowerBoundUMA: rate("10.001"), UpperBoundUMA: rate("999.999"), EmployerPercent: rate("0.08324")},
+	}
+
+	resicoBrackets := []database.RESICOBracket{
+		{UpperLimit: mxn("25000.00"), ApplicableRate: rate("0.0100")},
+		{UpperLimit: mxn("50000.00"), ApplicableRate: rate("0.0110")},
+		{UpperLimit: mxn("83333.33"), ApplicableRate: rate("0.0150")},
+		{UpperLimit: mxn("208333.33"), ApplicableRate: rate("0.0200")},
+		{UpperLimit: mxn("9999999.99"), ApplicableRate: rate("0.0250")},
+	}
+
+	retentionRules := []database.RetentionRule{
+		{RegimeName: "RESICO", ClientType: "PERSONA_MORAL", RetentionRate: rate("0.0125")},
+		{RegimeName: "RESICO", ClientType: "PERSONA_FISICA", RetentionRate: rate("0.0000")},
+		{RegimeName: "ACTIVIDAD_EMPRESARIAL", ClientType: "PERSONA_MORAL", RetentionRate: rate("0.1000")},
+		{RegimeName: "ACTIVIDAD_EMPRESARIAL", ClientType: "PERSONA_FISICA", RetentionRate: rate("0.0000")},
+	}
+
+	// Vacaciones Dignas: 12 days in the first year, two more per year
+	var seniority []database.SeniorityBenefit
+	for year := 1; year <= 20; year++ {
+		seniority = append(seniority, database.SeniorityBenefit{
+			YearsOfService:         year,
+			VacationDays:           10 + 2*year,
+			PrimaVacacionalPercent: rate("0.25"),
+			AguinaldoDays:          15,
+		})
+	}
+
+	stateTaxes := []database.StatePayrollTax{
+		{StateCode: "CDMX", StateName: "Ciudad de México", Rate: rate("0.04")},
+		{StateCode: "JAL", StateName: "Jalisco", Rate: rate("0.02")},
+	}
+
+	riskClasses := []database.RiskClass{
+		{Class: "I", Premium: rate("0.0054355")},
+		{Class: "II", Premium: rate("0.0113065")},
+		{Class: "III", Premium: rate("0.0259840")},
+		{Class: "IV", Premium: rate("0.0465325")},
+		{Class: "V", Premium: rate("0.0758875")},
+	}
+
+	return NewRateTables(fiscalYear, isrBrackets, imssConcepts, cesantiaBrackets, resicoBrackets, retentionRules, seniority, stateTaxes, riskClasses)
+}
+
+func TestNewRateTables(t *testing.T) {
+	t.Run("Snapshot is not affected by later changes to the input slices", func(t *testing.T) {
+		brackets := []database.ISRBracket{
+			{LowerLimit: mxn("0.01"), UpperLimit: mxn("999999999.99"), FixedFee: mxn("0"), SurplusPercent: rate("0.10")},
+		}
+
+		rt := NewRateTables(database.FiscalYear{}, brackets, nil, nil, nil, nil, nil, nil, nil)
+		brackets[0].SurplusPercent = rate("0.50")
+
+		assert.Equal(t, rt.ISRBrackets()[0].SurplusPercent, rate("0.10"))
+	})
+}
+
+func TestCalculateISR(t *testing.T) {
+	brackets := newTestRateTables().ISRBrackets()
+
+	tests := []struct {
+		name     string
+		gross    money.Money
+		expected money.Money
+	}{
+		{"First bracket", mxn("500.00"), mxn("9.60")},
+		{"Middle bracket", mxn("15000.00"), mxn("1552.78")},
+		{"Lower limit of a bracket", mxn("31236.50"), mxn("5004.12")},
+		{"Zero income", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, CalculateISR(tt.gross, brackets), tt.expected)
+		})
+	}
+}
+
+func TestCalculateTaxArt174(t *testing.T) {
+	brackets := newTestRateTables().ISRBrackets()
+
+	t.Run("Returns zero for a zero bonus", func(t *testing.T) {
+		assert.Equal(t, CalculateTaxArt174(mxn("30000"), 0, brackets), money.Money(0))
+	})
+
+	t.Run("Taxes the bonus at the marginal rate of the salary", func(t *testing.T) {
+		// A small bonus on a 20,000 salary stays inside the 21.36% bracket
+		assert.Equal(t, CalculateTaxArt174(mxn("20000"), mxn("1000"), brackets), mxn("213.59"))
+	})
+}
+
+func TestCalculateSalary(t *testing.T) {
+	rt := newTestRateTables()
+
+	t.Run("Applies the subsidio below the threshold", func(t *testing.T) {
+		result := CalculateSalary(rt, mxn("10000"), 1, ZoneGeneral)
+		assert.True(t, result.SubsidioEmpleo > 0)
+	})
+
+	t.Run("Does not apply the subsidio above the threshold", func(t *testing.T) {
+		result := CalculateSalary(rt, mxn("20000"), 1, ZoneGeneral)
+		assert.Equal(t, result.SubsidioEmpleo, money.Money(0))
+		// IMSS is paid on the SBC (657.89 daily x 1.0493), not on the gross salary
+		assert.Equal(t, result.SBC, mxn("690.32"))
+		assert.Equal(t, result.IMSSWorker, mxn("541.08"))
+		assert.Equal(t, result.NetSalary, result.GrossSalary-result.ISRTax-result.IMSSWorker)
+	})
+}
+
+func TestCalculateIMSSWorker(t *testing.T) {
+	rt := newTestRateTables()
+
+	t.Run("Pays no excedente up to 3 UMAs", func(t *testing.T) {
+		// 2.375% of the SBC: pensionados, prestaciones en dinero, IV and Cesantía
+		assert.Equal(t, CalculateIMSSWorker(rt, mxn("300")), mxn("216.60"))
+	})
+
+	t.Run("Adds 0.40% on the SBC above 3 UMAs", func(t *testing.T) {
+		// 361.00 on the SBC plus 0.40% of (500 - 3 x 113.14) x 30.4
+		assert.Equal(t, CalculateIMSSWorker(rt, mxn("500")), mxn("380.53"))
+	})
+}
+
+func TestCalculateIMSSEmployer(t *testing.T) {
+	rt := newTestRateTables()
+
+	t.Run("Uses the progressive Cesantía bracket", func(t *testing.T) {
+		// A daily SBC of 20,000 / 30.4 is ~5.8 UMA: cuota fija of 20.40% of the UMA,
+		// 1.10% excedente over 3 UMAs, 7% on the SBC and 5.456% Cesantía
+		assert.Equal(t, CalculateIMSSEmployer(rt, mxn("20000").DivRate(daysPerMonth), rate("0.005")), mxn("3299.34"))
+	})
+
+	t.Run("Charges the cuota fija on one UMA below 3 UMAs", func(t *testing.T) {
+		// 701.65 of cuota fija plus 10.544% of 300 x 30.4, rounded per concept
+		low := CalculateIMSSEmployer(rt, mxn("300"), rate("0.005"))
+		assert.Equal(t, low, mxn("1663.26"))
+	})
+}
+
+func TestSeniorityBenefit(t *testing.T) {
+	rt := newTestRateTables()
+
+	tests := []struct {
+		name         string
+		years        int
+		vacationDays int
+	}{
+		{"First year uses the year 1 row", 0, 12},
+		{"Exact row", 5, 20},
+		{"Past the last row keeps the last row", 30, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, rt.SeniorityBenefit(tt.years).VacationDays, tt.vacationDays)
+		})
+	}
+
+	t.Run("Falls back to the LFT minimum without seniority rows", func(t *testing.T) {
+		rt := NewRateTables(database.FiscalYear{}, nil, nil, nil, nil, nil, nil, nil, nil)
+		assert.Equal(t, rt.SeniorityBenefit(3), statutoryMinimum)
+	})
+}
+
+func TestIntegrationFactor(t *testing.T) {
+	rt := newTestRateTables()
+
+	tests := []struct {
+		name     string
+		input    SalaryInput
+		expected money.Rate
+	}{
+		{"Statutory minimum in the first year", SalaryInput{YearsOfService: 1}, rate("1.0493")},
+		{"Statutory minimum grows with seniority", SalaryInput{YearsOfService: 10}, rate("1.0616")},
+		{"Superior aguinaldo is used", SalaryInput{YearsOfService: 1, HasAguinaldo: true, AguinaldoDays: 30}, rate("1.0904")},
+		{"Lower company benefits do not reduce the factor", SalaryInput{YearsOfService: 10, HasPrimaVacacional: true, VacationDays: 12, PrimaVacacionalPercent: 25}, rate("1.0616")},
+		{"Superior prima vacacional is used", SalaryInput{YearsOfService: 1, HasPrimaVacacional: true, VacationDays: 12, PrimaVacacionalPercent: 50}, rate("1.0575")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, IntegrationFactor(rt, tt.input), tt.expected)
+		})
+	}
+}
+
+func TestCalculateSBC(t *testing.T) {
+	rt := newTestRateTables()
+
+	t.Run("Integrates vales above 40% of the daily UMA", func(t *testing.T) {
+		base := CalculateSBC(rt, SalaryInput{GrossMonthlySalary: mxn("30400"), YearsOfService: 1})
+		withVales := CalculateSBC(rt, SalaryInput{GrossMonthlySalary: mxn("30400"), YearsOfService: 1, HasValesDespensa: true, ValesDespensaAmount: mxn("3040")})
+
+		// 100 daily in vales, 45.26 of them exempt
+		assert.Equal(t, base, mxn("1049.30"))
+		assert.Equal(t, withVales, mxn("1104.04"))
+	})
+
+	t.Run("Vales below the threshold do not integrate", func(t *testing.T) {
+		withVales := CalculateSBC(rt, SalaryInput{GrossMonthlySalary: mxn("30400"), YearsOfService: 1, HasValesDespensa: true, ValesDespensaAmount: mxn("1000")})
+		assert.Equal(t, withVales, mxn("1049.30"))
+	})
+
+	t.Run("Caps at 25 UMAs", func(t *testing.T) {
+		assert.Equal(t, CalculateSBC(rt, SalaryInput{GrossMonthlySalary: mxn("500000"), YearsOfService: 1}), mxn("2828.50"))
+	})
+}
+
+func TestCalculateRESICO(t *testing.T) {
+	rt := newTestRateTables()
+
+	t.Run("Applies the flat rate to the total income", func(t *testing.T) {
+		result, err := CalculateRESICO(rt, RESICOInput{MonthlyIncome: mxn("30000")})
+		assert.Nil(t, err)
+		assert.Equal(t, result.ISRTax, mxn("330"))
+		assert.Equal(t, result.NetSalary, mxn("29670"))
+		assert.Equal(t, result.YearlyNet, mxn("29670")*12)
+	})
+
+	t.Run("Individuals do not retain ISR", func(t *testing.T) {
+		result, err := CalculateRESICO(rt, RESICOInput{MonthlyIncome: mxn("30000")})
+		assert.Nil(t, err)
+		assert.Equal(t, result.ClientType, "PERSONA_FISICA")
+		assert.Equal(t, result.ISRRetained, money.Money(0))
+		assert.Equal(t, result.ISRProvisionalPayment, mxn("330"))
+		assert.Equal(t, result.CashReceived, mxn("30000"))
+	})
+
+	t.Run("Companies retain 1.25 percent", func(t *testing.T) {
+		result, err := CalculateRESICO(rt, RESICOInput{MonthlyIncome: mxn("30000"), ClientType: ClientPersonaMoral})
+		assert.Nil(t, err)
+		assert.Equal(t, result.ISRRetained, mxn("375"))
+		assert.Equal(t, result.CashReceived, mxn("29625"))
+		// The retention covers the whole tax, nothing is left to pay
+		assert.Equal(t, result.ISRProvisionalPayment, money.Money(0))
+		assert.Equal(t, result.NetSalary, mxn("29670"))
+	})
+
+	t.Run("Pays the tax not covered by the retention", func(t *testing.T) {
+		result, err := CalculateRESICO(rt, RESICOInput{MonthlyIncome: mxn("100000"), ClientType: ClientPersonaMoral})
+		assert.Nil(t, err)
+		assert.Equal(t, result.ISRRetained, mxn("1250"))
+		assert.Equal(t, result.ISRProvisionalPayment, mxn("750"))
+	})
+
+	t.Run("Flags incomes above the annual cap", func(t *testing.T) {
+		below, err := CalculateRESICO(rt, RESICOInput{MonthlyIncome: mxn("290000")})
+		assert.Nil(t, err)
+		assert.False(t, below.ExceedsRESICOCap)
+
+		above, err := CalculateRESICO(rt, RESICOInput{MonthlyIncome: mxn("300000")})
+		assert.Nil(t, err)
+		assert.True(t, above.ExceedsRESICOCap)
+	})
+
+	t.Run("Rejects unknown client types", func(t *testing.T) {
+		_, err := CalculateRESICO(rt, RESICOInput{MonthlyIncome: mxn("30000"), ClientType: "GOBIERNO"})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Fails when no bracket covers the income", func(t *testing.T) {
+		rt := NewRateTables(database.FiscalYear{}, nil, nil, nil, nil, nil, nil, nil, nil)
+
+		_, err := CalculateRESICO(rt, RESICOInput{MonthlyIncome: mxn("30000")})
+		assert.NotNil(t, err)
+	})
+}
+
+func TestCalculateSalaryWithBenefits(t *testing.T) {
+	rt := newTestRateTables()
+
+	t.Run("Caps vales de despensa at one monthly UMA", func(t *testing.T) {
+		result, err := CalculateSalaryWithBenefits(rt, SalaryInput{
+			GrossMonthlySalary:  mxn("30000"),
+			HasValesDespensa:    true,
+			ValesDespensaAmount: mxn("5000"),
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, result.ValesDespensaMonthly, mxn("3439.46"))
+	})
+
+	t.Run("Exempts aguinaldo up to 30 UMA", func(t *testing.T) {
+		result, err := CalculateSalaryWithBenefits(rt, SalaryInput{
+			GrossMonthlySalary: mxn("6080"),
+			HasAguinaldo:       true,
+			AguinaldoDays:      15,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, result.AguinaldoGross, mxn("3000"))
+		assert.Equal(t, result.AguinaldoISR, money.Money(0))
+		assert.Equal(t, result.AguinaldoNet, mxn("3000"))
+	})
+
+	t.Run("Defaults vacation days to the statutory days for the seniority", func(t *testing.T) {
+		result, err := CalculateSalaryWithBenefits(rt, SalaryInput{
+			GrossMonthlySalary: mxn("30400"),
+			YearsOfService:     5,
+			HasPrimaVacacional: true,
+		})
+		assert.Nil(t, err)
+		// 20 days x 1,000 daily x 25%
+		assert.Equal(t, result.PrimaVacacionalGross, mxn("5000"))
+		assert.Equal(t, result.IntegrationFactor, rate("1.0548"))
+	})
+
+	t.Run("Converts USD other benefits with the exchange rate", func(t *testing.T) {
+		result, err := CalculateSalaryWithBenefits(rt, SalaryInput{
+			GrossMonthlySalary: mxn("30000"),
+			OtherBenefits: []OtherBenefit{
+				{Name: "Internet", Amount: 50, TaxFree: true, Currency: "USD", Cadence: "monthly"},
+			},
+			ExchangeRate: rate("20"),
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, result.OtherBenefitsMonthlyNet, mxn("1000"))
+	})
+}
@@ -0,0 +1,122 @@
+package payroll
+
+import (
+	"github.com/jcroyoaun/totalcompmx/internal/database"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+// Periodicity mirrors the periodicity_enum used by the ISR tariffs (Anexo 8)
+type Periodicity string
+
+const (
+	PeriodDaily       Periodicity = "DAILY"
+	PeriodWeekly      Periodicity = "WEEKLY"
+	PeriodBiweekly    Periodicity = "BIWEEKLY"    // Catorcenal, 14 days
+	PeriodSemimonthly Periodicity = "SEMIMONTHLY" // Quincenal, 15 days
+	PeriodMonthly     Periodicity = "MONTHLY"
+	PeriodAnnual      Periodicity = "ANNUAL" // Art. 152 annual tariff, not a pay period
+)
+
+// daysPerMonth is the 30.4-day month of the ISR tariffs, used across the engine
+var daysPerMonth = money.MustParseRate("30.4")
+
+// Valid reports whether the periodicity is one of the enum values
+func (p Periodicity) Valid() bool {
+	switch p {
+	case PeriodDaily, PeriodWeekly, PeriodBiweekly, PeriodSemimonthly, PeriodMonthly, PeriodAnnual:
+		return true
+	}
+	return false
+}
+
+// IsPayPeriod reports whether salaries can be paid with this periodicity
+func (p Periodicity) IsPayPeriod() bool {
+	return p.Valid() && p != PeriodAnnual
+}
+
+// Days returns the number of days covered by the period's ISR tariff
+func (p Periodicity) Days() money.Rate {
+	switch p {
+	case PeriodDaily:
+		return money.Int(1)
+	case PeriodWeekly:
+		return money.Int(7)
+	case PeriodBiweekly:
+		return money.Int(14)
+	case PeriodSemimonthly:
+		return money.Int(15)
+	case PeriodAnnual:
+		return money.Int(365)
+	}
+	return daysPerMonth
+}
+
+// PeriodsPerMonth returns how many pay periods make up the engine's 30.4-day
+// month. Quincenas are paid exactly twice a month regardless of their 15 days.
+func (p Periodicity) PeriodsPerMonth() money.Rate {
+	switch p {
+	case PeriodSemimonthly:
+		return money.Int(2)
+	case PeriodAnnual:
+		return money.One.Div(money.Int(12))
+	}
+	return daysPerMonth.Div(p.Days())
+}
+
+// MonthlyFromPeriod converts an amount paid every period to its monthly equivalent
+func MonthlyFromPeriod(amount money.Money, period Periodicity) money.Money {
+	return amount.Mul(period.PeriodsPerMonth())
+}
+
+// ISRTable returns a copy of the ISR tariff for the periodicity. When the table
+// was not seeded it is derived from the monthly tariff the way Anexo 8 does:
+// divided by 30.4 and multiplied by the days of the period (times 12 for the
+// annual tariff).
+func (rt *RateTables) ISRTable(period Periodicity) []database.ISRBracket {
+	if period == "" || period == PeriodMonthly {
+		return rt.ISRBrackets()
+	}
+	if table, ok := rt.isrTables[period]; ok {
+		return append([]database.ISRBracket(nil), table...)
+	}
+
+	scale := period.Days().Div(daysPerMonth)
+	if period == PeriodAnnual {
+		scale = money.Int(12)
+	}
+
+	table := make([]database.ISRBracket, len(rt.isrBrackets))
+	for i, b := range rt.isrBrackets {
+		table[i] = database.ISRBracket{
+			Periodicity:    string(period),
+			LowerLimit:     b.LowerLimit.Mul(scale),
+			UpperLimit:     b.UpperLimit.Mul(scale),
+			FixedFee:       b.FixedFee.Mul(scale),
+			SurplusPercent: b.SurplusPercent,
+		}
+		if i == 0 {
+			table[i].LowerLimit = money.Centavo
+		} else {
+			table[i].LowerLimit = table[i-1].UpperLimit + money.Centavo
+		}
+	}
+	return table
+}
+
+// calculatePayslip withholds one pay period with the period's own ISR tariff.
+// The subsidio and IMSS are the monthly amounts split evenly across the periods.
+func calculatePayslip(rt *RateTables, input SalaryInput, monthlySubsidio, monthlyIMSS money.Money, tr *tracer) database.PeriodWithholding {
+	perMonth := input.PayPeriod.PeriodsPerMonth()
+
+	slip := database.PeriodWithholding{
+		Periodicity: string(input.PayPeriod),
+		Gross:       input.GrossMonthlySalary.DivRate(perMonth),
+	}
+
+	slip.ISRTax = calculateISR(tr, "Sueldo", slip.Gross, rt.ISRTable(input.PayPeriod))
+	slip.SubsidioEmpleo = monthlySubsidio.DivRate(perMonth)
+	slip.IMSSWorker = monthlyIMSS.DivRate(perMonth)
+	slip.Net = slip.Gross - slip.ISRTax + slip.SubsidioEmpleo - slip.IMSSWorker
+
+	return slip
+}
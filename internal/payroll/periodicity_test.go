@@ -0,0 +1,126 @@
+package payroll
+
+import (
+	"testing"
+
+	"github.com/jcroyoaun/totalcompmx/internal/assert"
+	"github.com/jcroyoaun/totalcompmx/internal/database"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+// newTestRateTablesWithQuincenal adds the Anexo 8 quincenal tariff to the test tables
+func newTestRateTablesWithQuincenal() *RateTables {
+	base := newTestRateTables()
+
+	quincenal := []database.ISRBracket{
+		{Periodicity: "SEMIMONTHLY", LowerLimit: mxn("0.01"), UpperLimit: mxn("368.10"), FixedFee: mxn("0.00"), SurplusPercent: rate("0.0192")},
+		{Periodicity: "SEMIMONTHLY", LowerLimit: mxn("368.11"), UpperLimit: mxn("3124.35"), FixedFee: mxn("7.05"), SurplusPercent: rate("0.0640")},
+		{Periodicity: "SEMIMONTHLY", LowerLimit: mxn("3124.36"), UpperLimit: mxn("5490.75"), FixedFee: mxn("183.45"), SurplusPercent: rate("0.1088")},
+		{Periodicity: "SEMIMONTHLY", LowerLimit: mxn("5490.76"), UpperLimit: mxn("6382.80"), FixedFee: mxn("441.00"), SurplusPercent: rate("0.1600")},
+		{Periodicity: "SEMIMONTHLY", LowerLimit: mxn("6382.81"), UpperLimit: mxn("7641.90"), FixedFee: mxn("583.65"), SurplusPercent: rate("0.1792")},
+		{Periodicity: "SEMIMONTHLY", LowerLimit: mxn("7641.91"), UpperLimit: mxn("15412.80"), FixedFee: mxn("809.25"), SurplusPercent: rate("0.2136")},
+		{Periodicity: "SEMIMONTHLY", LowerLimit: mxn("15412.81"), UpperLimit: mxn("24292.65"), FixedFee: mxn("2469.15"), SurplusPercent: rate("0.2352")},
+		{Periodicity: "SEMIMONTHLY", LowerLimit: mxn("24292.66"), UpperLimit: mxn("46378.50"), FixedFee: mxn("4557.75"), SurplusPercent: rate("0.3000")},
+		{Periodicity: "SEMIMONTHLY", LowerLimit: mxn("46378.51"), UpperLimit: mxn("61838.10"), FixedFee: mxn("11183.40"), SurplusPercent: rate("0.3200")},
+		{Periodicity: "SEMIMONTHLY", LowerLimit: mxn("61838.11"), UpperLimit: mxn("185514.30"), FixedFee: mxn("16130.55"), SurplusPercent: rate("0.3400")},
+		{Periodicity: "SEMIMONTHLY", LowerLimit: mxn("185514.31"), UpperLimit: mxn("999999999.99"), FixedFee: mxn("58180.35"), SurplusPercent: rate("0.3500")},
+	}
+
+	return NewRateTables(base.fiscalYear, append(quincenal, base.ISRBrackets()...),
+		base.imssConcepts, base.cesantiaBrackets, base.resicoBrackets, base.retentionRules, base.seniority, base.stateTaxes, base.riskClasses)
+}
+
+func TestPeriodicity(t *testing.T) {
+	tests := []struct {
+		period   Periodicity
+		perMonth money.Rate
+	}{
+		{PeriodDaily, rate("30.4")},
+		{PeriodWeekly, rate("4.3428571429")},
+		{PeriodBiweekly, rate("2.1714285714")},
+		{PeriodSemimonthly, rate("2")},
+		{PeriodMonthly, rate("1")},
+		{PeriodAnnual, rate("0.0833333333")},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.period), func(t *testing.T) {
+			assert.True(t, tt.period.Valid())
+			assert.Equal(t, tt.period.PeriodsPerMonth(), tt.perMonth)
+		})
+	}
+
+	t.Run("Annual is not a pay period", func(t *testing.T) {
+		assert.False(t, PeriodAnnual.IsPayPeriod())
+		assert.False(t, Periodicity("HOURLY").IsPayPeriod())
+	})
+
+	t.Run("Converts per-period pay to monthly", func(t *testing.T) {
+		assert.Equal(t, MonthlyFromPeriod(mxn("15000"), PeriodSemimonthly), mxn("30000"))
+		assert.Equal(t, MonthlyFromPeriod(mxn("700"), PeriodWeekly), mxn("3040"))
+	})
+}
+
+func TestISRTable(t *testing.T) {
+	rt := newTestRateTablesWithQuincenal()
+
+	t.Run("Keeps the monthly tariff separate", func(t *testing.T) {
+		assert.Equal(t, len(rt.ISRBrackets()), 11)
+		assert.Equal(t, rt.ISRTable(PeriodMonthly)[1].LowerLimit, mxn("746.05"))
+	})
+
+	t.Run("Returns the seeded tariff", func(t *testing.T) {
+		assert.Equal(t, rt.ISRTable(PeriodSemimonthly)[0].UpperLimit, mxn("368.10"))
+		assert.Equal(t, rt.ISRTable(PeriodSemimonthly)[10].FixedFee, mxn("58180.35"))
+	})
+
+	t.Run("Derives missing tariffs from the monthly one", func(t *testing.T) {
+		weekly := rt.ISRTable(PeriodWeekly)
+		assert.Equal(t, weekly[0].LowerLimit, mxn("0.01"))
+		assert.Equal(t, weekly[1].FixedFee, mxn("3.30"))
+		assert.Equal(t, weekly[2].LowerLimit, weekly[1].UpperLimit+money.Centavo)
+
+		annual := rt.ISRTable(PeriodAnnual)
+		assert.Equal(t, annual[0].UpperLimit, mxn("8952.48"))
+	})
+}
+
+func TestCalculateSalaryPaidPerPeriod(t *testing.T) {
+	rt := newTestRateTablesWithQuincenal()
+
+	t.Run("Withholds each quincena with the quincenal tariff", func(t *testing.T) {
+		result, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("30000"), YearsOfService: 1, PayPeriod: PeriodSemimonthly})
+		assert.Nil(t, err)
+		assert.NotNil(t, result.Payslip)
+
+		// 809.25 + (15,000 - 7,641.91) x 21.36%
+		assert.Equal(t, result.Payslip.Gross, mxn("15000.00"))
+		assert.Equal(t, result.Payslip.ISRTax, mxn("2380.94"))
+		assert.Equal(t, result.ISRTax, mxn("4761.88"))
+
+		monthly, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("30000"), YearsOfService: 1})
+		assert.Nil(t, err)
+		assert.Nil(t, monthly.Payslip)
+		assert.Equal(t, monthly.ISRTax, mxn("4740.00"))
+		// Each payslip rounds its half of the monthly IMSS to the cent
+		assert.True(t, result.IMSSWorker-monthly.IMSSWorker <= money.Centavo)
+	})
+
+	t.Run("Annual figures are the sum of the payslips", func(t *testing.T) {
+		result, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("30000"), YearsOfService: 1, PayPeriod: PeriodSemimonthly})
+		assert.Nil(t, err)
+		assert.Equal(t, result.NetSalary, result.Payslip.Net*2)
+		assert.Equal(t, result.YearlyNet, result.Payslip.Net*24)
+	})
+
+	t.Run("Splits the subsidio across weekly payslips", func(t *testing.T) {
+		result := calculateMonthly(rt, withStatutoryDefaults(rt, SalaryInput{
+			GrossMonthlySalary: MonthlyFromPeriod(mxn("2100"), PeriodWeekly),
+			YearsOfService:     1,
+			PayPeriod:          PeriodWeekly,
+		}), nil)
+		assert.Equal(t, result.Payslip.Gross, mxn("2100.00"))
+		// 9,120 monthly x 13.8% split into 30.4 / 7 weeks
+		assert.Equal(t, result.Payslip.SubsidioEmpleo, mxn("289.80"))
+	})
+}
@@ -0,0 +1,125 @@
+package payroll
+
+import (
+	"fmt"
+
+	"github.com/jcroyoaun/totalcompmx/internal/database"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+// MaxProjectionYears is the longest compensation projection accepted
+const MaxProjectionYears = 10
+
+// MinProjectionRate and MaxProjectionRate bound the yearly raise and inflation
+// of a projection, in percent. Near -100% the price level rounds to zero and
+// today's pesos cannot be recovered.
+const (
+	MinProjectionRate = -50
+	MaxProjectionRate = 100
+)
+
+// Promotion is a raise on top of the yearly one from the start of a projection year
+type Promotion struct {
+	Year  int     // Projection year it takes effect; year 1 is the offer itself
+	Raise float64 // Percent (e.g. 15 for 15%)
+}
+
+// ProjectionInput configures the multi-year projection of a package
+type ProjectionInput struct {
+	Years       int     // Years to project; 0 skips the projection
+	AnnualRaise float64 // Nominal raise every year in percent (e.g. 5 for 5%)
+	Inflation   float64 // Expected yearly INPC inflation in percent
+	Promotions  []Promotion
+	EquityMXN   []money.Money // Equity vested each year in nominal pesos, starting with year 1
+}
+
+// Calculator calculates a package of any regime for a gross monthly salary and
+// the years of service of the employee
+type Calculator func(grossMonthlySalary money.Money, yearsOfService int) (database.SalaryCalculation, error)
+
+// ProjectCompensation raises the salary every year, plus any promotion, and
+// re-runs the package calculation so ISR, IMSS and the statutory benefits of
+// each year's seniority are recalculated. The rate tables are those of the
+// current fiscal year: the UMA, the minimum wage and the ISR tariff are assumed
+// to follow inflation, so each year is calculated in today's pesos and then
+// inflated back to nominal pesos.
+func ProjectCompensation(input ProjectionInput, grossMonthlySalary money.Money, yearsOfService int, calculate Calculator) (database.CompensationProjection, error) {
+	var projection database.CompensationProjection
+
+	if input.Years <= 0 || input.Years > MaxProjectionYears {
+		return projection, fmt.Errorf("projection years must be between 1 and %d", MaxProjectionYears)
+	}
+	if !(input.AnnualRaise >= MinProjectionRate && input.AnnualRaise <= MaxProjectionRate) ||
+		!(input.Inflation >= MinProjectionRate && input.Inflation <= MaxProjectionRate) {
+		return projection, fmt.Errorf("projection raise and inflation must be between %d%% and %d%%", MinProjectionRate, MaxProjectionRate)
+	}
+	for _, promotion := range input.Promotions {
+		if promotion.Year < 2 || promotion.Year > input.Years {
+			return projection, fmt.Errorf("promotion year must be between 2 and %d", input.Years)
+		}
+		if promotion.Raise <= -100 {
+			return projection, fmt.Errorf("promotion raise must be above -100%%")
+		}
+	}
+
+	raise := money.Percent(input.AnnualRaise)
+	inflation := money.Percent(input.Inflation)
+
+	projection.Years = input.Years
+	projection.AnnualRaise = raise
+	projection.Inflation = inflation
+
+	nominalGross := grossMonthlySalary
+	priceLevel := money.One
+	var cumulative, cumulativeReal money.Money
+	for year := 1; year <= input.Years; year++ {
+		if year > 1 {
+			nominalGross = nominalGross.Mul(money.One + raise)
+			priceLevel = priceLevel.Mul(money.One + inflation)
+		}
+		for _, promotion := range input.Promotions {
+			if promotion.Year == year {
+				nominalGross = nominalGross.Mul(money.One + money.Percent(promotion.Raise))
+			}
+		}
+
+		calc, err := calculate(nominalGross.DivRate(priceLevel), yearsOfService+year-1)
+		if err != nil {
+			return projection, err
+		}
+
+		row := database.ProjectionYear{
+			Year:               year,
+			PriceLevel:         priceLevel,
+			GrossMonthlySalary: nominalGross,
+			ISRTax:             calc.ISRTax.Mul(priceLevel),
+			IMSSWorker:         calc.IMSSWorker.Mul(priceLevel),
+			YearlyNet:          calc.YearlyNet.Mul(priceLevel),
+			YearlyNetReal:      calc.YearlyNet,
+		}
+		if year <= len(input.EquityMXN) {
+			row.Equity = input.EquityMXN[year-1]
+			row.EquityReal = input.EquityMXN[year-1].DivRate(priceLevel)
+		}
+		row.Total = row.YearlyNet + row.Equity
+		row.TotalReal = row.YearlyNetReal + row.EquityReal
+
+		cumulative += row.Total
+		cumulativeReal += row.TotalReal
+		row.Cumulative = cumulative
+		row.CumulativeReal = cumulativeReal
+
+		switch year {
+		case 4:
+			projection.Cumulative4Years = row.Cumulative
+			projection.Cumulative4YearsReal = row.CumulativeReal
+		case 5:
+			projection.Cumulative5Years = row.Cumulative
+			projection.Cumulative5YearsReal = row.CumulativeReal
+		}
+
+		projection.Schedule = append(projection.Schedule, row)
+	}
+
+	return projection, nil
+}
@@ -0,0 +1,96 @@
+package payroll
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jcroyoaun/totalcompmx/internal/assert"
+	"github.com/jcroyoaun/totalcompmx/internal/database"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+func TestProjectCompensation(t *testing.T) {
+	// A flat 20% tax keeps the arithmetic visible
+	flat := func(gross money.Money, _ int) (database.SalaryCalculation, error) {
+		return database.SalaryCalculation{ISRTax: gross.Mul(rate("0.2")), YearlyNet: gross.Mul(rate("0.8")) * 12}, nil
+	}
+
+	t.Run("Keeps the real net when raises match inflation", func(t *testing.T) {
+		projection, err := ProjectCompensation(ProjectionInput{Years: 3, AnnualRaise: 4, Inflation: 4}, mxn("10000"), 1, flat)
+		assert.Nil(t, err)
+		assert.Equal(t, len(projection.Schedule), 3)
+		for _, year := range projection.Schedule {
+			assert.Equal(t, year.YearlyNetReal, mxn("96000"))
+		}
+		// 1.04 x 1.04 = 1.0816
+		last := projection.Schedule[2]
+		assert.Equal(t, last.GrossMonthlySalary, mxn("10816"))
+		assert.Equal(t, last.YearlyNet, mxn("103833.60"))
+		assert.Equal(t, last.ISRTax, mxn("2163.20"))
+	})
+
+	t.Run("Applies promotions on top of the yearly raise", func(t *testing.T) {
+		projection, err := ProjectCompensation(ProjectionInput{Years: 3, AnnualRaise: 5, Promotions: []Promotion{{Year: 3, Raise: 10}}}, mxn("10000"), 1, flat)
+		assert.Nil(t, err)
+		assert.Equal(t, projection.Schedule[1].GrossMonthlySalary, mxn("10500"))
+		assert.Equal(t, projection.Schedule[2].GrossMonthlySalary, mxn("12127.50"))
+	})
+
+	t.Run("Adds equity and accumulates 4 and 5 year totals", func(t *testing.T) {
+		grant := mxn("100000")
+		equity := []money.Money{grant, grant, grant, grant}
+		projection, err := ProjectCompensation(ProjectionInput{Years: 5, AnnualRaise: 10, Inflation: 10, EquityMXN: equity}, mxn("10000"), 1, flat)
+		assert.Nil(t, err)
+		assert.Equal(t, projection.Schedule[1].EquityReal, mxn("90909.09"))
+		assert.Equal(t, projection.Schedule[4].Equity, money.Money(0))
+		assert.Equal(t, projection.Cumulative4Years, projection.Schedule[3].Cumulative)
+		assert.Equal(t, projection.Cumulative5Years, projection.Cumulative4Years+projection.Schedule[4].Total)
+		// 5 x 96,000 plus 100,000 + 90,909.09 + 82,644.63 + 75,131.48 of equity
+		assert.Equal(t, projection.Cumulative5YearsReal, mxn("828685.20"))
+	})
+
+	t.Run("Recalculates each year with its seniority", func(t *testing.T) {
+		rt := newTestRateTables()
+		input := SalaryInput{HasPrimaVacacional: true, PrimaVacacionalPercent: 25}
+		var seniority []int
+		projection, err := ProjectCompensation(ProjectionInput{Years: 2}, mxn("30000"), 1, func(gross money.Money, yearsOfService int) (database.SalaryCalculation, error) {
+			seniority = append(seniority, yearsOfService)
+			in := input
+			in.GrossMonthlySalary = gross
+			in.YearsOfService = yearsOfService
+			return CalculateSalaryWithBenefits(rt, in)
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, seniority, []int{1, 2})
+
+		// Two more vacation days raise the prima vacacional
+		assert.True(t, projection.Schedule[1].YearlyNet > projection.Schedule[0].YearlyNet)
+		assert.Equal(t, projection.Cumulative4Years, money.Money(0))
+	})
+
+	t.Run("Accepts the bounds of the raise and inflation", func(t *testing.T) {
+		projection, err := ProjectCompensation(ProjectionInput{Years: MaxProjectionYears, AnnualRaise: MaxProjectionRate, Inflation: MinProjectionRate}, mxn("10000"), 1, flat)
+		assert.Nil(t, err)
+		assert.True(t, projection.Schedule[MaxProjectionYears-1].PriceLevel > 0)
+	})
+
+	t.Run("Rejects invalid input", func(t *testing.T) {
+		inputs := []ProjectionInput{
+			{Years: 0},
+			{Years: MaxProjectionYears + 1},
+			{Years: 3, Inflation: -100},
+			// The price level would round to zero
+			{Years: 3, Inflation: -99.9999999999},
+			{Years: 3, Inflation: MaxProjectionRate + 1},
+			{Years: 3, AnnualRaise: MinProjectionRate - 1},
+			{Years: 3, AnnualRaise: math.Inf(-1)},
+			{Years: 3, Inflation: math.NaN()},
+			{Years: 3, Promotions: []Promotion{{Year: 1, Raise: 10}}},
+			{Years: 3, Promotions: []Promotion{{Year: 4, Raise: 10}}},
+		}
+		for _, input := range inputs {
+			_, err := ProjectCompensation(input, mxn("10000"), 1, flat)
+			assert.NotNil(t, err)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package payroll
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+// PTUMode selects how the expected PTU (reparto de utilidades) is estimated
+type PTUMode string
+
+const (
+	PTUEmployeeShare PTUMode = "EMPLOYEE_SHARE" // Pesos the employee expects to receive, not the company's distributable PTU
+	PTUDays          PTUMode = "DAYS"           // Expected days of salary
+	PTUCompanyPool   PTUMode = "COMPANY_POOL"   // The company's distributable PTU, split by days and salaries
+)
+
+// ErrPTUCompanyTotals is returned when the company's days worked or salaries
+// leave out the employee's own
+var ErrPTUCompanyTotals = errors.New("payroll: ptu company totals must include the employee's days and salary")
+
+// ptuFullYearDays is the days worked assumed when a company pool leaves them out
+const ptuFullYearDays = 365
+
+// ptuExemptUMAs is the LISR Article 93 exemption of the PTU, in daily UMAs
+const ptuExemptUMAs = 15
+
+// PTU describes the expected profit sharing of a package
+type PTU struct {
+	Mode PTUMode
+
+	// Value depends on Mode: the employee's own pesos for the year, days of
+	// salary, or the pesos the company distributes among all its workers.
+	Value float64
+
+	// DaysWorked, TotalDaysWorked and TotalSalaries are only used by
+	// PTUCompanyPool. Half of the pool is split by the days each worker
+	// worked in the year and half by the salaries they earned (LFT Art. 123).
+	// DaysWorked defaults to a full year.
+	DaysWorked      float64
+	TotalDaysWorked float64
+	TotalSalaries   money.Money
+
+	// ThreeYearAverage is the average PTU received in the last three years. The
+	// 2021 reform caps the PTU at three months of salary or this average,
+	// whichever is greater.
+	ThreeYearAverage money.Money
+}
+
+// Valid reports whether the mode is one of the supported values
+func (m PTUMode) Valid() bool {
+	switch m {
+	case PTUEmployeeShare, PTUDays, PTUCompanyPool:
+		return true
+	}
+	return false
+}
+
+// PTUCap returns the most PTU an employee can receive (LFT Article 127 fr. VIII):
+// three months of salary or the three-year average, whichever is greater
+func PTUCap(grossMonthlySalary, threeYearAverage money.Money) money.Money {
+	return max(grossMonthlySalary*3, threeYearAverage)
+}
+
+// CalculatePTU returns the PTU payable for the year after the cap, and whether
+// the cap reduced it
+func CalculatePTU(ptu PTU, grossMonthlySalary money.Money) (amount money.Money, capped bool, err error) {
+	if ptu.Value < 0 || ptu.ThreeYearAverage < 0 {
+		return 0, false, fmt.Errorf("ptu values cannot be negative")
+	}
+
+	switch ptu.Mode {
+	case PTUEmployeeShare:
+		amount = money.FromFloat(ptu.Value)
+	case PTUDays:
+		amount = grossMonthlySalary.DivRate(daysPerMonth).Mul(money.RateFromFloat(ptu.Value))
+	case PTUCompanyPool:
+		amount, err = companyPoolShare(ptu, grossMonthlySalary)
+		if err != nil {
+			return 0, false, err
+		}
+	default:
+		return 0, false, fmt.Errorf("unknown ptu mode %q", ptu.Mode)
+	}
+
+	if limit := PTUCap(grossMonthlySalary, ptu.ThreeYearAverage); amount > limit {
+		amount, capped = limit, true
+	}
+
+	return amount, capped, nil
+}
+
+// companyPoolShare splits the company's distributable PTU the way LFT Article
+// 123 does: half in equal parts per day worked and half in proportion to the
+// salary each worker earned in the year
+func companyPoolShare(ptu PTU, grossMonthlySalary money.Money) (money.Money, error) {
+	days := ptu.DaysWorked
+	if days == 0 {
+		days = ptuFullYearDays
+	}
+	if !(days > 0 && days <= 366) {
+		return 0, fmt.Errorf("ptu days worked must be between 1 and 366")
+	}
+	if !(ptu.TotalDaysWorked >= days) {
+		return 0, ErrPTUCompanyTotals
+	}
+
+	salary := grossMonthlySalary.DivRate(daysPerMonth).Mul(money.RateFromFloat(days))
+	if ptu.TotalSalaries < salary || ptu.TotalSalaries <= 0 {
+		return 0, ErrPTUCompanyTotals
+	}
+
+	half := money.FromFloat(ptu.Value).Div(2)
+	byDays := half.Mul(money.RateFromFloat(days / ptu.TotalDaysWorked))
+	bySalary := half.Mul(salary.Ratio(ptu.TotalSalaries))
+
+	return byDays + bySalary, nil
+}
@@ -0,0 +1,88 @@
+package payroll
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jcroyoaun/totalcompmx/internal/assert"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+func TestCalculatePTU(t *testing.T) {
+	tests := []struct {
+		name     string
+		ptu      PTU
+		expected money.Money
+		capped   bool
+	}{
+		{"Employee share below the cap", PTU{Mode: PTUEmployeeShare, Value: 50000}, mxn("50000"), false},
+		{"Days of salary", PTU{Mode: PTUDays, Value: 30}, mxn("29605.20"), false},
+		{"Capped at three months of salary", PTU{Mode: PTUEmployeeShare, Value: 150000}, mxn("90000"), true},
+		{"Three-year average raises the cap", PTU{Mode: PTUEmployeeShare, Value: 150000, ThreeYearAverage: mxn("120000")}, mxn("120000"), true},
+		{"Average below three months is ignored", PTU{Mode: PTUDays, Value: 120, ThreeYearAverage: mxn("40000")}, mxn("90000"), true},
+		// Half of 1,000,000 over 1% of the days plus half over 5% of the salaries
+		{"Company pool split by days and salaries", PTU{Mode: PTUCompanyPool, Value: 1000000, TotalDaysWorked: 36500, TotalSalaries: mxn("7203932")}, mxn("30000"), false},
+		{"Company pool for part of the year", PTU{Mode: PTUCompanyPool, Value: 1000000, DaysWorked: 182.5, TotalDaysWorked: 36500, TotalSalaries: mxn("7203932")}, mxn("15000"), false},
+		{"Company pool share is capped", PTU{Mode: PTUCompanyPool, Value: 10000000, TotalDaysWorked: 36500, TotalSalaries: mxn("7203932")}, mxn("90000"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, capped, err := CalculatePTU(tt.ptu, mxn("30000"))
+			assert.Nil(t, err)
+			assert.Equal(t, amount, tt.expected)
+			assert.Equal(t, capped, tt.capped)
+		})
+	}
+
+	t.Run("Rejects unknown modes", func(t *testing.T) {
+		_, _, err := CalculatePTU(PTU{Mode: "SHARES", Value: 10}, mxn("30000"))
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Rejects company totals that leave out the employee", func(t *testing.T) {
+		invalid := []PTU{
+			{Mode: PTUCompanyPool, Value: 1000000, TotalDaysWorked: 300, TotalSalaries: mxn("7203932")},
+			{Mode: PTUCompanyPool, Value: 1000000, TotalDaysWorked: 36500, TotalSalaries: mxn("100000")},
+			{Mode: PTUCompanyPool, Value: 1000000, DaysWorked: 400, TotalDaysWorked: 36500, TotalSalaries: mxn("7203932")},
+		}
+		for _, ptu := range invalid {
+			_, _, err := CalculatePTU(ptu, mxn("30000"))
+			assert.NotNil(t, err)
+		}
+		_, _, err := CalculatePTU(invalid[1], mxn("30000"))
+		assert.True(t, errors.Is(err, ErrPTUCompanyTotals))
+	})
+}
+
+func TestCalculateSalaryWithPTU(t *testing.T) {
+	rt := newTestRateTables()
+
+	base, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("30000"), YearsOfService: 1})
+	assert.Nil(t, err)
+	withPTU, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("30000"), YearsOfService: 1, HasPTU: true, PTU: PTU{Mode: PTUEmployeeShare, Value: 1500}})
+	assert.Nil(t, err)
+
+	t.Run("Exempts up to 15 UMAs", func(t *testing.T) {
+		// 1,500 is below 15 x 113.14
+		assert.Equal(t, withPTU.PTUISR, money.Money(0))
+		assert.Equal(t, withPTU.PTUNet, mxn("1500"))
+	})
+
+	t.Run("Flows into the yearly totals", func(t *testing.T) {
+		assert.Equal(t, withPTU.YearlyGross, base.YearlyGross+mxn("1500"))
+		assert.Equal(t, withPTU.YearlyNet, base.YearlyNet+mxn("1500"))
+	})
+
+	t.Run("Does not change the SBC", func(t *testing.T) {
+		assert.Equal(t, withPTU.SBC, base.SBC)
+	})
+
+	t.Run("Taxes the excess over the exemption", func(t *testing.T) {
+		result, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("30000"), YearsOfService: 1, HasPTU: true, PTU: PTU{Mode: PTUDays, Value: 30}})
+		assert.Nil(t, err)
+		taxable := result.PTUGross - mxn("113.14")*15
+		assert.Equal(t, result.PTUISR, CalculateTaxArt174(mxn("30000"), taxable, rt.ISRBrackets()))
+		assert.True(t, result.PTUISR > 0)
+	})
+}
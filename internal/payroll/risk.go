@@ -0,0 +1,69 @@
+package payroll
+
+import (
+	"fmt"
+
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+// RiskClass is the IMSS classification of a company by the hazard of its activity (RACERF Art. 73)
+type RiskClass string
+
+const (
+	RiskClassI   RiskClass = "I"   // Offices and commerce
+	RiskClassII  RiskClass = "II"  // Low risk
+	RiskClassIII RiskClass = "III" // Medium risk
+	RiskClassIV  RiskClass = "IV"  // High risk
+	RiskClassV   RiskClass = "V"   // Construction, mining and other maximum risk activities
+)
+
+// Limits of the prima de riesgo de trabajo (LSS Art. 74)
+const (
+	minRiskPremium = 0.005
+	maxRiskPremium = 0.15
+)
+
+// Valid reports whether the risk class is one of the supported values
+func (c RiskClass) Valid() bool {
+	switch c {
+	case RiskClassI, RiskClassII, RiskClassIII, RiskClassIV, RiskClassV:
+		return true
+	}
+	return false
+}
+
+// WorkRisk describes the company's Riesgo de Trabajo insurance. An exact prima,
+// as shown in the company's annual determinación, wins over the class; with
+// neither, the imss_concepts rate applies.
+type WorkRisk struct {
+	Class   RiskClass
+	Premium float64 // Exact prima de riesgo in percent (e.g. 2.5984 for 2.5984%)
+}
+
+// RiskPremium returns the prima de riesgo de trabajo as a fraction of the SBC:
+// the exact prima, the prima media of the class, or the imss_concepts default
+func (rt *RateTables) RiskPremium(risk WorkRisk) (money.Rate, error) {
+	if risk.Premium != 0 {
+		premium := risk.Premium / 100.0
+		if premium < minRiskPremium || premium > maxRiskPremium {
+			return 0, fmt.Errorf("risk premium must be between %.2f%% and %.2f%%", minRiskPremium*100, maxRiskPremium*100)
+		}
+		return money.Percent(risk.Premium), nil
+	}
+
+	if risk.Class != "" {
+		for _, class := range rt.riskClasses {
+			if class.Class == string(risk.Class) {
+				return class.Premium, nil
+			}
+		}
+		return 0, fmt.Errorf("unknown risk class %q", risk.Class)
+	}
+
+	for _, concept := range rt.imssConcepts {
+		if concept.ConceptName == conceptRiesgoTrabajo {
+			return concept.EmployerPercent, nil
+		}
+	}
+	return 0, nil
+}
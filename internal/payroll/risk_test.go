@@ -0,0 +1,59 @@
+package payroll
+
+import (
+	"testing"
+
+	"github.com/jcroyoaun/totalcompmx/internal/assert"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+func TestRiskPremium(t *testing.T) {
+	rt := newTestRateTables()
+
+	tests := []struct {
+		name    string
+		risk    WorkRisk
+		premium money.Rate
+	}{
+		{"Defaults to the imss_concepts rate", WorkRisk{}, rate("0.005")},
+		{"Uses the prima media of the class", WorkRisk{Class: RiskClassIII}, rate("0.0259840")},
+		{"Exact prima wins over the class", WorkRisk{Class: RiskClassV, Premium: 1.2}, rate("0.012")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			premium, err := rt.RiskPremium(tt.risk)
+			assert.Nil(t, err)
+			assert.Equal(t, premium, tt.premium)
+		})
+	}
+
+	t.Run("Rejects a prima outside the legal limits", func(t *testing.T) {
+		_, err := rt.RiskPremium(WorkRisk{Premium: 0.2})
+		assert.NotNil(t, err)
+		_, err = rt.RiskPremium(WorkRisk{Premium: 16})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Rejects an unknown class", func(t *testing.T) {
+		_, err := rt.RiskPremium(WorkRisk{Class: "VI"})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Every employer IMSS calculation uses the prima", func(t *testing.T) {
+		base, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("30000"), YearsOfService: 1, State: "CDMX"})
+		assert.Nil(t, err)
+		risky, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("30000"), YearsOfService: 1, State: "CDMX", WorkRisk: WorkRisk{Class: RiskClassV}})
+		assert.Nil(t, err)
+
+		monthlyBase := risky.SBC.Mul(daysPerMonth)
+		extra := monthlyBase.Mul(rate("0.0758875")) - monthlyBase.Mul(rate("0.005"))
+		assert.Equal(t, risky.RiskPremium, rate("0.0758875"))
+		assert.Equal(t, risky.IMSSEmployerMonthly-base.IMSSEmployerMonthly, extra)
+		assert.Equal(t, risky.EmployerCost.RiesgoTrabajo-base.EmployerCost.RiesgoTrabajo, extra)
+
+		asimilados, err := CalculateAsimilados(rt, AsimiladosInput{GrossMonthlySalary: mxn("30000"), WorkRisk: WorkRisk{Class: RiskClassV}})
+		assert.Nil(t, err)
+		assert.Equal(t, asimilados.MissingIMSSEmployerMonthly, risky.IMSSEmployerMonthly)
+	})
+}
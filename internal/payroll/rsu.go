@@ -0,0 +1,66 @@
+package payroll
+
+import (
+	"slices"
+
+	"github.com/jcroyoaun/totalcompmx/internal/database"
+	"github.com/jcroyoaun/totalcompmx/internal/equity"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+// TaxEquitySchedule adds the ISR of every year's vest to the equity schedule.
+// RSUs of a foreign parent are salary income when they vest, so each vest is
+// stacked on the taxable income of the package for the year and taxed with the
+// annual tariff; the ISR attributable to it is the tax above that of the salary
+// alone. Independent workers (RESICO and Actividad Empresarial) declare their
+// fees apart, so their vest is taxed as the only salary income of the year.
+//
+// Under sell-to-cover the shares sold at vest cover the withholding and the
+// difference with the ISR is settled in the annual return.
+func TaxEquitySchedule(rt *RateTables, regime Regime, calc database.SalaryCalculation, config equity.EquityConfig, schedule []equity.YearlyEquity) []equity.YearlyEquity {
+	annual := rt.ISRTable(PeriodAnnual)
+
+	var salaryBase money.Money
+	switch regime {
+	case RegimeSueldos, RegimeAsimilados:
+		salaryBase = CalculateAnnualReturn(rt, calc, PersonalDeductions{}).TaxBase
+	case RegimeRESICO, RegimeActividadEmpresarial:
+		// The fees are declared apart from the vest
+	}
+	salaryISR := CalculateISR(salaryBase, annual)
+
+	taxed := slices.Clone(schedule)
+	for i, year := range taxed {
+		vest := money.FromFloat(year.TotalVestedMXN)
+		if vest <= 0 {
+			continue
+		}
+
+		isr := CalculateISR(salaryBase+vest, annual) - salaryISR
+		year.ISRMXN = isr.Float64()
+		year.NetVestedMXN = (vest - isr).Float64()
+
+		if config.Withholding == equity.WithholdSellToCover {
+			withholding := money.Percent(config.SellToCoverPercent)
+			if withholding == 0 {
+				withholding = marginalRate(salaryBase+vest, annual)
+			}
+			sold := vest.Mul(withholding)
+			year.SoldToCoverMXN = sold.Float64()
+			year.SettlementMXN = (isr - sold).Float64()
+		}
+		taxed[i] = year
+	}
+
+	return taxed
+}
+
+// marginalRate is the surplus rate of the bracket the income falls in
+func marginalRate(income money.Money, brackets []database.ISRBracket) money.Rate {
+	for _, bracket := range brackets {
+		if income >= bracket.LowerLimit && income <= bracket.UpperLimit {
+			return bracket.SurplusPercent
+		}
+	}
+	return 0
+}
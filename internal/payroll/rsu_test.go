@@ -0,0 +1,72 @@
+package payroll
+
+import (
+	"testing"
+
+	"github.com/jcroyoaun/totalcompmx/internal/assert"
+	"github.com/jcroyoaun/totalcompmx/internal/equity"
+)
+
+func TestTaxEquitySchedule(t *testing.T) {
+	rt := newTestRateTables()
+	schedule := []equity.YearlyEquity{{Year: 0}, {Year: 1, TotalVestedMXN: 100000}, {Year: 2, TotalVestedMXN: 250000}}
+
+	// 400,000 a month is well into the 35% bracket of the annual tariff
+	high, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("400000"), YearsOfService: 1})
+	assert.Nil(t, err)
+
+	t.Run("Taxes the vest at the marginal rate on top of the salary", func(t *testing.T) {
+		taxed := TaxEquitySchedule(rt, RegimeSueldos, high, equity.EquityConfig{}, schedule)
+		assert.Equal(t, taxed[0].ISRMXN, 0.0)
+		assert.Equal(t, taxed[1].ISRMXN, 35000.0)
+		assert.Equal(t, taxed[1].NetVestedMXN, 65000.0)
+		assert.Equal(t, taxed[2].NetVestedMXN, 162500.0)
+		assert.Equal(t, taxed[1].SoldToCoverMXN, 0.0)
+		// The schedule passed in is left untouched
+		assert.Equal(t, schedule[1].ISRMXN, 0.0)
+	})
+
+	t.Run("Climbs the brackets with the vest", func(t *testing.T) {
+		calc, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("30000"), YearsOfService: 1})
+		assert.Nil(t, err)
+		taxed := TaxEquitySchedule(rt, RegimeSueldos, calc, equity.EquityConfig{}, schedule)
+		alone := CalculateISR(mxn("100000"), rt.ISRTable(PeriodAnnual)).Float64()
+		assert.True(t, taxed[1].ISRMXN > alone)
+		assert.True(t, taxed[2].ISRMXN/250000 > taxed[1].ISRMXN/100000)
+	})
+
+	t.Run("Taxes the vest alone for independent workers", func(t *testing.T) {
+		alone := CalculateISR(mxn("100000"), rt.ISRTable(PeriodAnnual)).Float64()
+
+		calc, err := CalculateRESICO(rt, RESICOInput{MonthlyIncome: mxn("30000")})
+		assert.Nil(t, err)
+		taxed := TaxEquitySchedule(rt, RegimeRESICO, calc, equity.EquityConfig{}, schedule)
+		assert.Equal(t, taxed[1].ISRMXN, alone)
+
+		// Actividad Empresarial also declares its fees apart from the vest
+		calc, err = CalculateActividadEmpresarial(rt, ActividadEmpresarialInput{MonthlyIncome: mxn("30000")})
+		assert.Nil(t, err)
+		taxed = TaxEquitySchedule(rt, RegimeActividadEmpresarial, calc, equity.EquityConfig{}, schedule)
+		assert.Equal(t, taxed[1].ISRMXN, alone)
+	})
+
+	t.Run("Stacks the vest on the salary of asimilados", func(t *testing.T) {
+		calc, err := CalculateAsimilados(rt, AsimiladosInput{GrossMonthlySalary: mxn("400000")})
+		assert.Nil(t, err)
+		taxed := TaxEquitySchedule(rt, RegimeAsimilados, calc, equity.EquityConfig{}, schedule)
+		assert.Equal(t, taxed[1].ISRMXN, 35000.0)
+	})
+
+	t.Run("Settles sell-to-cover in the annual return", func(t *testing.T) {
+		// Withholding at the marginal rate covers the ISR exactly
+		taxed := TaxEquitySchedule(rt, RegimeSueldos, high, equity.EquityConfig{Withholding: equity.WithholdSellToCover}, schedule)
+		assert.Equal(t, taxed[1].SoldToCoverMXN, 35000.0)
+		assert.Equal(t, taxed[1].SettlementMXN, 0.0)
+
+		// Selling 40% overwithholds and is refunded
+		taxed = TaxEquitySchedule(rt, RegimeSueldos, high, equity.EquityConfig{Withholding: equity.WithholdSellToCover, SellToCoverPercent: 40}, schedule)
+		assert.Equal(t, taxed[1].SoldToCoverMXN, 40000.0)
+		assert.Equal(t, taxed[1].SettlementMXN, -5000.0)
+		assert.Equal(t, taxed[1].NetVestedMXN, 65000.0)
+	})
+}
@@ -0,0 +1,198 @@
+package payroll
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+// SeparationType is how the employment relationship ended
+type SeparationType string
+
+const (
+	SeparationResignation SeparationType = "RENUNCIA"              // Voluntary resignation: finiquito only
+	SeparationDismissal   SeparationType = "DESPIDO_INJUSTIFICADO" // Unjustified dismissal: finiquito plus liquidación
+)
+
+const (
+	seniorityPremiumDays        = 12.0 // LFT Art. 162: days of salary per year of service
+	seniorityPremiumWageCap     = 2    // LFT Art. 486: salary capped at twice the minimum wage
+	seniorityPremiumMinYears    = 15   // Resignations only receive it after 15 years
+	indemnityMonths             = 3    // LFT Art. 48: three months of integrated salary
+	indemnityDaysPerYear        = 20.0 // LFT Art. 50: twenty days per year of service
+	separationExemptUMAsPerYear = 90   // LISR Art. 93 fr. XIII
+)
+
+// Valid reports whether the separation type is one of the supported values
+func (t SeparationType) Valid() bool {
+	switch t {
+	case SeparationResignation, SeparationDismissal:
+		return true
+	}
+	return false
+}
+
+// SeveranceInput holds everything needed to estimate what an employee is owed on exit
+type SeveranceInput struct {
+	GrossMonthlySalary     money.Money
+	Zone                   Zone
+	HireDate               time.Time
+	ExitDate               time.Time
+	Type                   SeparationType
+	AguinaldoDays          int     // Annual aguinaldo days; statutory when 0
+	PrimaVacacionalPercent float64 // Percent, e.g. 25; statutory when 0
+	VacationDaysTaken      float64 // Days already enjoyed in the current service year
+	PendingVacationDays    float64 // Unused days carried over from previous years
+	UnpaidSalaryDays       int     // Days worked but not yet paid
+}
+
+// Severance is the finiquito (always owed) and the liquidación (owed on
+// unjustified dismissal) with the ISR withheld on each part
+type Severance struct {
+	YearsOfService        float64 // Exact years between hire and exit
+	DailySalary           money.Money
+	IntegratedDailySalary money.Money // Daily salary times the integration factor, base of the indemnity
+
+	// Finiquito
+	UnpaidSalary     money.Money
+	AguinaldoDays    float64 // Proportional days for the calendar year
+	Aguinaldo        money.Money
+	VacationDays     float64 // Pending days, proportional current year included
+	Vacation         money.Money
+	PrimaVacacional  money.Money
+	SeniorityPremium money.Money // Prima de antigüedad
+	FiniquitoGross   money.Money
+	FiniquitoExempt  money.Money
+	FiniquitoISR     money.Money
+
+	// Liquidación
+	ThreeMonthsIndemnity money.Money
+	TwentyDaysIndemnity  money.Money
+	LiquidacionGross     money.Money
+
+	// Separation payments (seniority premium and indemnities) share one exemption
+	SeparationExempt money.Money
+	SeparationISR    money.Money
+
+	TotalGross money.Money
+	TotalISR   money.Money
+	TotalNet   money.Money
+}
+
+// CalculateSeverance estimates the finiquito and, for unjustified dismissals, the
+// liquidación. Ordinary items are taxed with the Article 174 method; separation
+// payments above the 90 UMA per year exemption are taxed at the effective rate
+// of the last monthly salary (LISR Art. 96).
+func CalculateSeverance(rt *RateTables, input SeveranceInput) (Severance, error) {
+	fiscalYear := rt.fiscalYear
+	var result Severance
+
+	if !input.Type.Valid() {
+		return result, fmt.Errorf("unknown separation type %q", input.Type)
+	}
+	if input.ExitDate.Before(input.HireDate) {
+		return result, fmt.Errorf("exit date is before the hire date")
+	}
+	if input.GrossMonthlySalary <= 0 {
+		return result, fmt.Errorf("salary must be greater than 0")
+	}
+
+	// Seniority: the last day counts as worked
+	daysEmployed := input.ExitDate.Sub(input.HireDate).Hours()/24 + 1
+	result.YearsOfService = daysEmployed / 365.0
+	completedYears := int(result.YearsOfService)
+	currentYear := rt.SeniorityBenefit(completedYears + 1)
+
+	if input.AguinaldoDays <= 0 {
+		input.AguinaldoDays = currentYear.AguinaldoDays
+	}
+	if input.PrimaVacacionalPercent <= 0 {
+		input.PrimaVacacionalPercent = currentYear.PrimaVacacionalPercent.Float64() * 100
+	}
+
+	result.DailySalary = input.GrossMonthlySalary.DivRate(daysPerMonth)
+	result.IntegratedDailySalary = result.DailySalary.Mul(IntegrationFactor(rt, SalaryInput{
+		GrossMonthlySalary:     input.GrossMonthlySalary,
+		YearsOfService:         completedYears + 1,
+		HasAguinaldo:           true,
+		AguinaldoDays:          input.AguinaldoDays,
+		HasPrimaVacacional:     true,
+		PrimaVacacionalPercent: input.PrimaVacacionalPercent,
+	}))
+
+	// 1. Salary earned and not paid
+	result.UnpaidSalary = result.DailySalary.Mul(money.Int(input.UnpaidSalaryDays))
+
+	// 2. Proportional aguinaldo for the days worked in the calendar year
+	yearStart := time.Date(input.ExitDate.Year(), time.January, 1, 0, 0, 0, 0, input.ExitDate.Location())
+	if input.HireDate.After(yearStart) {
+		yearStart = input.HireDate
+	}
+	daysThisYear := input.ExitDate.Sub(yearStart).Hours()/24 + 1
+	result.AguinaldoDays = float64(input.AguinaldoDays) * daysThisYear / 365.0
+	result.Aguinaldo = result.DailySalary.Mul(money.RateFromFloat(result.AguinaldoDays))
+
+	// 3. Vacation accrued in the current service year plus carried-over days
+	daysIntoServiceYear := daysEmployed - float64(completedYears)*365.0
+	accrued := float64(currentYear.VacationDays) * daysIntoServiceYear / 365.0
+	result.VacationDays = math.Max(0, accrued-input.VacationDaysTaken+input.PendingVacationDays)
+	result.Vacation = result.DailySalary.Mul(money.RateFromFloat(result.VacationDays))
+	result.PrimaVacacional = result.Vacation.Mul(money.Percent(input.PrimaVacacionalPercent))
+
+	// 4. Prima de antigüedad: owed on dismissal, and on resignation after 15 years
+	if input.Type == SeparationDismissal || completedYears >= seniorityPremiumMinYears {
+		cappedDaily := min(result.DailySalary, MinimumDailyWage(rt, input.Zone)*seniorityPremiumWageCap)
+		result.SeniorityPremium = cappedDaily.Mul(money.RateFromFloat(seniorityPremiumDays * result.YearsOfService))
+	}
+
+	// 5. Liquidación for unjustified dismissal, on the integrated salary
+	if input.Type == SeparationDismissal {
+		result.ThreeMonthsIndemnity = result.IntegratedDailySalary.Mul(daysPerMonth) * indemnityMonths
+		result.TwentyDaysIndemnity = result.IntegratedDailySalary.Mul(money.RateFromFloat(indemnityDaysPerYear * result.YearsOfService))
+		result.LiquidacionGross = result.ThreeMonthsIndemnity + result.TwentyDaysIndemnity
+	}
+
+	// ISR on ordinary items: aguinaldo and prima keep their Art. 93 exemptions
+	aguinaldoExempt := min(result.Aguinaldo, fiscalYear.UMADaily*aguinaldoExemptUMAs)
+	primaExempt := min(result.PrimaVacacional, fiscalYear.UMADaily*primaVacacionalExempt)
+	result.FiniquitoExempt = aguinaldoExempt + primaExempt
+
+	ordinaryTaxable := result.UnpaidSalary + result.Vacation + (result.Aguinaldo - aguinaldoExempt) + (result.PrimaVacacional - primaExempt)
+	result.FiniquitoISR = CalculateTaxArt174(input.GrossMonthlySalary, ordinaryTaxable, rt.isrBrackets)
+
+	// ISR on separation payments: 90 UMAs per year of service are exempt, and a
+	// fraction of more than six months counts as a full year
+	exemptYears := completedYears
+	if result.YearsOfService-float64(exemptYears) > 0.5 {
+		exemptYears++
+	}
+	separationGross := result.SeniorityPremium + result.LiquidacionGross
+	result.SeparationExempt = min(separationGross, fiscalYear.UMADaily.Mul(money.Int(separationExemptUMAsPerYear*exemptYears)))
+	result.SeparationISR = separationPaymentISR(rt, input.GrossMonthlySalary, separationGross-result.SeparationExempt)
+
+	result.FiniquitoGross = result.UnpaidSalary + result.Aguinaldo + result.Vacation + result.PrimaVacacional + result.SeniorityPremium
+	result.TotalGross = result.FiniquitoGross + result.LiquidacionGross
+	result.TotalISR = result.FiniquitoISR + result.SeparationISR
+	result.TotalNet = result.TotalGross - result.TotalISR
+
+	return result, nil
+}
+
+// separationPaymentISR applies LISR Art. 96: the taxable separation payment is
+// taxed at the effective rate of the last ordinary monthly salary. When it is
+// smaller than that salary it is taxed as ordinary income of the month.
+func separationPaymentISR(rt *RateTables, grossMonthlySalary, taxable money.Money) money.Money {
+	if taxable <= 0 {
+		return 0
+	}
+
+	salaryISR := CalculateISR(grossMonthlySalary, rt.isrBrackets)
+	if taxable < grossMonthlySalary {
+		return CalculateISR(grossMonthlySalary+taxable, rt.isrBrackets) - salaryISR
+	}
+
+	effectiveRate := salaryISR.Ratio(grossMonthlySalary)
+	return taxable.Mul(effectiveRate)
+}
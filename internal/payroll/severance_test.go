@@ -0,0 +1,114 @@
+package payroll
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jcroyoaun/totalcompmx/internal/assert"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+func TestCalculateSeverance(t *testing.T) {
+	rt := newTestRateTables()
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+	t.Run("Resignation only pays the finiquito", func(t *testing.T) {
+		result, err := CalculateSeverance(rt, SeveranceInput{
+			GrossMonthlySalary: mxn("30000"),
+			HireDate:           date(2024, time.January, 1),
+			ExitDate:           date(2024, time.December, 30),
+			Type:               SeparationResignation,
+		})
+		assert.Nil(t, err)
+		// A full calendar year of statutory aguinaldo
+		assert.Equal(t, result.AguinaldoDays, 15.0)
+		assert.Equal(t, result.SeniorityPremium, money.Money(0))
+		assert.Equal(t, result.LiquidacionGross, money.Money(0))
+		assert.Equal(t, result.SeparationISR, money.Money(0))
+		assert.Equal(t, result.TotalGross, result.FiniquitoGross)
+	})
+
+	t.Run("Pays the seniority premium on resignation after 15 years", func(t *testing.T) {
+		result, err := CalculateSeverance(rt, SeveranceInput{
+			GrossMonthlySalary: mxn("30000"),
+			HireDate:           date(2009, time.January, 1),
+			ExitDate:           date(2024, time.June, 30),
+			Type:               SeparationResignation,
+		})
+		assert.Nil(t, err)
+		assert.True(t, result.SeniorityPremium > 0)
+	})
+
+	t.Run("Caps the seniority premium at twice the minimum wage", func(t *testing.T) {
+		result, err := CalculateSeverance(rt, SeveranceInput{
+			GrossMonthlySalary: mxn("30000"),
+			HireDate:           date(2020, time.January, 1),
+			ExitDate:           date(2024, time.December, 30),
+			Type:               SeparationDismissal,
+		})
+		assert.Nil(t, err)
+		expected := (MinimumDailyWage(rt, ZoneGeneral) * 2).Mul(money.RateFromFloat(12 * result.YearsOfService))
+		assert.Equal(t, result.SeniorityPremium, expected)
+	})
+
+	t.Run("Dismissal adds three months and 20 days per year", func(t *testing.T) {
+		result, err := CalculateSeverance(rt, SeveranceInput{
+			GrossMonthlySalary: mxn("30000"),
+			HireDate:           date(2020, time.January, 1),
+			ExitDate:           date(2024, time.December, 30),
+			Type:               SeparationDismissal,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, result.ThreeMonthsIndemnity, result.IntegratedDailySalary.Mul(daysPerMonth)*3)
+		assert.Equal(t, result.TwentyDaysIndemnity, result.IntegratedDailySalary.Mul(money.RateFromFloat(20*result.YearsOfService)))
+		assert.True(t, result.IntegratedDailySalary > result.DailySalary)
+	})
+
+	t.Run("Exempts 90 UMAs per year of service", func(t *testing.T) {
+		result, err := CalculateSeverance(rt, SeveranceInput{
+			GrossMonthlySalary: mxn("30000"),
+			HireDate:           date(2020, time.January, 1),
+			ExitDate:           date(2024, time.December, 30),
+			Type:               SeparationDismissal,
+		})
+		assert.Nil(t, err)
+		// Five years of service
+		assert.Equal(t, result.SeparationExempt, mxn("113.14")*90*5)
+		taxable := result.SeniorityPremium + result.LiquidacionGross - result.SeparationExempt
+		effectiveRate := CalculateISR(mxn("30000"), rt.ISRBrackets()).Ratio(mxn("30000"))
+		assert.Equal(t, result.SeparationISR, taxable.Mul(effectiveRate))
+	})
+
+	t.Run("Rounds a fraction over six months up to a full year", func(t *testing.T) {
+		result, err := CalculateSeverance(rt, SeveranceInput{
+			GrossMonthlySalary: mxn("30000"),
+			HireDate:           date(2022, time.January, 1),
+			ExitDate:           date(2024, time.September, 30),
+			Type:               SeparationDismissal,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, result.SeparationExempt, mxn("113.14")*90*3)
+	})
+
+	t.Run("Subtracts vacation already taken", func(t *testing.T) {
+		input := SeveranceInput{
+			GrossMonthlySalary: mxn("30000"),
+			HireDate:           date(2024, time.January, 1),
+			ExitDate:           date(2024, time.June, 30),
+			Type:               SeparationResignation,
+		}
+		full, err := CalculateSeverance(rt, input)
+		assert.Nil(t, err)
+		input.VacationDaysTaken = 2
+		taken, err := CalculateSeverance(rt, input)
+		assert.Nil(t, err)
+		assert.True(t, taken.VacationDays < full.VacationDays)
+	})
+
+	t.Run("Rejects invalid input", func(t *testing.T) {
+		_, err := CalculateSeverance(rt, SeveranceInput{GrossMonthlySalary: mxn("30000"), HireDate: date(2024, time.June, 1), ExitDate: date(2024, time.January, 1), Type: SeparationResignation})
+		assert.NotNil(t, err)
+		_, err = CalculateSeverance(rt, SeveranceInput{GrossMonthlySalary: mxn("30000"), HireDate: date(2024, time.January, 1), ExitDate: date(2024, time.June, 1), Type: "DESPIDO"})
+		assert.NotNil(t, err)
+	})
+}
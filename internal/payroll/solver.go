@@ -0,0 +1,200 @@
+package payroll
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/jcroyoaun/totalcompmx/internal/database"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+// NetField selects which net figure of a SalaryCalculation the solver matches
+type NetField string
+
+const (
+	NetFieldNetSalary       NetField = "net_salary"
+	NetFieldYearlyNet       NetField = "yearly_net"
+	NetFieldMonthlyAdjusted NetField = "monthly_adjusted"
+)
+
+// solverScanSteps is the number of grid points between 0 and the upper bound
+const solverScanSteps = 2000
+
+// solverMaxGross is the monthly gross above which we give up
+var solverMaxGross = money.Pesos(100_000_000)
+
+// ErrTargetUnreachable is returned when no gross salary below solverMaxGross reaches the target
+var ErrTargetUnreachable = errors.New("payroll: target net is not reachable")
+
+// GrossSolution is the result of a net-to-gross search
+type GrossSolution struct {
+	GrossMonthlySalary money.Money
+	Calculation        database.SalaryCalculation
+
+	// CliffAt is the gross salary where net drops (e.g. losing the Subsidio al
+	// Empleo) when it sits just above the solution: any raise up to the point
+	// where net recovers leaves the employee worse off. Zero when not applicable.
+	CliffAt money.Money
+}
+
+// Valid reports whether the field is one of the supported net figures
+func (f NetField) Valid() bool {
+	switch f {
+	case NetFieldNetSalary, NetFieldYearlyNet, NetFieldMonthlyAdjusted:
+		return true
+	}
+	return false
+}
+
+// value extracts the selected net figure from a calculation
+func (f NetField) value(calc database.SalaryCalculation) money.Money {
+	switch f {
+	case NetFieldYearlyNet:
+		return calc.YearlyNet
+	case NetFieldMonthlyAdjusted:
+		return calc.MonthlyAdjusted
+	default:
+		return calc.NetSalary
+	}
+}
+
+// SolveSalaryGross finds the lowest gross monthly salary that produces the target
+// net for a Sueldos y Salarios package. input.GrossMonthlySalary is ignored.
+func SolveSalaryGross(rt *RateTables, input SalaryInput, field NetField, target money.Money) (GrossSolution, error) {
+	calculate := func(gross money.Money) (database.SalaryCalculation, error) {
+		in := input
+		in.GrossMonthlySalary = gross
+		return CalculateSalaryWithBenefits(rt, in)
+	}
+
+	// Net drops right after the Subsidio al Empleo threshold
+	breakpoints := []money.Money{rt.fiscalYear.SubsidyThresholdMonthly}
+
+	return solveGross(calculate, field, target, breakpoints)
+}
+
+// SolveRESICOGross finds the lowest monthly income that produces the target net
+// for a RESICO package. input.MonthlyIncome is ignored.
+func SolveRESICOGross(rt *RateTables, input RESICOInput, field NetField, target money.Money) (GrossSolution, error) {
+	calculate := func(gross money.Money) (database.SalaryCalculation, error) {
+		in := input
+		in.MonthlyIncome = gross
+		return CalculateRESICO(rt, in)
+	}
+
+	// The flat rate applies to the whole income, so net drops at every tier ceiling
+	var breakpoints []money.Money
+	for _, bracket := range rt.resicoBrackets {
+		breakpoints = append(breakpoints, bracket.UpperLimit)
+	}
+
+	return solveGross(calculate, field, target, breakpoints)
+}
+
+// solveGross searches for the lowest gross whose net reaches the target.
+//
+// Net is not monotonic in gross: it drops at known breakpoints (subsidio cliff,
+// RESICO tiers) and is non-decreasing between them. The search scans a grid that
+// always includes every breakpoint, stops at the first point that reaches the
+// target and then bisects between that point and the previous one.
+func solveGross(calculate func(money.Money) (database.SalaryCalculation, error), field NetField, target money.Money, breakpoints []money.Money) (GrossSolution, error) {
+	if !field.Valid() {
+		return GrossSolution{}, fmt.Errorf("payroll: unknown net field %q", field)
+	}
+	if target <= 0 {
+		return GrossSolution{}, fmt.Errorf("payroll: target net must be greater than 0")
+	}
+
+	net := func(gross money.Money) (money.Money, error) {
+		calc, err := calculate(gross)
+		if err != nil {
+			return 0, err
+		}
+		return field.value(calc), nil
+	}
+
+	// 1. Find an upper bound that reaches the target
+	hi := max(target, money.Pesos(1))
+	for {
+		value, err := net(hi)
+		if err != nil {
+			return GrossSolution{}, err
+		}
+		if value >= target {
+			break
+		}
+		hi *= 2
+		if hi > solverMaxGross {
+			return GrossSolution{}, ErrTargetUnreachable
+		}
+	}
+
+	// 2. Scan the grid (plus breakpoints) for the first point reaching the target
+	points := make([]money.Money, 0, solverScanSteps+len(breakpoints)*2)
+	for i := 1; i <= solverScanSteps; i++ {
+		points = append(points, hi*money.Money(i)/solverScanSteps)
+	}
+	for _, bp := range breakpoints {
+		if bp > 0 && bp < hi {
+			points = append(points, bp, bp+money.Centavo)
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i] < points[j] })
+
+	var lo money.Money
+	for _, point := range points {
+		value, err := net(point)
+		if err != nil {
+			return GrossSolution{}, err
+		}
+		if value >= target {
+			hi = point
+			break
+		}
+		lo = point
+	}
+
+	// 3. Bisect: net(lo) < target <= net(hi)
+	for hi-lo > money.Centavo {
+		mid := (lo + hi) / 2
+		value, err := net(mid)
+		if err != nil {
+			return GrossSolution{}, err
+		}
+		if value >= target {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+
+	// hi is now the lowest centavo reaching the target
+	gross := hi
+	calc, err := calculate(gross)
+	if err != nil {
+		return GrossSolution{}, err
+	}
+
+	solution := GrossSolution{
+		GrossMonthlySalary: gross,
+		Calculation:        calc,
+	}
+
+	// Warn when the next breakpoint would make a raise lower the net
+	for _, bp := range breakpoints {
+		if bp < gross {
+			continue
+		}
+		above, err := net(bp + money.Centavo)
+		if err != nil {
+			return GrossSolution{}, err
+		}
+		if above < field.value(calc) {
+			solution.CliffAt = bp
+		}
+		break
+	}
+
+	return solution, nil
+}
@@ -0,0 +1,83 @@
+package payroll
+
+import (
+	"testing"
+
+	"github.com/jcroyoaun/totalcompmx/internal/assert"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+func TestSolveSalaryGross(t *testing.T) {
+	rt := newTestRateTables()
+
+	t.Run("Round-trips a gross salary through the forward calculation", func(t *testing.T) {
+		input := SalaryInput{GrossMonthlySalary: mxn("45000"), HasAguinaldo: true, AguinaldoDays: 15}
+		forward, err := CalculateSalaryWithBenefits(rt, input)
+		assert.Nil(t, err)
+
+		solution, err := SolveSalaryGross(rt, input, NetFieldNetSalary, forward.NetSalary)
+		assert.Nil(t, err)
+		// Net is rounded to the centavo, so a cent or two less can give the same net
+		assert.True(t, solution.GrossMonthlySalary <= mxn("45000"))
+		assert.True(t, solution.GrossMonthlySalary > mxn("44999.95"))
+		assert.Equal(t, solution.Calculation.NetSalary, forward.NetSalary)
+		assert.Equal(t, solution.CliffAt, money.Money(0))
+	})
+
+	t.Run("Solves against yearly net", func(t *testing.T) {
+		solution, err := SolveSalaryGross(rt, SalaryInput{}, NetFieldYearlyNet, mxn("300000"))
+		assert.Nil(t, err)
+		assert.True(t, solution.Calculation.YearlyNet >= mxn("300000"))
+		assert.True(t, solution.Calculation.YearlyNet-mxn("300000") < mxn("1"))
+	})
+
+	t.Run("Flags the subsidio cliff just above the solution", func(t *testing.T) {
+		solution, err := SolveSalaryGross(rt, SalaryInput{}, NetFieldNetSalary, mxn("10000"))
+		assert.Nil(t, err)
+		assert.True(t, solution.GrossMonthlySalary <= mxn("10171.00"))
+		assert.True(t, solution.Calculation.SubsidioEmpleo > 0)
+		assert.Equal(t, solution.CliffAt, mxn("10171.00"))
+	})
+
+	t.Run("Jumps past the cliff when the subsidio cannot reach the target", func(t *testing.T) {
+		atThreshold := CalculateSalary(rt, mxn("10171.00"), 1, ZoneGeneral)
+
+		solution, err := SolveSalaryGross(rt, SalaryInput{}, NetFieldNetSalary, atThreshold.NetSalary+mxn("50"))
+		assert.Nil(t, err)
+		assert.True(t, solution.GrossMonthlySalary > mxn("10171.00"))
+		assert.Equal(t, solution.Calculation.SubsidioEmpleo, money.Money(0))
+		assert.Equal(t, solution.CliffAt, money.Money(0))
+	})
+
+	t.Run("Rejects invalid targets", func(t *testing.T) {
+		_, err := SolveSalaryGross(rt, SalaryInput{}, NetFieldNetSalary, 0)
+		assert.NotNil(t, err)
+
+		_, err = SolveSalaryGross(rt, SalaryInput{}, NetField("gross"), mxn("10000"))
+		assert.NotNil(t, err)
+	})
+}
+
+func TestSolveRESICOGross(t *testing.T) {
+	rt := newTestRateTables()
+
+	t.Run("Finds the income for a net inside the first tier", func(t *testing.T) {
+		solution, err := SolveRESICOGross(rt, RESICOInput{}, NetFieldNetSalary, mxn("19800"))
+		assert.Nil(t, err)
+		assert.Equal(t, solution.GrossMonthlySalary, mxn("20000"))
+		assert.Equal(t, solution.CliffAt, money.Money(0))
+	})
+
+	t.Run("Skips the income range lost to the next tier", func(t *testing.T) {
+		// 25,000 nets 24,750 but 25,000.01 only nets 24,725.01
+		solution, err := SolveRESICOGross(rt, RESICOInput{}, NetFieldNetSalary, mxn("24740"))
+		assert.Nil(t, err)
+		assert.Equal(t, solution.GrossMonthlySalary, mxn("24989.90"))
+		assert.Equal(t, solution.CliffAt, mxn("25000"))
+
+		solution, err = SolveRESICOGross(rt, RESICOInput{}, NetFieldNetSalary, mxn("24760"))
+		assert.Nil(t, err)
+		assert.True(t, solution.GrossMonthlySalary > mxn("25000"))
+		assert.True(t, solution.Calculation.NetSalary >= mxn("24760"))
+	})
+}
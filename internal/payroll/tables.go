@@ -0,0 +1,175 @@
+package payroll
+
+import (
+	"sort"
+
+	"github.com/jcroyoaun/totalcompmx/internal/database"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+// RateTables is an immutable snapshot of every rate table the engine needs for
+// one fiscal year. Build it once (e.g. per request or per batch run) and pass it
+// to as many calculations as needed; nothing in this package touches the database.
+type RateTables struct {
+	fiscalYear       database.FiscalYear
+	isrBrackets      []database.ISRBracket // Monthly tariff
+	isrTables        map[Periodicity][]database.ISRBracket
+	imssConcepts     []database.IMSSConcept
+	cesantiaBrackets []database.CesantiaBracket
+	resicoBrackets   []database.RESICOBracket
+	retentionRules   []database.RetentionRule
+	seniority        []database.SeniorityBenefit
+	stateTaxes       []database.StatePayrollTax
+	riskClasses      []database.RiskClass
+}
+
+// statutoryMinimum is the LFT floor used when no seniority row is loaded
+var statutoryMinimum = database.SeniorityBenefit{
+	YearsOfService:         1,
+	VacationDays:           12,
+	PrimaVacacionalPercent: money.MustParseRate("0.25"),
+	AguinaldoDays:          15,
+}
+
+// NewRateTables copies the given tables into a new snapshot so later changes to
+// the caller's slices cannot leak into running calculations. ISR brackets of every
+// periodicity may be mixed; brackets without a periodicity are monthly.
+func NewRateTables(
+	fiscalYear database.FiscalYear,
+	isrBrackets []database.ISRBracket,
+	imssConcepts []database.IMSSConcept,
+	cesantiaBrackets []database.CesantiaBracket,
+	resicoBrackets []database.RESICOBracket,
+	retentionRules []database.RetentionRule,
+	seniority []database.SeniorityBenefit,
+	stateTaxes []database.StatePayrollTax,
+	riskClasses []database.RiskClass,
+) *RateTables {
+	rt := &RateTables{
+		fiscalYear:       fiscalYear,
+		isrTables:        make(map[Periodicity][]database.ISRBracket),
+		imssConcepts:     append([]database.IMSSConcept(nil), imssConcepts...),
+		cesantiaBrackets: append([]database.CesantiaBracket(nil), cesantiaBrackets...),
+		resicoBrackets:   append([]database.RESICOBracket(nil), resicoBrackets...),
+		retentionRules:   append([]database.RetentionRule(nil), retentionRules...),
+		seniority:        append([]database.SeniorityBenefit(nil), seniority...),
+		stateTaxes:       append([]database.StatePayrollTax(nil), stateTaxes...),
+		riskClasses:      append([]database.RiskClass(nil), riskClasses...),
+	}
+
+	for _, b := range isrBrackets {
+		period := Periodicity(b.Periodicity)
+		if period == "" || period == PeriodMonthly {
+			rt.isrBrackets = append(rt.isrBrackets, b)
+			continue
+		}
+		rt.isrTables[period] = append(rt.isrTables[period], b)
+	}
+
+	sort.Slice(rt.isrBrackets, func(i, j int) bool {
+		return rt.isrBrackets[i].LowerLimit < rt.isrBrackets[j].LowerLimit
+	})
+	for _, table := range rt.isrTables {
+		sort.Slice(table, func(i, j int) bool {
+			return table[i].LowerLimit < table[j].LowerLimit
+		})
+	}
+	sort.Slice(rt.cesantiaBrackets, func(i, j int) bool {
+		return rt.cesantiaBrackets[i].LowerBoundUMA < rt.cesantiaBrackets[j].LowerBoundUMA
+	})
+	sort.Slice(rt.resicoBrackets, func(i, j int) bool {
+		return rt.resicoBrackets[i].UpperLimit < rt.resicoBrackets[j].UpperLimit
+	})
+	sort.Slice(rt.seniority, func(i, j int) bool {
+		return rt.seniority[i].YearsOfService < rt.seniority[j].YearsOfService
+	})
+	sort.Slice(rt.stateTaxes, func(i, j int) bool {
+		return rt.stateTaxes[i].StateName < rt.stateTaxes[j].StateName
+	})
+	sort.Slice(rt.riskClasses, func(i, j int) bool {
+		return rt.riskClasses[i].Premium < rt.riskClasses[j].Premium
+	})
+
+	return rt
+}
+
+// FiscalYear returns the fiscal year constants (UMA, minimum wages, caps) of the snapshot
+func (rt *RateTables) FiscalYear() database.FiscalYear {
+	return rt.fiscalYear
+}
+
+// ISRBrackets returns a copy of the monthly ISR brackets
+func (rt *RateTables) ISRBrackets() []database.ISRBracket {
+	return append([]database.ISRBracket(nil), rt.isrBrackets...)
+}
+
+// StatePayrollTaxes returns a copy of the ISN rate of every state, by state name
+func (rt *RateTables) StatePayrollTaxes() []database.StatePayrollTax {
+	return append([]database.StatePayrollTax(nil), rt.stateTaxes...)
+}
+
+// StatePayrollTax finds the ISN rate of a state by its code
+func (rt *RateTables) StatePayrollTax(stateCode string) (database.StatePayrollTax, bool) {
+	for _, tax := range rt.stateTaxes {
+		if tax.StateCode == stateCode {
+			return tax, true
+		}
+	}
+	return database.StatePayrollTax{}, false
+}
+
+// RiskClasses returns a copy of the prima media of every IMSS risk class, from I to V
+func (rt *RateTables) RiskClasses() []database.RiskClass {
+	return append([]database.RiskClass(nil), rt.riskClasses...)
+}
+
+// SeniorityBenefit returns the statutory benefits for the given years of service.
+// Employees in their first year use the year 1 row, and anyone past the last
+// seeded row keeps the last row's benefits.
+func (rt *RateTables) SeniorityBenefit(yearsOfService int) database.SeniorityBenefit {
+	if len(rt.seniority) == 0 {
+		return statutoryMinimum
+	}
+
+	benefit := rt.seniority[0]
+	for _, row := range rt.seniority {
+		if row.YearsOfService > yearsOfService {
+			break
+		}
+		benefit = row
+	}
+	return benefit
+}
+
+// cesantiaBracket finds the employer Cesantía bracket for a daily salary expressed
+// in UMAs. The bounds have three decimals, so the salary is rounded to them first.
+func (rt *RateTables) cesantiaBracket(salaryInUMAs money.Rate) (database.CesantiaBracket, bool) {
+	salaryInUMAs = salaryInUMAs.Round(3)
+	for _, bracket := range rt.cesantiaBrackets {
+		if salaryInUMAs >= bracket.LowerBoundUMA && salaryInUMAs <= bracket.UpperBoundUMA {
+			return bracket, true
+		}
+	}
+	return database.CesantiaBracket{}, false
+}
+
+// resicoBracket finds the lowest RESICO tier whose ceiling covers the monthly income
+func (rt *RateTables) resicoBracket(monthlyIncome money.Money) (database.RESICOBracket, bool) {
+	for _, bracket := range rt.resicoBrackets {
+		if monthlyIncome <= bracket.UpperLimit {
+			return bracket, true
+		}
+	}
+	return database.RESICOBracket{}, false
+}
+
+// retentionRate returns the ISR a client of the given type retains from a regime's
+// invoices. Missing rules mean no retention.
+func (rt *RateTables) retentionRate(regime string, clientType ClientType) money.Rate {
+	for _, rule := range rt.retentionRules {
+		if rule.RegimeName == regime && rule.ClientType == string(clientType) {
+			return rule.RetentionRate
+		}
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+package payroll
+
+import (
+	"github.com/jcroyoaun/totalcompmx/internal/database"
+)
+
+// tracer records the steps of one calculation for explain mode. A nil tracer
+// records nothing, so the engine calls it unconditionally.
+type tracer struct {
+	trace database.CalculationTrace
+}
+
+// newTracer returns a tracer when explain mode is on and nil otherwise
+func newTracer(explain bool) *tracer {
+	if !explain {
+		return nil
+	}
+	return &tracer{}
+}
+
+func (t *tracer) isr(step database.ISRStep) {
+	if t != nil {
+		t.trace.ISR = append(t.trace.ISR, step)
+	}
+}
+
+func (t *tracer) art174(step database.Art174Step) {
+	if t != nil {
+		t.trace.Art174 = append(t.trace.Art174, step)
+	}
+}
+
+func (t *tracer) imss(step database.IMSSStep) {
+	if t != nil {
+		t.trace.IMSS = append(t.trace.IMSS, step)
+	}
+}
+
+func (t *tracer) cesantia(step database.CesantiaStep) {
+	if t != nil {
+		t.trace.Cesantia = &step
+	}
+}
+
+func (t *tracer) exemption(step database.ExemptionStep) {
+	if t != nil {
+		t.trace.Exemptions = append(t.trace.Exemptions, step)
+	}
+}
+
+// result returns the trace to attach to a result, nil when not explaining
+func (t *tracer) result() *database.CalculationTrace {
+	if t == nil {
+		return nil
+	}
+	trace := t.trace
+	return &trace
+}
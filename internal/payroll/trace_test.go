@@ -0,0 +1,83 @@
+package payroll
+
+import (
+	"testing"
+
+	"github.com/jcroyoaun/totalcompmx/internal/assert"
+	"github.com/jcroyoaun/totalcompmx/internal/database"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+func TestExplain(t *testing.T) {
+	rt := newTestRateTables()
+
+	t.Run("Records nothing unless asked", func(t *testing.T) {
+		result, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("20000"), YearsOfService: 1})
+		assert.Nil(t, err)
+		assert.True(t, result.Trace == nil)
+	})
+
+	t.Run("Traces the ISR bracket, Article 174 and the exemptions", func(t *testing.T) {
+		result, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("20000"), YearsOfService: 1, HasAguinaldo: true, AguinaldoDays: 30, Explain: true})
+		assert.Nil(t, err)
+
+		isr := result.Trace.ISR[0]
+		assert.Equal(t, isr.Label, "Sueldo")
+		assert.Equal(t, isr.LowerLimit, mxn("15487.72"))
+		assert.Equal(t, isr.FixedFee, mxn("1640.18"))
+		assert.Equal(t, isr.SurplusPercent, rate("0.2136"))
+		assert.Equal(t, isr.Surplus, mxn("4512.28"))
+		assert.Equal(t, isr.Tax, result.ISRTax)
+
+		// 30 days of 657.89 minus the 30 UMA exemption
+		exemption := result.Trace.Exemptions[0]
+		assert.Equal(t, exemption.Concept, "Aguinaldo")
+		assert.Equal(t, exemption.Limit, mxn("3394.20"))
+		assert.Equal(t, exemption.Taxable, result.AguinaldoGross-mxn("3394.20"))
+
+		art174 := result.Trace.Art174[0]
+		assert.Equal(t, art174.Amount, exemption.Taxable)
+		assert.Equal(t, art174.TaxOnShare, art174.TaxOnTotal-art174.TaxOnSalary)
+		assert.Equal(t, art174.Tax, result.AguinaldoISR)
+	})
+
+	t.Run("Traces every IMSS concept and the Cesantía bracket", func(t *testing.T) {
+		result, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("20000"), YearsOfService: 1, Explain: true})
+		assert.Nil(t, err)
+		assert.Equal(t, len(result.Trace.IMSS), 9)
+
+		var worker, employer money.Money
+		for _, step := range result.Trace.IMSS {
+			worker += step.Worker
+			employer += step.Employer
+			assert.False(t, step.Capped)
+		}
+		assert.Equal(t, worker, result.IMSSWorker)
+		assert.Equal(t, employer, result.IMSSEmployerMonthly)
+
+		// A daily SBC of 690.32 is 6.101 UMAs
+		assert.Equal(t, *result.Trace.Cesantia, database.CesantiaStep{
+			SalaryInUMAs:    rate("6.101"),
+			LowerBoundUMA:   rate("6.001"),
+			UpperBoundUMA:   rate("6.500"),
+			EmployerPercent: rate("0.05775"),
+		})
+	})
+
+	t.Run("Flags the concepts capped at 25 UMAs", func(t *testing.T) {
+		result, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("150000"), YearsOfService: 1, Explain: true})
+		assert.Nil(t, err)
+		assert.False(t, result.Trace.IMSS[0].Capped)
+		assert.Equal(t, result.Trace.IMSS[0].DailyBase, mxn("113.14"))
+		assert.True(t, result.Trace.IMSS[2].Capped)
+		assert.Equal(t, result.Trace.IMSS[2].DailyBase, mxn("2828.50"))
+	})
+
+	t.Run("Traces the payslip tariff", func(t *testing.T) {
+		result, err := CalculateAsimilados(rt, AsimiladosInput{GrossMonthlySalary: mxn("30000"), PayPeriod: PeriodSemimonthly, Explain: true})
+		assert.Nil(t, err)
+		assert.Equal(t, result.Trace.ISR[0].Periodicity, string(PeriodSemimonthly))
+		assert.Equal(t, result.Trace.ISR[0].Base, mxn("15000"))
+		assert.Equal(t, result.Trace.ISR[0].Tax, result.Payslip.ISRTax)
+	})
+}
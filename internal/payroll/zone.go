@@ -0,0 +1,139 @@
+package payroll
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+// Zone is the geographic region where the income is earned. It selects the
+// minimum wage and, for Actividad Empresarial, whether the border region ISR
+// stimulus can apply.
+type Zone string
+
+const (
+	ZoneGeneral     Zone = "GENERAL"        // Rest of the country
+	ZoneNorthBorder Zone = "FRONTERA_NORTE" // Zona Libre de la Frontera Norte (ZLFN)
+	ZoneSouthBorder Zone = "FRONTERA_SUR"   // Región Fronteriza Sur
+)
+
+// borderISRCreditDivisor sets the ISR credit granted by the border region
+// decrees: one third of the ISR caused on business income earned in the region.
+// Wages and asimilados are excluded.
+const borderISRCreditDivisor = 3
+
+//go:embed zlfn_municipalities.csv
+var municipalitiesCSV string
+
+// Municipality is a municipality covered by the border region decrees
+type Municipality struct {
+	State string
+	Name  string
+	Zone  Zone
+}
+
+// borderMunicipalities is parsed once from the embedded decree list
+var borderMunicipalities = mustParseMunicipalities(municipalitiesCSV)
+
+// Valid reports whether the zone is one of the supported values
+func (z Zone) Valid() bool {
+	switch z {
+	case ZoneGeneral, ZoneNorthBorder, ZoneSouthBorder:
+		return true
+	}
+	return false
+}
+
+// HasBorderMinimumWage reports whether the ZLFN minimum wage applies. The
+// southern region keeps the general minimum wage.
+func (z Zone) HasBorderMinimumWage() bool {
+	return z == ZoneNorthBorder
+}
+
+// HasISRStimulus reports whether business income earned in the zone can get
+// the border ISR credit
+func (z Zone) HasISRStimulus() bool {
+	return z == ZoneNorthBorder || z == ZoneSouthBorder
+}
+
+// MinimumDailyWage returns the daily minimum wage (SMG) of the zone
+func MinimumDailyWage(rt *RateTables, zone Zone) money.Money {
+	if zone.HasBorderMinimumWage() {
+		return rt.fiscalYear.SMGBorder
+	}
+	return rt.fiscalYear.SMGGeneral
+}
+
+// MinimumMonthlyWage returns the monthly minimum wage of the zone (30.4 days)
+func MinimumMonthlyWage(rt *RateTables, zone Zone) money.Money {
+	return MinimumDailyWage(rt, zone).Mul(daysPerMonth)
+}
+
+// BorderISRCredit returns the border region stimulus for the ISR of business
+// income: a credit of one third of the tax, zero outside the border regions.
+// Only taxpayers registered in the padrón of the stimulus may apply it.
+func BorderISRCredit(zone Zone, isr money.Money) money.Money {
+	if !zone.HasISRStimulus() || isr <= 0 {
+		return 0
+	}
+	return isr.Div(borderISRCreditDivisor)
+}
+
+// BorderMunicipalities returns the municipalities covered by the border region decrees
+func BorderMunicipalities() []Municipality {
+	municipalities := make([]Municipality, len(borderMunicipalities))
+	copy(municipalities, borderMunicipalities)
+	return municipalities
+}
+
+// LookupMunicipality finds a border municipality by name. The query may be the
+// bare name ("Tijuana") or include the state ("Guerrero, Tamaulipas"); case and
+// accents are ignored. Municipalities not in the list belong to ZoneGeneral.
+func LookupMunicipality(query string) (Municipality, bool) {
+	name, state, _ := strings.Cut(query, ",")
+	name = normalizePlaceName(name)
+	state = normalizePlaceName(state)
+	if name == "" {
+		return Municipality{}, false
+	}
+
+	for _, m := range borderMunicipalities {
+		if normalizePlaceName(m.Name) != name {
+			continue
+		}
+		if state != "" && normalizePlaceName(m.State) != state {
+			continue
+		}
+		return m, true
+	}
+	return Municipality{}, false
+}
+
+// normalizePlaceName lowercases, strips accents and collapses whitespace
+func normalizePlaceName(s string) string {
+	replacer := strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n")
+	return strings.Join(strings.Fields(replacer.Replace(strings.ToLower(s))), " ")
+}
+
+func mustParseMunicipalities(data string) []Municipality {
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("payroll: invalid municipalities table: %v", err))
+	}
+
+	var municipalities []Municipality
+	for i, record := range records {
+		if i == 0 {
+			continue // Header
+		}
+		zone := Zone(record[2])
+		if !zone.HasISRStimulus() {
+			panic(fmt.Sprintf("payroll: invalid zone %q for %s", record[2], record[1]))
+		}
+		municipalities = append(municipalities, Municipality{State: record[0], Name: record[1], Zone: zone})
+	}
+	return municipalities
+}
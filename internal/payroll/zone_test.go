@@ -0,0 +1,74 @@
+package payroll
+
+import (
+	"testing"
+
+	"github.com/jcroyoaun/totalcompmx/internal/assert"
+	"github.com/jcroyoaun/totalcompmx/internal/money"
+)
+
+func TestLookupMunicipality(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		found    bool
+		expected Zone
+	}{
+		{"Exact name", "Tijuana", true, ZoneNorthBorder},
+		{"Ignores case and accents", "ciudad juarez", false, ""},
+		{"Matches without accents", "juarez", true, ZoneNorthBorder},
+		{"Name with state", "Guerrero, Tamaulipas", true, ZoneNorthBorder},
+		{"Wrong state", "Tijuana, Sonora", false, ""},
+		{"Southern border", "Tapachula", true, ZoneSouthBorder},
+		{"Not a border municipality", "Cuauhtémoc", false, ""},
+		{"Empty", "  ", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, found := LookupMunicipality(tt.query)
+			assert.Equal(t, found, tt.found)
+			assert.Equal(t, m.Zone, tt.expected)
+		})
+	}
+
+	t.Run("Lists every municipality of the decrees", func(t *testing.T) {
+		assert.Equal(t, len(BorderMunicipalities()), 68)
+	})
+}
+
+func TestMinimumMonthlyWage(t *testing.T) {
+	rt := newTestRateTables()
+
+	assert.Equal(t, MinimumMonthlyWage(rt, ZoneGeneral), mxn("8475.52"))
+	assert.Equal(t, MinimumMonthlyWage(rt, ZoneNorthBorder), mxn("12764.35"))
+	// The southern region keeps the general minimum wage
+	assert.Equal(t, MinimumMonthlyWage(rt, ZoneSouthBorder), mxn("8475.52"))
+}
+
+func TestCalculateSalaryInBorderZone(t *testing.T) {
+	rt := newTestRateTables()
+
+	cdmx, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("40000"), YearsOfService: 1, HasAguinaldo: true, AguinaldoDays: 30})
+	assert.Nil(t, err)
+	tijuana, err := CalculateSalaryWithBenefits(rt, SalaryInput{GrossMonthlySalary: mxn("40000"), YearsOfService: 1, HasAguinaldo: true, AguinaldoDays: 30, Zone: ZoneNorthBorder})
+	assert.Nil(t, err)
+
+	t.Run("Does not credit the ISR of wages", func(t *testing.T) {
+		assert.Equal(t, tijuana.BorderISRCredit, money.Money(0))
+		assert.Equal(t, tijuana.ISRTax, cdmx.ISRTax)
+		assert.Equal(t, tijuana.NetSalary, cdmx.NetSalary)
+		assert.Equal(t, tijuana.AguinaldoISR, cdmx.AguinaldoISR)
+	})
+
+	t.Run("Does not change the IMSS base", func(t *testing.T) {
+		assert.Equal(t, tijuana.SBC, cdmx.SBC)
+		assert.Equal(t, tijuana.IMSSWorker, cdmx.IMSSWorker)
+	})
+
+	t.Run("Floors the SBC at the border minimum wage", func(t *testing.T) {
+		sbc := CalculateSBC(rt, SalaryInput{GrossMonthlySalary: mxn("9000"), YearsOfService: 1, Zone: ZoneNorthBorder})
+		// 419.88 x 1.0493
+		assert.Equal(t, sbc, mxn("440.58"))
+	})
+}
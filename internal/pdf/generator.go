@@ -14,6 +14,8 @@ import (
 	"github.com/chromedp/chromedp"
 
 	"github.com/jcroyoaun/totalcompmx/internal/database"
+	"github.com/jcroyoaun/totalcompmx/internal/equity"
+	"github.com/jcroyoaun/totalcompmx/internal/payroll"
 )
 
 // OtherBenefit represents custom benefits
@@ -29,6 +31,8 @@ type OtherBenefit struct {
 type PackageInput struct {
 	Name                    string
 	Regime                  string
+	Zone                    string
+	Municipality            string
 	Currency                string
 	ExchangeRate            string
 	PaymentFrequency        string
@@ -43,7 +47,22 @@ type PackageInput struct {
 	PrimaVacacionalPercent  string
 	HasFondoAhorro          bool
 	FondoAhorroPercent      string
+	HasInfonavitCredit      bool
+	InfonavitCreditType     string
+	InfonavitCreditValue    string
+	HasPTU                  bool
+	PTUMode                 string
+	PTUValue                string
+	PTUThreeYearAverage     string
+	HasOvertime             bool
+	OvertimeHoursPerWeek    string
+	SundaysPerMonth         string
+	State                   string
 	UnpaidVacationDays      string
+	ClientType              string
+	ChargesIVA              bool
+	IVAExpenses             string
+	Expenses                []payroll.Expense
 	OtherBenefits           []OtherBenefit
 	// Equity fields
 	HasEquity               bool
@@ -51,13 +70,26 @@ type PackageInput struct {
 	HasRefreshers           bool
 	RefresherMinUSD         string
 	RefresherMaxUSD         string
+	VestingYears            string
+	VestingPercents         string
+	CliffMonths             string
+	VestFrequency           string
+	EquityWithholding       string
+	SellToCoverPercent      string
+	PriceGrowthBear         string
+	PriceGrowthBase         string
+	PriceGrowthBull         string
+	PriceVolatility         string
 }
 
 // PackageResult represents a single package's calculation results
 type PackageResult struct {
-	Name        string
-	Input       PackageInput
-	Calculation *database.SalaryCalculation
+	Name           string
+	Input          PackageInput
+	Calculation    *database.SalaryCalculation
+	EquityConfig   *equity.EquityConfig // nil without equity
+	EquitySchedule []equity.YearlyEquity
+	EquityOutlook  *equity.ValueOutlook // nil without share price scenarios
 }
 
 // ReportData represents the data passed to the PDF template
@@ -74,53 +106,64 @@ func GenerateComparisonReport(packages []PackageResult, fiscalYear database.Fisc
 		Packages: packages,
 	}
 
-	// Custom template functions
-	funcMap := template.FuncMap{
-		"formatFloat": func(f float64, decimals int) string {
+	tmpl, err := parseTemplate("report.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	// Render the template to HTML string
+	var htmlBuf bytes.Buffer
+	if err := tmpl.Execute(&htmlBuf, data); err != nil {
+		return nil, fmt.Errorf("failed to execute PDF template: %w", err)
+	}
+
+	htmlContent := htmlBuf.String()
+
+	// Generate PDF using Chrome
+	pdfBytes, err := renderHTMLToPDF(htmlContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render HTML to PDF: %w", err)
+	}
+
+	return pdfBytes, nil
+}
+
+// templateFuncs returns the functions available to the PDF templates
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"formatFloat": func(f any, decimals int) string {
 			// Format with thousands separator
-			formatted := fmt.Sprintf(fmt.Sprintf("%%.%df", decimals), f)
+			formatted := fmt.Sprintf(fmt.Sprintf("%%.%df", decimals), toFloat(f))
 			return addThousandsSeparator(formatted)
 		},
-		"div": func(a, b float64) float64 {
-			if b == 0 {
+		"div": func(a, b any) float64 {
+			if toFloat(b) == 0 {
 				return 0
 			}
-			return a / b
+			return toFloat(a) / toFloat(b)
 		},
-		"mul": func(a, b float64) float64 {
-			return a * b
+		"mul": func(a, b any) float64 {
+			return toFloat(a) * toFloat(b)
 		},
 	}
+}
 
+// parseTemplate loads a template from assets/templates/pdf
+func parseTemplate(name string) (*template.Template, error) {
 	// Find template path (works both in dev and production)
-	templatePath := "assets/templates/pdf/report.tmpl"
+	templatePath := filepath.Join("assets/templates/pdf", name)
 	if _, err := os.Stat(templatePath); os.IsNotExist(err) {
 		// Try from working directory
 		wd, _ := os.Getwd()
 		templatePath = filepath.Join(wd, templatePath)
 	}
 
-	// Parse the template
-	tmpl, err := template.New("report.tmpl").Funcs(funcMap).ParseFiles(templatePath)
+	tmpl, err := template.New(name).Funcs(templateFuncs()).ParseFiles(templatePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse PDF template at %s: %w", templatePath, err)
 	}
 
-	// Render the template to HTML string
-	var htmlBuf bytes.Buffer
-	if err := tmpl.Execute(&htmlBuf, data); err != nil {
-		return nil, fmt.Errorf("failed to execute PDF template: %w", err)
-	}
-
-	htmlContent := htmlBuf.String()
-
-	// Generate PDF using Chrome
-	pdfBytes, err := renderHTMLToPDF(htmlContent)
-	if err != nil {
-		return nil, fmt.Errorf("failed to render HTML to PDF: %w", err)
-	}
-
-	return pdfBytes, nil
+	return tmpl, nil
 }
 
 // renderHTMLToPDF uses chromedp to render HTML to PDF
@@ -216,6 +259,22 @@ func addThousandsSeparator(s string) string {
 	return finalInt + decPart
 }
 
+// toFloat converts a template number, including money amounts and rates, to a
+// float for display
+func toFloat(v any) float64 {
+	switch n := v.(type) {
+	case interface{ Float64() float64 }:
+		return n.Float64()
+	case float64:
+		return n
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	}
+	return 0
+}
+
 // reverse reverses a string
 func reverse(s string) string {
 	runes := []rune(s)
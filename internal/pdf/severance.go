@@ -0,0 +1,45 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/jcroyoaun/totalcompmx/internal/database"
+	"github.com/jcroyoaun/totalcompmx/internal/payroll"
+)
+
+// SeveranceReportData represents the data passed to the severance PDF template
+type SeveranceReportData struct {
+	Date       string
+	FiscalYear database.FiscalYear
+	Input      payroll.SeveranceInput
+	Result     payroll.Severance
+}
+
+// GenerateSeveranceReport renders a finiquito/liquidación breakdown to PDF
+func GenerateSeveranceReport(input payroll.SeveranceInput, result payroll.Severance, fiscalYear database.FiscalYear) ([]byte, error) {
+	data := SeveranceReportData{
+		Date:       time.Now().Format("02 Jan 2006"),
+		FiscalYear: fiscalYear,
+		Input:      input,
+		Result:     result,
+	}
+
+	tmpl, err := parseTemplate("severance.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	var htmlBuf bytes.Buffer
+	if err := tmpl.Execute(&htmlBuf, data); err != nil {
+		return nil, fmt.Errorf("failed to execute PDF template: %w", err)
+	}
+
+	pdfBytes, err := renderHTMLToPDF(htmlBuf.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to render HTML to PDF: %w", err)
+	}
+
+	return pdfBytes, nil
+}